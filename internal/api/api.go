@@ -47,12 +47,13 @@ func paramName(ctx *gin.Context) (string, bool) {
 func recordingsOfPath(
 	pathConf *conf.Path,
 	pathName string,
+	l logger.Writer,
 ) *defs.APIRecording {
 	ret := &defs.APIRecording{
 		Name: pathName,
 	}
 
-	segments, _ := recordstore.FindSegments(pathConf, pathName, nil, nil)
+	segments, _ := recordstore.FindSegments(pathConf, pathName, nil, nil, l)
 
 	ret.Segments = make([]*defs.APIRecordingSegment, len(segments))
 
@@ -77,27 +78,28 @@ type apiParent interface {
 
 // API is an API server.
 type API struct {
-	Version        string
-	Started        time.Time
-	Address        string
-	Encryption     bool
-	ServerKey      string
-	ServerCert     string
-	AllowOrigins   []string
-	TrustedProxies conf.IPNetworks
-	ReadTimeout    conf.Duration
-	WriteTimeout   conf.Duration
-	Conf           *conf.Conf
-	AuthManager    apiAuthManager
-	PathManager    defs.APIPathManager
-	RTSPServer     defs.APIRTSPServer
-	RTSPSServer    defs.APIRTSPServer
-	RTMPServer     defs.APIRTMPServer
-	RTMPSServer    defs.APIRTMPServer
-	HLSServer      defs.APIHLSServer
-	WebRTCServer   defs.APIWebRTCServer
-	SRTServer      defs.APISRTServer
-	Parent         apiParent
+	Version          string
+	Started          time.Time
+	Address          string
+	Encryption       bool
+	ServerKey        string
+	ServerCert       string
+	AllowOrigins     []string
+	TrustedProxies   conf.IPNetworks
+	ReadTimeout      conf.Duration
+	WriteTimeout     conf.Duration
+	Conf             *conf.Conf
+	AuthManager      apiAuthManager
+	PathManager      defs.APIPathManager
+	RTSPServer       defs.APIRTSPServer
+	RTSPSServer      defs.APIRTSPServer
+	RTMPServer       defs.APIRTMPServer
+	RTMPSServer      defs.APIRTMPServer
+	HLSServer        defs.APIHLSServer
+	WebRTCServer     defs.APIWebRTCServer
+	SRTServer        defs.APISRTServer
+	ForwarderManager defs.APIForwarderManager
+	Parent           apiParent
 
 	httpServer *httpp.Server
 	mutex      sync.RWMutex
@@ -132,6 +134,7 @@ func (a *API) Initialize() error {
 
 	group.GET("/paths/list", a.onPathsList)
 	group.GET("/paths/get/*name", a.onPathsGet)
+	group.POST("/paths/record/trigger/*name", a.onPathsRecordTrigger)
 
 	if !interfaceIsEmpty(a.HLSServer) {
 		group.GET("/hlsmuxers/list", a.onHLSMuxersList)
@@ -178,6 +181,15 @@ func (a *API) Initialize() error {
 		group.POST("/srtconns/kick/:id", a.onSRTConnsKick)
 	}
 
+	if !interfaceIsEmpty(a.ForwarderManager) {
+		group.GET("/forwarders/list", a.onForwardersList)
+		group.GET("/forwarders/get/:id", a.onForwardersGet)
+		group.POST("/forwarders/start/:id", a.onForwardersStart)
+		group.POST("/forwarders/stop/:id", a.onForwardersStop)
+		group.POST("/forwarders/restart/:id", a.onForwardersRestart)
+		group.POST("/paths/forward/*name", a.onPathsForward)
+	}
+
 	group.GET("/recordings/list", a.onRecordingsList)
 	group.GET("/recordings/get/*name", a.onRecordingsGet)
 	group.DELETE("/recordings/deletesegment", a.onRecordingDeleteSegment)
@@ -0,0 +1,116 @@
+//nolint:dupl
+package api //nolint:revive
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/conf/jsonwrapper"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/forwarder"
+)
+
+func (a *API) onForwardersList(ctx *gin.Context) {
+	data, err := a.ForwarderManager.APIForwardersList()
+	if err != nil {
+		a.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	data.ItemCount = len(data.Items)
+	pageCount, err := paginate(&data.Items, ctx.Query("itemsPerPage"), ctx.Query("page"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	data.PageCount = pageCount
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onForwardersGet(ctx *gin.Context) {
+	uuid, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := a.ForwarderManager.APIForwardersGet(uuid)
+	if err != nil {
+		if errors.Is(err, forwarder.ErrForwarderNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onForwardersAction(ctx *gin.Context, action func(uuid.UUID) error) {
+	uuid, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = action(uuid)
+	if err != nil {
+		if errors.Is(err, forwarder.ErrForwarderNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	a.writeOK(ctx)
+}
+
+func (a *API) onForwardersStart(ctx *gin.Context) {
+	a.onForwardersAction(ctx, a.ForwarderManager.APIForwardersStart)
+}
+
+func (a *API) onForwardersStop(ctx *gin.Context) {
+	a.onForwardersAction(ctx, a.ForwarderManager.APIForwardersStop)
+}
+
+func (a *API) onForwardersRestart(ctx *gin.Context) {
+	a.onForwardersAction(ctx, a.ForwarderManager.APIForwardersRestart)
+}
+
+func (a *API) onPathsForward(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	var req defs.APIForwardReq
+	err := jsonwrapper.Decode(ctx.Request.Body, &req)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := a.ForwarderManager.APIForwardersAdd(pathName, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, forwarder.ErrInvalidForwardTarget):
+			a.writeError(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, conf.ErrPathNotFound):
+			a.writeError(ctx, http.StatusNotFound, err)
+		default:
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
@@ -0,0 +1,296 @@
+package api //nolint:revive
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/forwarder"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type testForwarderManager struct {
+	forwarders map[uuid.UUID]*defs.APIForwarder
+}
+
+func (m *testForwarderManager) APIForwardersList() (*defs.APIForwarderList, error) {
+	items := make([]*defs.APIForwarder, 0, len(m.forwarders))
+	for _, f := range m.forwarders {
+		items = append(items, f)
+	}
+	return &defs.APIForwarderList{Items: items}, nil
+}
+
+func (m *testForwarderManager) APIForwardersGet(id uuid.UUID) (*defs.APIForwarder, error) {
+	f, ok := m.forwarders[id]
+	if !ok {
+		return nil, forwarder.ErrForwarderNotFound
+	}
+	return f, nil
+}
+
+func (m *testForwarderManager) setState(id uuid.UUID, state defs.APIForwarderState) error {
+	f, ok := m.forwarders[id]
+	if !ok {
+		return forwarder.ErrForwarderNotFound
+	}
+	f.State = state
+	return nil
+}
+
+func (m *testForwarderManager) APIForwardersStart(id uuid.UUID) error {
+	return m.setState(id, defs.APIForwarderStateRunning)
+}
+
+func (m *testForwarderManager) APIForwardersStop(id uuid.UUID) error {
+	return m.setState(id, defs.APIForwarderStateStopped)
+}
+
+func (m *testForwarderManager) APIForwardersRestart(id uuid.UUID) error {
+	return m.setState(id, defs.APIForwarderStateRunning)
+}
+
+func (m *testForwarderManager) APIForwardersAdd(pathName string, req *defs.APIForwardReq) (*defs.APIForwarder, error) {
+	if pathName != "mystream" {
+		return nil, conf.ErrPathNotFound
+	}
+	if req.SRT == nil {
+		return nil, forwarder.ErrInvalidForwardTarget
+	}
+
+	f := &defs.APIForwarder{
+		ID:        uuid.New(),
+		Path:      pathName,
+		Target:    req.SRT.URL,
+		Protocol:  "srt",
+		Temporary: true,
+		State:     defs.APIForwarderStateRunning,
+	}
+	m.forwarders[f.ID] = f
+	return f, nil
+}
+
+func TestForwardersList(t *testing.T) {
+	id1 := uuid.New()
+	id2 := uuid.New()
+	lastError := "connection refused"
+
+	forwarderManager := &testForwarderManager{
+		forwarders: map[uuid.UUID]*defs.APIForwarder{
+			id1: {
+				ID:          id1,
+				Path:        "stream1",
+				Target:      "srt://example.com:8890?streamid=publish:stream1",
+				Protocol:    "srt",
+				State:       defs.APIForwarderStateRunning,
+				Connected:   true,
+				Uptime:      conf.Duration(10 * time.Second),
+				BytesSent:   100000,
+				PacketsSent: 1000,
+			},
+			id2: {
+				ID:             id2,
+				Path:           "stream2",
+				Target:         "http://example.com:8889/stream2/whip",
+				Protocol:       "webrtc",
+				State:          defs.APIForwarderStateRunning,
+				ReconnectCount: 3,
+				LastError:      &lastError,
+			},
+		},
+	}
+
+	api := API{
+		Address:          "localhost:9997",
+		ReadTimeout:      conf.Duration(10 * time.Second),
+		WriteTimeout:     conf.Duration(10 * time.Second),
+		AuthManager:      test.NilAuthManager,
+		ForwarderManager: forwarderManager,
+		Parent:           &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	var out defs.APIForwarderList
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/forwarders/list", nil, &out)
+
+	require.Equal(t, 2, out.ItemCount)
+	require.Equal(t, 1, out.PageCount)
+	require.Len(t, out.Items, 2)
+}
+
+func TestForwardersGet(t *testing.T) {
+	id := uuid.New()
+	lastError := "connection refused"
+
+	forwarderManager := &testForwarderManager{
+		forwarders: map[uuid.UUID]*defs.APIForwarder{
+			id: {
+				ID:             id,
+				Path:           "mystream",
+				Target:         "rtsp://example.com:8554/mystream",
+				Protocol:       "rtsp",
+				State:          defs.APIForwarderStateRunning,
+				Connected:      true,
+				Uptime:         conf.Duration(90 * time.Second),
+				BytesSent:      888888,
+				PacketsSent:    1000,
+				PacketsLost:    2,
+				ReconnectCount: 1,
+				LastError:      &lastError,
+			},
+		},
+	}
+
+	api := API{
+		Address:          "localhost:9997",
+		ReadTimeout:      conf.Duration(10 * time.Second),
+		WriteTimeout:     conf.Duration(10 * time.Second),
+		AuthManager:      test.NilAuthManager,
+		ForwarderManager: forwarderManager,
+		Parent:           &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	var out defs.APIForwarder
+	httpRequest(t, hc, http.MethodGet, fmt.Sprintf("http://localhost:9997/v3/forwarders/get/%s", id), nil, &out)
+
+	require.Equal(t, *forwarderManager.forwarders[id], out)
+
+	res, err := hc.Get(fmt.Sprintf("http://localhost:9997/v3/forwarders/get/%s", uuid.New()))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	checkError(t, res.Body, "forwarder not found")
+}
+
+func TestForwardersStartStopRestart(t *testing.T) {
+	id := uuid.New()
+
+	forwarderManager := &testForwarderManager{
+		forwarders: map[uuid.UUID]*defs.APIForwarder{
+			id: {
+				ID:       id,
+				Path:     "mystream",
+				Target:   "rtmp://example.com/live/mystream",
+				Protocol: "rtmp",
+				State:    defs.APIForwarderStateRunning,
+			},
+		},
+	}
+
+	api := API{
+		Address:          "localhost:9997",
+		ReadTimeout:      conf.Duration(10 * time.Second),
+		WriteTimeout:     conf.Duration(10 * time.Second),
+		AuthManager:      test.NilAuthManager,
+		ForwarderManager: forwarderManager,
+		Parent:           &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	httpRequest(t, hc, http.MethodPost, fmt.Sprintf("http://localhost:9997/v3/forwarders/stop/%s", id), nil, nil)
+	require.Equal(t, defs.APIForwarderStateStopped, forwarderManager.forwarders[id].State)
+
+	httpRequest(t, hc, http.MethodPost, fmt.Sprintf("http://localhost:9997/v3/forwarders/start/%s", id), nil, nil)
+	require.Equal(t, defs.APIForwarderStateRunning, forwarderManager.forwarders[id].State)
+
+	httpRequest(t, hc, http.MethodPost, fmt.Sprintf("http://localhost:9997/v3/forwarders/stop/%s", id), nil, nil)
+	httpRequest(t, hc, http.MethodPost, fmt.Sprintf("http://localhost:9997/v3/forwarders/restart/%s", id), nil, nil)
+	require.Equal(t, defs.APIForwarderStateRunning, forwarderManager.forwarders[id].State)
+}
+
+func TestPathsForward(t *testing.T) {
+	forwarderManager := &testForwarderManager{
+		forwarders: map[uuid.UUID]*defs.APIForwarder{},
+	}
+
+	api := API{
+		Address:          "localhost:9997",
+		ReadTimeout:      conf.Duration(10 * time.Second),
+		WriteTimeout:     conf.Duration(10 * time.Second),
+		AuthManager:      test.NilAuthManager,
+		ForwarderManager: forwarderManager,
+		Parent:           &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	var out defs.APIForwarder
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/paths/forward/mystream", map[string]any{
+		"srt": map[string]any{
+			"url": "srt://example.com:8890?streamid=publish:mystream",
+		},
+	}, &out)
+
+	require.Equal(t, "srt://example.com:8890?streamid=publish:mystream", out.Target)
+	require.True(t, out.Temporary)
+	require.Equal(t, *forwarderManager.forwarders[out.ID], out)
+
+	for _, ca := range []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{
+			"unknown field",
+			"mystream",
+			`{"ftp":{}}`,
+			http.StatusBadRequest,
+		},
+		{
+			"invalid target",
+			"mystream",
+			`{}`,
+			http.StatusBadRequest,
+		},
+		{
+			"path not found",
+			"otherstream",
+			`{"srt":{"url":"srt://example.com:8890"}}`,
+			http.StatusNotFound,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "http://localhost:9997/v3/paths/forward/"+ca.path,
+				strings.NewReader(ca.body))
+			require.NoError(t, err)
+
+			res, err := hc.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			require.Equal(t, ca.status, res.StatusCode)
+		})
+	}
+}
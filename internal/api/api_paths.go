@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/gin-gonic/gin"
 )
 
@@ -47,3 +49,43 @@ func (a *API) onPathsGet(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, data)
 }
+
+func (a *API) onPathsRecordTrigger(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	var req defs.APIPathRecordTriggerReq
+
+	if v := ctx.Query("before"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid 'before' parameter: %w", err))
+			return
+		}
+		req.Before = &d
+	}
+
+	if v := ctx.Query("after"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid 'after' parameter: %w", err))
+			return
+		}
+		req.After = &d
+	}
+
+	err := a.PathManager.APIPathsRecordTrigger(pathName, &req)
+	if err != nil {
+		if errors.Is(err, conf.ErrPathNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	a.writeOK(ctx)
+}
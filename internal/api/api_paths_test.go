@@ -12,7 +12,8 @@ import (
 )
 
 type testPathManager struct {
-	paths map[string]*defs.APIPath
+	paths    map[string]*defs.APIPath
+	triggers []*defs.APIPathRecordTriggerReq
 }
 
 func (m *testPathManager) APIPathsList() (*defs.APIPathList, error) {
@@ -31,6 +32,14 @@ func (m *testPathManager) APIPathsGet(name string) (*defs.APIPath, error) {
 	return path, nil
 }
 
+func (m *testPathManager) APIPathsRecordTrigger(name string, req *defs.APIPathRecordTriggerReq) error {
+	if _, ok := m.paths[name]; !ok {
+		return conf.ErrPathNotFound
+	}
+	m.triggers = append(m.triggers, req)
+	return nil
+}
+
 func TestPathsList(t *testing.T) {
 	now := time.Now()
 	pathManager := &testPathManager{
@@ -134,3 +143,69 @@ func TestPathsGet(t *testing.T) {
 	require.Equal(t, uint64(123456), out.BytesReceived)
 	require.Equal(t, uint64(789012), out.BytesSent)
 }
+
+func TestPathsRecordTrigger(t *testing.T) {
+	pathManager := &testPathManager{
+		paths: map[string]*defs.APIPath{
+			"mystream": {
+				Name:     "mystream",
+				ConfName: "mystream",
+			},
+		},
+	}
+
+	api := API{
+		Address:      "localhost:9997",
+		ReadTimeout:  conf.Duration(10 * time.Second),
+		WriteTimeout: conf.Duration(10 * time.Second),
+		AuthManager:  test.NilAuthManager,
+		PathManager:  pathManager,
+		Parent:       &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/paths/record/trigger/mystream?before=5s&after=20s", nil, nil)
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/paths/record/trigger/mystream", nil, nil)
+
+	require.Equal(t, []*defs.APIPathRecordTriggerReq{
+		{
+			Before: ptrOf(5 * time.Second),
+			After:  ptrOf(20 * time.Second),
+		},
+		{},
+	}, pathManager.triggers)
+
+	for _, ca := range []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{
+			"not found",
+			"http://localhost:9997/v3/paths/record/trigger/otherstream",
+			http.StatusNotFound,
+		},
+		{
+			"invalid duration",
+			"http://localhost:9997/v3/paths/record/trigger/mystream?before=abc",
+			http.StatusBadRequest,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			req, err2 := http.NewRequest(http.MethodPost, ca.url, nil)
+			require.NoError(t, err2)
+
+			res, err2 := hc.Do(req)
+			require.NoError(t, err2)
+			defer res.Body.Close()
+
+			require.Equal(t, ca.status, res.StatusCode)
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package api //nolint:revive
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"strings"
@@ -9,6 +12,7 @@ import (
 
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/protocols/s3"
 	"github.com/bluenviron/mediamtx/internal/recordstore"
 	"github.com/gin-gonic/gin"
 )
@@ -34,7 +38,7 @@ func (a *API) onRecordingsList(ctx *gin.Context) {
 
 	for i, pathName := range pathNames {
 		pathConf, _, _ := conf.FindPathConf(c.Paths, pathName)
-		data.Items[i] = recordingsOfPath(pathConf, pathName)
+		data.Items[i] = recordingsOfPath(pathConf, pathName, a)
 	}
 
 	ctx.JSON(http.StatusOK, data)
@@ -57,7 +61,7 @@ func (a *API) onRecordingsGet(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, recordingsOfPath(pathConf, pathName))
+	ctx.JSON(http.StatusOK, recordingsOfPath(pathConf, pathName, a))
 }
 
 func (a *API) onRecordingDeleteSegment(ctx *gin.Context) {
@@ -89,10 +93,33 @@ func (a *API) onRecordingDeleteSegment(ctx *gin.Context) {
 	}.Encode(pathFormat)
 
 	err = os.Remove(segmentPath)
-	if err != nil {
+	if err != nil && (pathConf.RecordUploadURL == "" || !errors.Is(err, fs.ErrNotExist)) {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
+	localRemoved := (err == nil)
+
+	if pathConf.RecordUploadURL != "" {
+		err = deleteRemoteSegment(ctx.Request.Context(), pathConf, segmentPath)
+		if err != nil && (!localRemoved || !errors.Is(err, s3.ErrObjectNotFound)) {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
 
 	a.writeOK(ctx)
 }
+
+func deleteRemoteSegment(ctx context.Context, pathConf *conf.Path, segmentPath string) error {
+	client, _, err := recordstore.RemoteClient(pathConf)
+	if err != nil {
+		return err
+	}
+
+	key, err := recordstore.RemoteKey(pathConf, segmentPath)
+	if err != nil {
+		return err
+	}
+
+	return client.DeleteObject(ctx, key)
+}
@@ -18,6 +18,10 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func ptrOf[T any](v T) *T {
+	return &v
+}
+
 type testParent struct {
 	log func(_ logger.Level, _ string, _ ...any)
 }
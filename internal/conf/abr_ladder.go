@@ -0,0 +1,137 @@
+package conf
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ABRLadderLayer is the layer name of the simulcast path generated by an ABR ladder
+// (i.e. "live/cam~abr").
+const ABRLadderLayer = "abr"
+
+const defaultABRLadderPreset = "veryfast"
+
+var reResolution = regexp.MustCompile(`^[0-9]+x[0-9]+$`)
+
+// ABRLadderRendition is a rendition of an ABR ladder.
+type ABRLadderRendition struct {
+	// Simulcast layer: "high", "medium" or "low"
+	Layer string `json:"layer"`
+
+	// Resolution in format "WIDTHxHEIGHT" (e.g., "1280x720")
+	Resolution string `json:"resolution"`
+
+	// Bitrate in bps (e.g., 1000000 for 1Mbps)
+	Bitrate uint `json:"bitrate"`
+
+	// Framerate in fps (e.g., 30)
+	Framerate uint `json:"framerate"`
+
+	// FFmpeg preset (default "veryfast")
+	Preset string `json:"preset"`
+}
+
+func (pconf *Path) validateABRLadder() error {
+	if pconf.SRTTranscoding != nil && pconf.SRTTranscoding.Enable {
+		return fmt.Errorf("'abrLadder' and 'srtTranscoding' cannot be used together")
+	}
+
+	layers := make(map[string]struct{})
+
+	for i, rendition := range pconf.ABRLadder {
+		if rendition.Layer != "high" && rendition.Layer != "medium" && rendition.Layer != "low" {
+			return fmt.Errorf("abrLadder[%d]: layer must be 'high', 'medium', or 'low'", i)
+		}
+
+		if _, ok := layers[rendition.Layer]; ok {
+			return fmt.Errorf("abrLadder[%d]: duplicate layer '%s'", i, rendition.Layer)
+		}
+		layers[rendition.Layer] = struct{}{}
+
+		if !reResolution.MatchString(rendition.Resolution) {
+			return fmt.Errorf("abrLadder[%d]: invalid resolution '%s'", i, rendition.Resolution)
+		}
+
+		if rendition.Bitrate == 0 {
+			return fmt.Errorf("abrLadder[%d]: bitrate cannot be zero", i)
+		}
+
+		if rendition.Framerate == 0 {
+			return fmt.Errorf("abrLadder[%d]: framerate cannot be zero", i)
+		}
+	}
+
+	return nil
+}
+
+// ABRLadderTranscoding returns the transcoding configuration that produces
+// the renditions of the ABR ladder, with a single FFmpeg process.
+func (pconf *Path) ABRLadderTranscoding() *SRTTranscodingConfig {
+	tconf := &SRTTranscodingConfig{
+		Enable: true,
+		Ladder: true,
+	}
+
+	for _, rendition := range pconf.ABRLadder {
+		preset := rendition.Preset
+		if preset == "" {
+			preset = defaultABRLadderPreset
+		}
+
+		tconf.Outputs = append(tconf.Outputs, SRTTranscodingOutput{
+			Path: rendition.Layer,
+			Type: "video",
+			Video: &SRTTranscodingVideoConfig{
+				Resolution: rendition.Resolution,
+				Bitrate:    rendition.Bitrate,
+				Framerate:  rendition.Framerate,
+				Preset:     preset,
+			},
+		})
+	}
+
+	return tconf
+}
+
+// ABRLadderPaths returns the configurations of the paths generated by the ABR ladder
+// of a path: a path for each rendition, fed by the transcoder, and a simulcast path
+// that groups them.
+func (pconf *Path) ABRLadderPaths(pathName string) []*Path {
+	simulcastConf := &Path{}
+	simulcastConf.setDefaults()
+	simulcastConf.Name = SimulcastLayerPath(pathName, ABRLadderLayer)
+	simulcastConf.Source = "simulcast"
+	simulcastConf.SimulcastConfig = &SimulcastConfig{
+		Enable: true,
+		ABR:    true,
+	}
+
+	ret := []*Path{}
+
+	for _, rendition := range pconf.ABRLadder {
+		renditionConf := &Path{}
+		renditionConf.setDefaults()
+		renditionConf.Name = SimulcastLayerPath(pathName, rendition.Layer)
+		renditionConf.Source = "transcoder:" + pathName + ":" + rendition.Layer
+		ret = append(ret, renditionConf)
+
+		simulcastConf.SimulcastConfig.Inputs = append(simulcastConf.SimulcastConfig.Inputs, SimulcastInput{
+			Path:       renditionConf.Name,
+			Layer:      rendition.Layer,
+			Resolution: rendition.Resolution,
+			Bitrate:    rendition.Bitrate,
+			Type:       "video",
+		})
+	}
+
+	// renditions contain the same audio track
+	if len(ret) != 0 {
+		simulcastConf.SimulcastConfig.Inputs = append(simulcastConf.SimulcastConfig.Inputs, SimulcastInput{
+			Path:    ret[0].Name,
+			Bitrate: 64000,
+			Type:    "audio",
+		})
+	}
+
+	return append(ret, simulcastConf)
+}
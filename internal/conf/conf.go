@@ -150,20 +150,21 @@ var defaultAuthInternalUsers = AuthInternalUsers{
 // WARNING: Avoid using slices directly due to https://github.com/golang/go/issues/21092
 type Conf struct {
 	// General
-	LogLevel            LogLevel        `json:"logLevel"`
-	LogDestinations     LogDestinations `json:"logDestinations"`
-	LogStructured       bool            `json:"logStructured"`
-	LogFile             string          `json:"logFile"`
-	SysLogPrefix        string          `json:"sysLogPrefix"`
-	ReadTimeout         Duration        `json:"readTimeout"`
-	WriteTimeout        Duration        `json:"writeTimeout"`
-	ReadBufferCount     *int            `json:"readBufferCount,omitempty"` // deprecated
-	WriteQueueSize      int             `json:"writeQueueSize"`
-	UDPMaxPayloadSize   int             `json:"udpMaxPayloadSize"`
-	UDPReadBufferSize   uint            `json:"udpReadBufferSize"`
-	RunOnConnect        string          `json:"runOnConnect"`
-	RunOnConnectRestart bool            `json:"runOnConnectRestart"`
-	RunOnDisconnect     string          `json:"runOnDisconnect"`
+	LogLevel               LogLevel        `json:"logLevel"`
+	LogDestinations        LogDestinations `json:"logDestinations"`
+	LogStructured          bool            `json:"logStructured"`
+	LogFile                string          `json:"logFile"`
+	SysLogPrefix           string          `json:"sysLogPrefix"`
+	ReadTimeout            Duration        `json:"readTimeout"`
+	WriteTimeout           Duration        `json:"writeTimeout"`
+	ReadBufferCount        *int            `json:"readBufferCount,omitempty"` // deprecated
+	WriteQueueSize         int             `json:"writeQueueSize"`
+	UDPMaxPayloadSize      int             `json:"udpMaxPayloadSize"`
+	UDPReadBufferSize      uint            `json:"udpReadBufferSize"`
+	TranscoderMaxProcesses int             `json:"transcoderMaxProcesses"`
+	RunOnConnect           string          `json:"runOnConnect"`
+	RunOnConnectRestart    bool            `json:"runOnConnectRestart"`
+	RunOnDisconnect        string          `json:"runOnDisconnect"`
 
 	// Authentication
 	AuthMethod                AuthMethod                  `json:"authMethod"`
@@ -548,6 +549,10 @@ func (conf *Conf) Validate(l logger.Writer) error {
 		return fmt.Errorf("'udpMaxPayloadSize' must be less than 1472")
 	}
 
+	if conf.TranscoderMaxProcesses < 0 {
+		return fmt.Errorf("'transcoderMaxProcesses' must be greater than or equal to zero")
+	}
+
 	// Authentication
 
 	if conf.ExternalAuthenticationURL != nil {
@@ -58,6 +58,9 @@ func TestConfFromFile(t *testing.T) {
 			RecordMaxPartSize:            50 * 1024 * 1024,
 			RecordSegmentDuration:        3600000000000,
 			RecordDeleteAfter:            86400000000000,
+			RecordUploadRegion:           "us-east-1",
+			RecordTriggerBefore:          10 * Duration(time.Second),
+			RecordTriggerAfter:           30 * Duration(time.Second),
 			OverridePublisher:            true,
 			RPICameraWidth:               1920,
 			RPICameraHeight:              1080,
@@ -438,6 +441,154 @@ func TestConfErrors(t *testing.T) {
 				"    recordDeleteAfter: 20m\n",
 			`'recordDeleteAfter' cannot be lower than 'recordSegmentDuration'`,
 		},
+		{
+			"invalid record upload url",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    recordUploadURL: ftp://localhost/mybucket\n",
+			`'recordUploadURL' must be a HTTP or HTTPS URL`,
+		},
+		{
+			"record upload url without bucket",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    recordUploadURL: http://localhost:9000\n",
+			`'recordUploadURL' must contain the bucket name`,
+		},
+		{
+			"record upload keep local without url",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    recordUploadKeepLocal: 1h\n",
+			`'recordUploadKeepLocal' requires 'recordUploadURL'`,
+		},
+		{
+			"record on trigger with mpegts",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    recordFormat: mpegts\n" +
+				"    recordOnTrigger: yes\n",
+			`'recordOnTrigger' requires 'recordFormat' to be fmp4`,
+		},
+		{
+			"record trigger command without record on trigger",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    recordTriggerCommand: ./detect.sh\n",
+			`'recordTriggerCommand' requires 'recordOnTrigger'`,
+		},
+		{
+			"invalid rtsp forward target url",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    rtspForwardTargets:\n" +
+				"    - url: http://localhost/mypath\n",
+			`rtspForwardTargets[0]: invalid RTSP URL: unsupported scheme 'http'`,
+		},
+		{
+			"invalid rtsp forward target transport",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    rtspForwardTargets:\n" +
+				"    - url: rtsp://localhost/mypath\n" +
+				"      transport: multicast\n",
+			`rtspForwardTargets[0]: multicast transport can't be used to publish`,
+		},
+		{
+			"invalid rtmp forward target url",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    rtmpForwardTargets:\n" +
+				"    - url: http://localhost/mypath\n",
+			`rtmpForwardTargets[0]: url scheme must be 'rtmp' or 'rtmps'`,
+		},
+		{
+			"invalid srt forward target medias",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    srtForwardTargets:\n" +
+				"    - url: srt://localhost:8890?streamid=publish:mypath\n" +
+				"      medias: subtitles\n",
+			`srtForwardTargets[0]: invalid medias: 'subtitles'. Use 'all', 'video', 'audio' or a list of track indexes`,
+		},
+		{
+			"webrtc forward target fallback path without max bitrate",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    webrtcForwardTargets:\n" +
+				"    - url: http://localhost:8889/mypath/whip\n" +
+				"      maxBitrateFallbackPath: my_path_low\n",
+			`webrtcForwardTargets[0]: maxBitrateFallbackPath requires maxBitrate`,
+		},
+		{
+			"srt forward target with source path and layer",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    srtForwardTargets:\n" +
+				"    - url: srt://localhost:8890?streamid=publish:mypath\n" +
+				"      sourcePath: other_path\n" +
+				"      layer: low\n",
+			`srtForwardTargets[0]: sourcePath and layer cannot be used together`,
+		},
+		{
+			"srt forward target with max buffer duration and without drop until keyframe",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    srtForwardTargets:\n" +
+				"    - url: srt://localhost:8890?streamid=publish:mypath\n" +
+				"      maxBufferDuration: 1s\n",
+			`srtForwardTargets[0]: maxBufferDuration requires dropUntilKeyframe`,
+		},
+		{
+			"srt forward target with invalid mode",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    srtForwardTargets:\n" +
+				"    - url: srt://localhost:8890?streamid=publish:mypath\n" +
+				"      mode: rendezvous\n",
+			`srtForwardTargets[0]: invalid mode: 'rendezvous'`,
+		},
+		{
+			"srt forward target in listener mode with redundant urls",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    srtForwardTargets:\n" +
+				"    - url: srt://:8891\n" +
+				"      mode: listener\n" +
+				"      redundantURLs: [srt://localhost:8890]\n",
+			`srtForwardTargets[0]: redundantURLs cannot be used in listener mode`,
+		},
+		{
+			"webrtc forward target with invalid ice transport policy",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    webrtcForwardTargets:\n" +
+				"    - url: http://localhost:8889/mypath/whip\n" +
+				"      iceTransportPolicy: none\n",
+			`webrtcForwardTargets[0]: invalid iceTransportPolicy: 'none'`,
+		},
+		{
+			"invalid abr ladder layer",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    abrLadder:\n" +
+				"    - layer: highest\n" +
+				"      resolution: 1280x720\n" +
+				"      bitrate: 2000000\n" +
+				"      framerate: 30\n",
+			`abrLadder[0]: layer must be 'high', 'medium', or 'low'`,
+		},
+		{
+			"invalid abr ladder resolution",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    abrLadder:\n" +
+				"    - layer: high\n" +
+				"      resolution: 720p\n" +
+				"      bitrate: 2000000\n" +
+				"      framerate: 30\n",
+			`abrLadder[0]: invalid resolution '720p'`,
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			tmpf, err := createTempFile([]byte(ca.conf))
@@ -509,3 +660,89 @@ func TestConfOverrideDefaultSlices(t *testing.T) {
 		{},
 	}, conf.AuthHTTPExclude)
 }
+
+func TestSimulcastLayerConf(t *testing.T) {
+	tmpf, err := createTempFile([]byte(
+		"paths:\n" +
+			"  live/cam:\n" +
+			"    maxReaders: 5\n" +
+			"    record: yes\n" +
+			"    runOnReady: ls\n" +
+			"    rtmpForwardTargets:\n" +
+			"      - url: rtmp://localhost/other\n"))
+	require.NoError(t, err)
+	defer os.Remove(tmpf)
+
+	conf, _, err := Load(tmpf, nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = FindPathConf(conf.Paths, "live/cam~h")
+	require.EqualError(t, err, "path 'live/cam~h' is not configured")
+
+	require.Len(t, conf.Paths["live/cam"].RTMPForwardTargets, 1)
+
+	layerConf := conf.Paths["live/cam"].SimulcastLayerConf("live/cam~h")
+	require.Equal(t, "live/cam~h", layerConf.Name)
+	require.Equal(t, "publisher", layerConf.Source)
+	require.Equal(t, 5, layerConf.MaxReaders)
+	require.False(t, layerConf.Record)
+	require.Empty(t, layerConf.RunOnReady)
+	require.Empty(t, layerConf.RTMPForwardTargets)
+}
+
+func TestABRLadderPaths(t *testing.T) {
+	tmpf, err := createTempFile([]byte(
+		"paths:\n" +
+			"  live/cam:\n" +
+			"    abrLadder:\n" +
+			"    - layer: high\n" +
+			"      resolution: 1280x720\n" +
+			"      bitrate: 2000000\n" +
+			"      framerate: 30\n" +
+			"    - layer: low\n" +
+			"      resolution: 640x360\n" +
+			"      bitrate: 500000\n" +
+			"      framerate: 15\n"))
+	require.NoError(t, err)
+	defer os.Remove(tmpf)
+
+	conf, _, err := Load(tmpf, nil, nil)
+	require.NoError(t, err)
+
+	pathConfs := conf.Paths["live/cam"].ABRLadderPaths("live/cam")
+	require.Len(t, pathConfs, 3)
+
+	require.Equal(t, "live/cam~high", pathConfs[0].Name)
+	require.Equal(t, "transcoder:live/cam:high", pathConfs[0].Source)
+	require.Equal(t, "live/cam~low", pathConfs[1].Name)
+	require.Equal(t, "transcoder:live/cam:low", pathConfs[1].Source)
+
+	require.Equal(t, "live/cam~abr", pathConfs[2].Name)
+	require.Equal(t, "simulcast", pathConfs[2].Source)
+	require.Equal(t, []SimulcastInput{
+		{
+			Path:       "live/cam~high",
+			Layer:      "high",
+			Resolution: "1280x720",
+			Bitrate:    2000000,
+			Type:       "video",
+		},
+		{
+			Path:       "live/cam~low",
+			Layer:      "low",
+			Resolution: "640x360",
+			Bitrate:    500000,
+			Type:       "video",
+		},
+		{
+			Path:    "live/cam~high",
+			Bitrate: 64000,
+			Type:    "audio",
+		},
+	}, pathConfs[2].SimulcastConfig.Inputs)
+
+	tconf := conf.Paths["live/cam"].ABRLadderTranscoding()
+	require.True(t, tconf.Ladder)
+	require.Len(t, tconf.Outputs, 2)
+	require.Equal(t, "veryfast", tconf.Outputs[0].Video.Preset)
+}
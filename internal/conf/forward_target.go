@@ -0,0 +1,263 @@
+package conf
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	srt "github.com/datarhei/gosrt"
+)
+
+// Validate checks the target configuration.
+func (t SRTForwardTarget) Validate() error {
+	if t.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	// validate URL format
+	srtConf := srt.DefaultConfig()
+	_, err := srtConf.UnmarshalURL(t.URL)
+	if err != nil {
+		return fmt.Errorf("invalid SRT URL: %w", err)
+	}
+
+	if t.Reconnect && t.ReconnectDelay <= 0 {
+		return fmt.Errorf("reconnectDelay must be > 0 when reconnect is enabled")
+	}
+
+	if t.Passphrase != "" {
+		err := checkSRTPassphrase(t.Passphrase)
+		if err != nil {
+			return fmt.Errorf("invalid passphrase: %w", err)
+		}
+	}
+
+	err = checkForwardMediasAndBitrate(t.Medias, t.MaxBitrate, t.MaxBitrateFallbackPath)
+	if err != nil {
+		return err
+	}
+
+	err = checkForwardSource(t.SourcePath, t.Layer, t.MaxBitrate)
+	if err != nil {
+		return err
+	}
+
+	if t.MaxBufferDuration != 0 && !t.DropUntilKeyframe {
+		return fmt.Errorf("maxBufferDuration requires dropUntilKeyframe")
+	}
+
+	switch t.Mode {
+	case "", "caller":
+
+	case "listener":
+		if len(t.RedundantURLs) != 0 {
+			return fmt.Errorf("redundantURLs cannot be used in listener mode")
+		}
+
+	default:
+		return fmt.Errorf("invalid mode: '%s'", t.Mode)
+	}
+
+	for _, u := range t.RedundantURLs {
+		_, err = srtConf.UnmarshalURL(u)
+		if err != nil {
+			return fmt.Errorf("invalid redundant SRT URL: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the target configuration.
+func (t WebRTCForwardTarget) Validate() error {
+	if t.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	// validate URL format
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url scheme must be 'http' or 'https'")
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("url host must be provided")
+	}
+
+	if !strings.HasSuffix(u.Path, "/whip") {
+		return fmt.Errorf("url path must end with '/whip'")
+	}
+
+	for _, server := range t.ICEServers {
+		if !strings.HasPrefix(server.URL, "stun:") &&
+			!strings.HasPrefix(server.URL, "turn:") &&
+			!strings.HasPrefix(server.URL, "turns:") {
+			return fmt.Errorf("invalid ICE server: '%s'", server.URL)
+		}
+	}
+
+	switch t.ICETransportPolicy {
+	case "", "all", "relay":
+
+	default:
+		return fmt.Errorf("invalid iceTransportPolicy: '%s'", t.ICETransportPolicy)
+	}
+
+	if t.Reconnect && t.ReconnectDelay <= 0 {
+		return fmt.Errorf("reconnectDelay must be > 0 when reconnect is enabled")
+	}
+
+	err = checkForwardMediasAndBitrate(t.Medias, t.MaxBitrate, t.MaxBitrateFallbackPath)
+	if err != nil {
+		return err
+	}
+
+	err = checkForwardSource(t.SourcePath, t.Layer, t.MaxBitrate)
+	if err != nil {
+		return err
+	}
+
+	if t.MaxBufferDuration != 0 && !t.DropUntilKeyframe {
+		return fmt.Errorf("maxBufferDuration requires dropUntilKeyframe")
+	}
+
+	return nil
+}
+
+// Validate checks the target configuration.
+func (t RTSPForwardTarget) Validate() error {
+	if t.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	// validate URL format
+	u, err := base.ParseURL(t.URL)
+	if err != nil {
+		return fmt.Errorf("invalid RTSP URL: %w", err)
+	}
+
+	if t.Transport.Protocol != nil && *t.Transport.Protocol == gortsplib.ProtocolUDPMulticast {
+		return fmt.Errorf("multicast transport can't be used to publish")
+	}
+
+	if t.Fingerprint != "" && u.Scheme != "rtsps" {
+		return fmt.Errorf("fingerprint can only be used with 'rtsps'")
+	}
+
+	if t.Reconnect && t.ReconnectDelay <= 0 {
+		return fmt.Errorf("reconnectDelay must be > 0 when reconnect is enabled")
+	}
+
+	return nil
+}
+
+// Validate checks the target configuration.
+func (t RTMPForwardTarget) Validate() error {
+	if t.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	// validate URL format
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if u.Scheme != "rtmp" && u.Scheme != "rtmps" {
+		return fmt.Errorf("url scheme must be 'rtmp' or 'rtmps'")
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("url host must be provided")
+	}
+
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		user := u.User.Username()
+		if user != "" && pass == "" ||
+			user == "" && pass != "" {
+			return fmt.Errorf("username and password must be both provided")
+		}
+	}
+
+	if t.Fingerprint != "" && u.Scheme != "rtmps" {
+		return fmt.Errorf("fingerprint can only be used with 'rtmps'")
+	}
+
+	if t.Reconnect && t.ReconnectDelay <= 0 {
+		return fmt.Errorf("reconnectDelay must be > 0 when reconnect is enabled")
+	}
+
+	return nil
+}
+
+func checkForwardMediasAndBitrate(medias string, maxBitrate uint, fallbackPath string) error {
+	switch medias {
+	case "", "all", "video", "audio":
+
+	default:
+		for _, v := range strings.Split(medias, ",") {
+			_, err := strconv.ParseUint(strings.TrimSpace(v), 10, 31)
+			if err != nil {
+				return fmt.Errorf("invalid medias: '%s'. Use 'all', 'video', 'audio' or a list of track indexes", medias)
+			}
+		}
+	}
+
+	if fallbackPath != "" {
+		if maxBitrate == 0 {
+			return fmt.Errorf("maxBitrateFallbackPath requires maxBitrate")
+		}
+
+		err := IsValidPathName(fallbackPath)
+		if err != nil {
+			return fmt.Errorf("invalid maxBitrateFallbackPath: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func checkForwardSource(sourcePath string, layer string, maxBitrate uint) error {
+	if sourcePath == "" && layer == "" {
+		return nil
+	}
+
+	if sourcePath != "" && layer != "" {
+		return fmt.Errorf("sourcePath and layer cannot be used together")
+	}
+
+	if maxBitrate != 0 {
+		return fmt.Errorf("maxBitrate cannot be used together with sourcePath or layer")
+	}
+
+	if sourcePath != "" {
+		err := IsValidPathName(strings.ReplaceAll(sourcePath, "$MTX_PATH", "path"))
+		if err != nil {
+			return fmt.Errorf("invalid sourcePath: %w", err)
+		}
+	}
+
+	if layer != "" && (strings.Contains(layer, "/") || strings.Contains(layer, SimulcastLayerSeparator) ||
+		IsValidPathName(layer) != nil) {
+		return fmt.Errorf("invalid layer: '%s'", layer)
+	}
+
+	return nil
+}
+
+// ForwardTargetsEqual checks whether two configurations have the same forward targets.
+func (pconf *Path) ForwardTargetsEqual(other *Path) bool {
+	return reflect.DeepEqual(pconf.SRTForwardTargets, other.SRTForwardTargets) &&
+		reflect.DeepEqual(pconf.WebRTCForwardTargets, other.WebRTCForwardTargets) &&
+		reflect.DeepEqual(pconf.RTSPForwardTargets, other.RTSPForwardTargets) &&
+		reflect.DeepEqual(pconf.RTMPForwardTargets, other.RTMPForwardTargets)
+}
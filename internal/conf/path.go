@@ -11,7 +11,6 @@ import (
 	"strings"
 	"time"
 
-	srt "github.com/datarhei/gosrt"
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 	"github.com/bluenviron/mediamtx/internal/logger"
 )
@@ -133,6 +132,19 @@ type Path struct {
 	RecordSegmentDuration Duration     `json:"recordSegmentDuration"`
 	RecordDeleteAfter     Duration     `json:"recordDeleteAfter"`
 
+	// Record upload
+	RecordUploadURL             string   `json:"recordUploadURL"`
+	RecordUploadRegion          string   `json:"recordUploadRegion"`
+	RecordUploadAccessKeyID     string   `json:"recordUploadAccessKeyID"`
+	RecordUploadSecretAccessKey string   `json:"recordUploadSecretAccessKey"`
+	RecordUploadKeepLocal       Duration `json:"recordUploadKeepLocal"`
+
+	// Record trigger
+	RecordOnTrigger      bool     `json:"recordOnTrigger"`
+	RecordTriggerBefore  Duration `json:"recordTriggerBefore"`
+	RecordTriggerAfter   Duration `json:"recordTriggerAfter"`
+	RecordTriggerCommand string   `json:"recordTriggerCommand"`
+
 	// Authentication (deprecated)
 	PublishUser *Credential `json:"publishUser,omitempty"` // deprecated
 	PublishPass *Credential `json:"publishPass,omitempty"` // deprecated
@@ -236,6 +248,7 @@ type Path struct {
 	RunOnUnread                string   `json:"runOnUnread"`
 	RunOnRecordSegmentCreate   string   `json:"runOnRecordSegmentCreate"`
 	RunOnRecordSegmentComplete string   `json:"runOnRecordSegmentComplete"`
+	RunOnForwardFailure        string   `json:"runOnForwardFailure"`
 
 	// SRT Forwarding
 	SRTForwardTargets []SRTForwardTarget `json:"srtForwardTargets"`
@@ -243,11 +256,20 @@ type Path struct {
 	// WebRTC Forwarding
 	WebRTCForwardTargets []WebRTCForwardTarget `json:"webrtcForwardTargets"`
 
+	// RTSP Forwarding
+	RTSPForwardTargets []RTSPForwardTarget `json:"rtspForwardTargets"`
+
+	// RTMP Forwarding
+	RTMPForwardTargets []RTMPForwardTarget `json:"rtmpForwardTargets"`
+
 	// SRT Transcoding
 	SRTTranscoding *SRTTranscodingConfig `json:"srtTranscoding,omitempty"`
 
 	// Simulcast WebRTC
 	SimulcastConfig *SimulcastConfig `json:"simulcastConfig,omitempty"`
+
+	// ABR ladder
+	ABRLadder []ABRLadderRendition `json:"abrLadder,omitempty"`
 }
 
 // SRTForwardTarget is a SRT forward target configuration.
@@ -267,6 +289,23 @@ type SRTForwardTarget struct {
 	Passphrase string `json:"passphrase,omitempty"` // SRT passphrase
 	Latency    uint   `json:"latency"`               // Latency in milliseconds, default 120
 	PacketSize uint   `json:"packetSize"`            // Packet size, default 1316
+
+	// Track selection and bitrate limit
+	Medias                 string `json:"medias,omitempty"`                 // all, video, audio or track indexes
+	MaxBitrate             uint   `json:"maxBitrate,omitempty"`             // Maximum bitrate of the source in bit/s
+	MaxBitrateFallbackPath string `json:"maxBitrateFallbackPath,omitempty"` // Path forwarded when maxBitrate is exceeded
+
+	// Source selection
+	SourcePath string `json:"sourcePath,omitempty"` // Path forwarded in place of the path itself
+	Layer      string `json:"layer,omitempty"`      // Simulcast layer forwarded in place of the path itself
+
+	// Output buffering
+	DropUntilKeyframe bool     `json:"dropUntilKeyframe,omitempty"` // Drop whole GOPs when the output is late
+	MaxBufferDuration Duration `json:"maxBufferDuration,omitempty"` // Maximum delay of the output, default 2s
+
+	// Connection mode and redundancy
+	Mode          string   `json:"mode,omitempty"`          // caller (default) or listener
+	RedundantURLs []string `json:"redundantURLs,omitempty"` // Additional destinations that receive the same stream
 }
 
 // WebRTCForwardTarget is a WebRTC forward target configuration.
@@ -284,6 +323,62 @@ type WebRTCForwardTarget struct {
 
 	// WebRTC specific configuration
 	Fingerprint string `json:"fingerprint,omitempty"` // TLS fingerprint for verification
+
+	// Authentication
+	BearerToken string            `json:"bearerToken,omitempty"` // Token sent in the Authorization header
+	Headers     map[string]string `json:"headers,omitempty"`     // Additional HTTP headers
+
+	// ICE configuration
+	ICEServers         []WebRTCICEServer `json:"iceServers,omitempty"`         // STUN/TURN servers
+	ICETransportPolicy string            `json:"iceTransportPolicy,omitempty"` // all (default) or relay
+
+	// Track selection and bitrate limit
+	Medias                 string `json:"medias,omitempty"`                 // all, video, audio or track indexes
+	MaxBitrate             uint   `json:"maxBitrate,omitempty"`             // Maximum bitrate of the source in bit/s
+	MaxBitrateFallbackPath string `json:"maxBitrateFallbackPath,omitempty"` // Path forwarded when maxBitrate is exceeded
+
+	// Source selection
+	SourcePath string `json:"sourcePath,omitempty"` // Path forwarded in place of the path itself
+	Layer      string `json:"layer,omitempty"`      // Simulcast layer forwarded in place of the path itself
+
+	// Output buffering
+	DropUntilKeyframe bool     `json:"dropUntilKeyframe,omitempty"` // Drop whole GOPs when the output is late
+	MaxBufferDuration Duration `json:"maxBufferDuration,omitempty"` // Maximum delay of the output, default 2s
+}
+
+// RTSPForwardTarget is a RTSP forward target configuration.
+type RTSPForwardTarget struct {
+	// Target RTSP URL, format: rtsp://host:port/path or rtsps://host:port/path
+	URL string `json:"url"`
+
+	// Enable forwarding
+	Enable bool `json:"enable"`
+
+	// Reconnect configuration
+	Reconnect        bool     `json:"reconnect"`        // Auto reconnect on failure
+	ReconnectDelay   Duration `json:"reconnectDelay"`   // Reconnect delay
+	MaxReconnectTime Duration `json:"maxReconnectTime"` // Maximum reconnect time
+
+	// RTSP specific configuration
+	Transport   RTSPTransport `json:"transport"`             // udp, tcp or automatic
+	Fingerprint string        `json:"fingerprint,omitempty"` // TLS fingerprint for verification
+}
+
+// RTMPForwardTarget is a RTMP forward target configuration.
+type RTMPForwardTarget struct {
+	// Target RTMP URL, format: rtmp://host:port/app/key or rtmps://host:port/app/key
+	URL string `json:"url"`
+
+	// Enable forwarding
+	Enable bool `json:"enable"`
+
+	// Reconnect configuration
+	Reconnect        bool     `json:"reconnect"`        // Auto reconnect on failure
+	ReconnectDelay   Duration `json:"reconnectDelay"`   // Reconnect delay
+	MaxReconnectTime Duration `json:"maxReconnectTime"` // Maximum reconnect time
+
+	// RTMP specific configuration
+	Fingerprint string `json:"fingerprint,omitempty"` // TLS fingerprint for verification
 }
 
 func (pconf *Path) setDefaults() {
@@ -300,6 +395,13 @@ func (pconf *Path) setDefaults() {
 	pconf.RecordSegmentDuration = 3600 * Duration(time.Second)
 	pconf.RecordDeleteAfter = 24 * 3600 * Duration(time.Second)
 
+	// Record upload
+	pconf.RecordUploadRegion = "us-east-1"
+
+	// Record trigger
+	pconf.RecordTriggerBefore = 10 * Duration(time.Second)
+	pconf.RecordTriggerAfter = 30 * Duration(time.Second)
+
 	// Publisher source
 	pconf.OverridePublisher = true
 
@@ -742,6 +844,43 @@ func (pconf *Path) validate(
 		return fmt.Errorf("'recordDeleteAfter' cannot be lower than 'recordSegmentDuration'")
 	}
 
+	// Record upload
+
+	if pconf.RecordUploadURL != "" {
+		u, err := url.Parse(pconf.RecordUploadURL)
+		if err != nil {
+			return fmt.Errorf("invalid 'recordUploadURL': %w", err)
+		}
+
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("'recordUploadURL' must be a HTTP or HTTPS URL")
+		}
+
+		if strings.Trim(u.Path, "/") == "" {
+			return fmt.Errorf("'recordUploadURL' must contain the bucket name")
+		}
+	} else if pconf.RecordUploadKeepLocal != 0 {
+		return fmt.Errorf("'recordUploadKeepLocal' requires 'recordUploadURL'")
+	}
+
+	// Record trigger
+
+	if pconf.RecordOnTrigger && pconf.RecordFormat != RecordFormatFMP4 {
+		return fmt.Errorf("'recordOnTrigger' requires 'recordFormat' to be fmp4")
+	}
+
+	if pconf.RecordTriggerBefore < 0 {
+		return fmt.Errorf("'recordTriggerBefore' cannot be negative")
+	}
+
+	if pconf.RecordTriggerAfter < 0 {
+		return fmt.Errorf("'recordTriggerAfter' cannot be negative")
+	}
+
+	if pconf.RecordTriggerCommand != "" && !pconf.RecordOnTrigger {
+		return fmt.Errorf("'recordTriggerCommand' requires 'recordOnTrigger'")
+	}
+
 	// Authentication (deprecated)
 
 	if deprecatedCredentialsMode {
@@ -820,57 +959,40 @@ func (pconf *Path) validate(
 		return fmt.Errorf("'runOnDemand' and 'runOnUnDemand' can be used only when source is 'publisher'")
 	}
 
-	// SRT Forwarding
+	// Forwarding
 	for i, target := range pconf.SRTForwardTargets {
-		if target.URL == "" {
-			return fmt.Errorf("srtForwardTargets[%d]: url is required", i)
-		}
-
-		// validate URL format
-		srtConf := srt.DefaultConfig()
-		_, err := srtConf.UnmarshalURL(target.URL)
+		err := target.Validate()
 		if err != nil {
-			return fmt.Errorf("srtForwardTargets[%d]: invalid SRT URL: %w", i, err)
-		}
-
-		if target.Reconnect && target.ReconnectDelay <= 0 {
-			return fmt.Errorf("srtForwardTargets[%d]: reconnectDelay must be > 0 when reconnect is enabled", i)
-		}
-
-		if target.Passphrase != "" {
-			err := checkSRTPassphrase(target.Passphrase)
-			if err != nil {
-				return fmt.Errorf("srtForwardTargets[%d]: invalid passphrase: %w", i, err)
-			}
+			return fmt.Errorf("srtForwardTargets[%d]: %w", i, err)
 		}
 	}
 
-	// WebRTC Forwarding
 	for i, target := range pconf.WebRTCForwardTargets {
-		if target.URL == "" {
-			return fmt.Errorf("webrtcForwardTargets[%d]: url is required", i)
-		}
-
-		// validate URL format
-		u, err := url.Parse(target.URL)
+		err := target.Validate()
 		if err != nil {
-			return fmt.Errorf("webrtcForwardTargets[%d]: invalid URL: %w", i, err)
-		}
-
-		if u.Scheme != "http" && u.Scheme != "https" {
-			return fmt.Errorf("webrtcForwardTargets[%d]: url scheme must be 'http' or 'https'", i)
+			return fmt.Errorf("webrtcForwardTargets[%d]: %w", i, err)
 		}
+	}
 
-		if u.Host == "" {
-			return fmt.Errorf("webrtcForwardTargets[%d]: url host must be provided", i)
+	for i, target := range pconf.RTSPForwardTargets {
+		err := target.Validate()
+		if err != nil {
+			return fmt.Errorf("rtspForwardTargets[%d]: %w", i, err)
 		}
+	}
 
-		if !strings.HasSuffix(u.Path, "/whip") {
-			return fmt.Errorf("webrtcForwardTargets[%d]: url path must end with '/whip'", i)
+	for i, target := range pconf.RTMPForwardTargets {
+		err := target.Validate()
+		if err != nil {
+			return fmt.Errorf("rtmpForwardTargets[%d]: %w", i, err)
 		}
+	}
 
-		if target.Reconnect && target.ReconnectDelay <= 0 {
-			return fmt.Errorf("webrtcForwardTargets[%d]: reconnectDelay must be > 0 when reconnect is enabled", i)
+	// ABR ladder
+	if len(pconf.ABRLadder) != 0 {
+		err := pconf.validateABRLadder()
+		if err != nil {
+			return err
 		}
 	}
 
@@ -1,5 +1,28 @@
 package conf
 
+// SimulcastLayerSeparator separates the name of a path from the layer,
+// in the name of the sub-paths of simulcast publishers (i.e. "live/cam~h").
+const SimulcastLayerSeparator = "~"
+
+// SimulcastLayerPath returns the name of the sub-path of a simulcast layer.
+func SimulcastLayerPath(pathName string, layer string) string {
+	return pathName + SimulcastLayerSeparator + layer
+}
+
+// SimulcastLayerConf returns the configuration of the sub-path of a layer of a simulcast publisher.
+// The sub-path inherits the source and the read settings of the path,
+// while forwarding, recording and hooks are performed by the path only.
+func (pconf *Path) SimulcastLayerConf(name string) *Path {
+	layerConf := &Path{}
+	layerConf.setDefaults()
+	layerConf.Name = name
+	layerConf.Source = pconf.Source
+	layerConf.MaxReaders = pconf.MaxReaders
+	layerConf.SRTReadPassphrase = pconf.SRTReadPassphrase
+	layerConf.UseAbsoluteTimestamp = pconf.UseAbsoluteTimestamp
+	return layerConf
+}
+
 // SimulcastConfig is the configuration for Simulcast WebRTC.
 type SimulcastConfig struct {
 	// Enable simulcast
@@ -7,6 +30,13 @@ type SimulcastConfig struct {
 
 	// Input configurations
 	Inputs []SimulcastInput `json:"inputs"`
+
+	// Move WebRTC readers between layers automatically,
+	// depending on the bandwidth estimated from their RTCP feedback
+	ABR bool `json:"abr"`
+
+	// Minimum time between two layer switches performed by ABR (default 5s)
+	ABRCooldown Duration `json:"abrCooldown"`
 }
 
 // SimulcastInput is a single simulcast input configuration.
@@ -26,4 +56,3 @@ type SimulcastInput struct {
 	// Type: "video" or "audio"
 	Type string `json:"type"`
 }
-
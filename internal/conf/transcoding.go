@@ -7,6 +7,12 @@ type SRTTranscodingConfig struct {
 
 	// Output configurations
 	Outputs []SRTTranscodingOutput `json:"outputs"`
+
+	// Decode the input once and produce all outputs with a single FFmpeg process
+	Ladder bool `json:"ladder"`
+
+	// Time after which an encoder that doesn't produce any output is restarted (default 10s)
+	StallTimeout Duration `json:"stallTimeout"`
 }
 
 // SRTTranscodingOutput is a single transcoding output configuration.
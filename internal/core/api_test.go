@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -773,7 +774,7 @@ func TestAPIProtocolListGet(t *testing.T) {
 							"remoteAddr":                out1.(map[string]any)["items"].([]any)[0].(map[string]any)["remoteAddr"],
 							"remoteCandidate":           out1.(map[string]any)["items"].([]any)[0].(map[string]any)["remoteCandidate"],
 							"state":                     "read",
-							"rtcpPacketsReceived":       float64(0),
+							"rtcpPacketsReceived":       float64(1),
 							"rtcpPacketsSent":           float64(2),
 							"rtpPacketsJitter":          float64(0),
 							"rtpPacketsLost":            float64(0),
@@ -1233,3 +1234,180 @@ func TestAPIProtocolKickNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIForwarders(t *testing.T) {
+	p, ok := newInstance("api: yes\n" +
+		"paths:\n" +
+		"  source:\n" +
+		"    rtspForwardTargets:\n" +
+		"    - url: rtsp://localhost:8554/dest\n" +
+		"      enable: yes\n" +
+		"      transport: tcp\n" +
+		"  dest:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	type forwarder struct {
+		ID        string `json:"id"`
+		Path      string `json:"path"`
+		Target    string `json:"target"`
+		Protocol  string `json:"protocol"`
+		State     string `json:"state"`
+		Connected bool   `json:"connected"`
+	}
+
+	type forwarderList struct {
+		ItemCount int         `json:"itemCount"`
+		Items     []forwarder `json:"items"`
+	}
+
+	source := gortsplib.Client{}
+	err := source.StartRecording("rtsp://localhost:8554/source",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	waitConnected := func(id string, connected bool) forwarder {
+		var out forwarder
+		for range 50 {
+			httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/forwarders/get/"+id, nil, &out)
+			if out.Connected == connected {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		return out
+	}
+
+	var list forwarderList
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/forwarders/list", nil, &list)
+	require.Equal(t, 1, list.ItemCount)
+
+	id := list.Items[0].ID
+
+	require.Equal(t, forwarder{
+		ID:        id,
+		Path:      "source",
+		Target:    "rtsp://localhost:8554/dest",
+		Protocol:  "rtsp",
+		State:     "running",
+		Connected: true,
+	}, waitConnected(id, true))
+
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/forwarders/stop/"+id, nil, nil)
+
+	out := waitConnected(id, false)
+	require.Equal(t, "stopped", out.State)
+	require.Equal(t, false, out.Connected)
+
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/forwarders/start/"+id, nil, nil)
+
+	out = waitConnected(id, true)
+	require.Equal(t, "running", out.State)
+	require.Equal(t, true, out.Connected)
+
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/forwarders/restart/"+id, nil, nil)
+
+	out = waitConnected(id, true)
+	require.Equal(t, true, out.Connected)
+
+	var pa struct {
+		Forwarders []forwarder `json:"forwarders"`
+	}
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/paths/get/source", nil, &pa)
+	require.Len(t, pa.Forwarders, 1)
+	require.Equal(t, id, pa.Forwarders[0].ID)
+
+	res, err := hc.Post("http://localhost:9997/v3/forwarders/stop/"+uuid.New().String(), "", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	checkError(t, "forwarder not found", res.Body)
+}
+
+func TestAPIForwardersHotReload(t *testing.T) {
+	p, ok := newInstance("api: yes\n" +
+		"paths:\n" +
+		"  source:\n" +
+		"    rtspForwardTargets:\n" +
+		"    - url: rtsp://localhost:8554/dest1\n" +
+		"      enable: yes\n" +
+		"      transport: tcp\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	type forwarder struct {
+		ID        string `json:"id"`
+		Target    string `json:"target"`
+		Temporary bool   `json:"temporary"`
+	}
+
+	type forwarderList struct {
+		Items []forwarder `json:"items"`
+	}
+
+	source := gortsplib.Client{}
+	err := source.StartRecording("rtsp://localhost:8554/source",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	var list forwarderList
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/forwarders/list", nil, &list)
+	require.Len(t, list.Items, 1)
+	id := list.Items[0].ID
+
+	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/source", map[string]any{
+		"rtspForwardTargets": []map[string]any{
+			{"url": "rtsp://localhost:8554/dest1", "enable": true, "transport": "tcp"},
+			{"url": "rtsp://localhost:8554/dest2", "enable": true, "transport": "tcp"},
+		},
+	}, nil)
+
+	for range 50 {
+		httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/forwarders/list", nil, &list)
+		if len(list.Items) == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	require.Equal(t, []forwarder{
+		{ID: id, Target: "rtsp://localhost:8554/dest1"},
+		{ID: list.Items[1].ID, Target: "rtsp://localhost:8554/dest2"},
+	}, list.Items)
+
+	// the publisher has not been kicked
+	var pa struct {
+		Ready bool `json:"ready"`
+	}
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/paths/get/source", nil, &pa)
+	require.Equal(t, true, pa.Ready)
+
+	var tmp forwarder
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/paths/forward/source", map[string]any{
+		"rtsp": map[string]any{"url": "rtsp://localhost:8554/dest3", "transport": "tcp"},
+	}, &tmp)
+	require.Equal(t, "rtsp://localhost:8554/dest3", tmp.Target)
+	require.Equal(t, true, tmp.Temporary)
+
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/forwarders/list", nil, &list)
+	require.Len(t, list.Items, 3)
+
+	res, err := hc.Post("http://localhost:9997/v3/paths/forward/nonexisting", "application/json",
+		strings.NewReader(`{"rtsp":{"url":"rtsp://localhost:8554/dest3"}}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
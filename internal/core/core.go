@@ -29,12 +29,14 @@ import (
 	"github.com/bluenviron/mediamtx/internal/playback"
 	"github.com/bluenviron/mediamtx/internal/pprof"
 	"github.com/bluenviron/mediamtx/internal/recordcleaner"
+	"github.com/bluenviron/mediamtx/internal/recorduploader"
 	"github.com/bluenviron/mediamtx/internal/rlimit"
 	"github.com/bluenviron/mediamtx/internal/servers/hls"
 	"github.com/bluenviron/mediamtx/internal/servers/rtmp"
 	"github.com/bluenviron/mediamtx/internal/servers/rtsp"
 	"github.com/bluenviron/mediamtx/internal/servers/srt"
 	"github.com/bluenviron/mediamtx/internal/servers/webrtc"
+	"github.com/bluenviron/mediamtx/internal/transcoder"
 )
 
 //go:generate go run ./versiongetter
@@ -61,9 +63,9 @@ var cli struct {
 	Upgrade  bool   `help:"upgrade executable to the latest version"`
 }
 
-func atLeastOneRecordDeleteAfter(pathConfs map[string]*conf.Path) bool {
+func atLeastOneRecordDeleteAfterOrUpload(pathConfs map[string]*conf.Path) bool {
 	for _, e := range pathConfs {
-		if e.RecordDeleteAfter != 0 {
+		if e.RecordDeleteAfter != 0 || e.RecordUploadURL != "" {
 			return true
 		}
 	}
@@ -90,6 +92,8 @@ type Core struct {
 	conf            *conf.Conf
 	logger          *logger.Logger
 	externalCmdPool *externalcmd.Pool
+	transcoderPool  *transcoder.ProcessPool
+	recordUploader  *recorduploader.Uploader
 	authManager     *auth.Manager
 	metrics         *metrics.Metrics
 	pprof           *pprof.PPROF
@@ -310,6 +314,16 @@ func (p *Core) createResources(initial bool) error {
 
 		p.externalCmdPool = &externalcmd.Pool{}
 		p.externalCmdPool.Initialize()
+
+		p.transcoderPool = &transcoder.ProcessPool{
+			MaxProcesses: p.conf.TranscoderMaxProcesses,
+		}
+		p.transcoderPool.Initialize()
+
+		p.recordUploader = &recorduploader.Uploader{
+			Parent: p,
+		}
+		p.recordUploader.Initialize()
 	}
 
 	if p.authManager == nil {
@@ -370,9 +384,10 @@ func (p *Core) createResources(initial bool) error {
 	}
 
 	if p.recordCleaner == nil &&
-		atLeastOneRecordDeleteAfter(p.conf.Paths) {
+		atLeastOneRecordDeleteAfterOrUpload(p.conf.Paths) {
 		p.recordCleaner = &recordcleaner.Cleaner{
 			PathConfs: p.conf.Paths,
+			Uploader:  p.recordUploader,
 			Parent:    p,
 		}
 		p.recordCleaner.Initialize()
@@ -414,6 +429,8 @@ func (p *Core) createResources(initial bool) error {
 			rtpMaxPayloadSize: rtpMaxPayloadSize,
 			pathConfs:         p.conf.Paths,
 			externalCmdPool:   p.externalCmdPool,
+			transcoderPool:    p.transcoderPool,
+			recordUploader:    p.recordUploader,
 			metrics:           p.metrics,
 			parent:            p,
 		}
@@ -655,27 +672,28 @@ func (p *Core) createResources(initial bool) error {
 	if p.conf.API &&
 		p.api == nil {
 		i := &api.API{
-			Version:        string(version),
-			Started:        started,
-			Address:        p.conf.APIAddress,
-			Encryption:     p.conf.APIEncryption,
-			ServerKey:      p.conf.APIServerKey,
-			ServerCert:     p.conf.APIServerCert,
-			AllowOrigins:   p.conf.APIAllowOrigins,
-			TrustedProxies: p.conf.APITrustedProxies,
-			ReadTimeout:    p.conf.ReadTimeout,
-			WriteTimeout:   p.conf.WriteTimeout,
-			Conf:           p.conf,
-			AuthManager:    p.authManager,
-			PathManager:    p.pathManager,
-			RTSPServer:     p.rtspServer,
-			RTSPSServer:    p.rtspsServer,
-			RTMPServer:     p.rtmpServer,
-			RTMPSServer:    p.rtmpsServer,
-			HLSServer:      p.hlsServer,
-			WebRTCServer:   p.webRTCServer,
-			SRTServer:      p.srtServer,
-			Parent:         p,
+			Version:          string(version),
+			Started:          started,
+			Address:          p.conf.APIAddress,
+			Encryption:       p.conf.APIEncryption,
+			ServerKey:        p.conf.APIServerKey,
+			ServerCert:       p.conf.APIServerCert,
+			AllowOrigins:     p.conf.APIAllowOrigins,
+			TrustedProxies:   p.conf.APITrustedProxies,
+			ReadTimeout:      p.conf.ReadTimeout,
+			WriteTimeout:     p.conf.WriteTimeout,
+			Conf:             p.conf,
+			AuthManager:      p.authManager,
+			PathManager:      p.pathManager,
+			RTSPServer:       p.rtspServer,
+			RTSPSServer:      p.rtspsServer,
+			RTMPServer:       p.rtmpServer,
+			RTMPSServer:      p.rtmpsServer,
+			HLSServer:        p.hlsServer,
+			WebRTCServer:     p.webRTCServer,
+			SRTServer:        p.srtServer,
+			ForwarderManager: p.pathManager,
+			Parent:           p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -745,7 +763,7 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		closeLogger
 
 	closeRecorderCleaner := newConf == nil ||
-		atLeastOneRecordDeleteAfter(newConf.Paths) != atLeastOneRecordDeleteAfter(p.conf.Paths) ||
+		atLeastOneRecordDeleteAfterOrUpload(newConf.Paths) != atLeastOneRecordDeleteAfterOrUpload(p.conf.Paths) ||
 		closeLogger
 	if !closeRecorderCleaner && p.recordCleaner != nil && !reflect.DeepEqual(newConf.Paths, p.conf.Paths) {
 		p.recordCleaner.ReloadPathConfs(newConf.Paths)
@@ -1015,6 +1033,15 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		p.authManager = nil
 	}
 
+	if newConf != nil && p.transcoderPool != nil &&
+		newConf.TranscoderMaxProcesses != p.conf.TranscoderMaxProcesses {
+		p.transcoderPool.SetMaxProcesses(newConf.TranscoderMaxProcesses)
+	}
+
+	if newConf == nil && p.recordUploader != nil {
+		p.recordUploader.Close()
+	}
+
 	if newConf == nil && p.externalCmdPool != nil {
 		p.Log(logger.Info, "waiting for running hooks")
 		p.externalCmdPool.Close()
@@ -79,6 +79,12 @@ func TestMetrics(t *testing.T) {
 paths_bytes_received 0
 paths_bytes_sent 0
 paths_readers 0
+transcoder_outputs 0
+transcoder_output_fps 0
+transcoder_restarts 0
+simulcast_layers 0
+simulcast_layers_rtp_packets_received 0
+simulcast_layers_bytes_received 0
 hls_muxers 0
 hls_muxers_bytes_sent 0
 rtsp_conns 0
@@ -176,6 +182,13 @@ webrtc_sessions_rtp_packets_lost 0
 webrtc_sessions_rtp_packets_jitter 0
 webrtc_sessions_rtcp_packets_received 0
 webrtc_sessions_rtcp_packets_sent 0
+forwarders 0
+forwarders_connected 0
+forwarders_bytes_sent 0
+forwarders_packets_sent 0
+forwarders_packets_lost 0
+forwarders_reconnects_total 0
+forwarders_dropped_gops_total 0
 `, string(bo))
 	})
 
@@ -382,6 +395,12 @@ webrtc_sessions_rtcp_packets_sent 0
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_readers\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`transcoder_outputs 0`+"\n"+
+				`transcoder_output_fps 0`+"\n"+
+				`transcoder_restarts 0`+"\n"+
+				`simulcast_layers 0`+"\n"+
+				`simulcast_layers_rtp_packets_received 0`+"\n"+
+				`simulcast_layers_bytes_received 0`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
@@ -489,6 +508,13 @@ webrtc_sessions_rtcp_packets_sent 0
 				`webrtc_sessions_rtp_packets_jitter\{id=".*?",path=".*?",remoteAddr=".*?",state="publish"\} [0-9]+`+"\n"+
 				`webrtc_sessions_rtcp_packets_received\{id=".*?",path=".*?",remoteAddr=".*?",state="publish"\} [0-9]+`+"\n"+
 				`webrtc_sessions_rtcp_packets_sent\{id=".*?",path=".*?",remoteAddr=".*?",state="publish"\} [0-9]+`+"\n"+
+				`forwarders 0`+"\n"+
+				`forwarders_connected 0`+"\n"+
+				`forwarders_bytes_sent 0`+"\n"+
+				`forwarders_packets_sent 0`+"\n"+
+				`forwarders_packets_lost 0`+"\n"+
+				`forwarders_reconnects_total 0`+"\n"+
+				`forwarders_dropped_gops_total 0`+"\n"+
 				"$",
 			string(bo))
 
@@ -512,7 +538,20 @@ webrtc_sessions_rtcp_packets_sent 0
 		require.Equal(t, "paths 0\n"+
 			"paths_bytes_received 0\n"+
 			"paths_bytes_sent 0\n"+
-			"paths_readers 0\n",
+			"paths_readers 0\n"+
+			"transcoder_outputs 0\n"+
+			"transcoder_output_fps 0\n"+
+			"transcoder_restarts 0\n"+
+			"simulcast_layers 0\n"+
+			"simulcast_layers_rtp_packets_received 0\n"+
+			"simulcast_layers_bytes_received 0\n"+
+			"forwarders 0\n"+
+			"forwarders_connected 0\n"+
+			"forwarders_bytes_sent 0\n"+
+			"forwarders_packets_sent 0\n"+
+			"forwarders_packets_lost 0\n"+
+			"forwarders_reconnects_total 0\n"+
+			"forwarders_dropped_gops_total 0\n",
 			string(bo))
 	})
 }
@@ -17,8 +17,10 @@ import (
 	"github.com/bluenviron/mediamtx/internal/hooks"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/recorder"
+	"github.com/bluenviron/mediamtx/internal/recorduploader"
 	"github.com/bluenviron/mediamtx/internal/staticsources"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/transcoder"
 )
 
 func emptyTimer() *time.Timer {
@@ -65,6 +67,11 @@ type pathAPIPathsGetReq struct {
 	res  chan pathAPIPathsGetRes
 }
 
+type pathAPIPathsRecordTriggerReq struct {
+	data *defs.APIPathRecordTriggerReq
+	res  chan error
+}
+
 type path struct {
 	parentCtx         context.Context
 	logLevel          conf.LogLevel
@@ -77,8 +84,11 @@ type path struct {
 	conf              *conf.Path
 	name              string
 	matches           []string
+	simulcastLayer    bool
 	wg                *sync.WaitGroup
 	externalCmdPool   *externalcmd.Pool
+	transcoderPool    *transcoder.ProcessPool
+	recordUploader    *recorduploader.Uploader
 	parent            pathParent
 
 	ctx                            context.Context
@@ -88,7 +98,9 @@ type path struct {
 	publisherQuery                 string
 	stream                         *stream.Stream
 	recorder                       *recorder.Recorder
+	onRecordTriggerStop            func()
 	forwarderManager               *forwarder.Manager
+	transcoderManager              *transcoder.Manager
 	readyTime                      time.Time
 	onUnDemandHook                 func(string)
 	onNotReadyHook                 func()
@@ -116,6 +128,7 @@ type path struct {
 	chAddReader               chan defs.PathAddReaderReq
 	chRemoveReader            chan defs.PathRemoveReaderReq
 	chAPIPathsGet             chan pathAPIPathsGetReq
+	chAPIPathsRecordTrigger   chan pathAPIPathsRecordTriggerReq
 
 	// out
 	done chan struct{}
@@ -142,21 +155,31 @@ func (pa *path) initialize() {
 	pa.chAddReader = make(chan defs.PathAddReaderReq)
 	pa.chRemoveReader = make(chan defs.PathRemoveReaderReq)
 	pa.chAPIPathsGet = make(chan pathAPIPathsGetReq)
+	pa.chAPIPathsRecordTrigger = make(chan pathAPIPathsRecordTriggerReq)
 	pa.done = make(chan struct{})
 
 	// initialize forwarder manager
-	if len(pa.conf.SRTForwardTargets) > 0 || len(pa.conf.WebRTCForwardTargets) > 0 {
-		pa.forwarderManager = forwarder.NewManager(
-			pa.ctx,
-			pa.conf.SRTForwardTargets,
-			pa.conf.WebRTCForwardTargets,
-			nil, // stream will be set later
-			pa,
-			time.Duration(pa.writeTimeout),
-			int(pa.udpReadBufferSize),
-			pa.udpReadBufferSize,
-			pa.name, // path name for variable substitution
-		)
+	pa.forwarderManager = forwarder.NewManager(
+		pa.ctx,
+		pa.conf.SRTForwardTargets,
+		pa.conf.WebRTCForwardTargets,
+		pa.conf.RTSPForwardTargets,
+		pa.conf.RTMPForwardTargets,
+		nil, // stream will be set later
+		pa,
+		time.Duration(pa.writeTimeout),
+		int(pa.udpReadBufferSize),
+		pa.udpReadBufferSize,
+		pa.name, // path name for variable substitution
+		pa.parent,
+		pa.onForwardFailure,
+	)
+
+	// initialize transcoder manager
+	if pa.conf.SRTTranscoding != nil && pa.conf.SRTTranscoding.Enable {
+		pa.transcoderManager = transcoder.NewManager(pa.conf.SRTTranscoding, pa.transcoderPool, pa)
+	} else if len(pa.conf.ABRLadder) != 0 && !pa.simulcastLayer {
+		pa.transcoderManager = transcoder.NewManager(pa.conf.ABRLadderTranscoding(), pa.transcoderPool, pa)
 	}
 
 	pa.Log(logger.Debug, "created")
@@ -246,7 +269,7 @@ func (pa *path) run() {
 	}
 
 	if pa.forwarderManager != nil {
-		pa.forwarderManager.Stop()
+		pa.forwarderManager.Close()
 	}
 
 	if pa.source != nil {
@@ -350,6 +373,9 @@ func (pa *path) runInner() error {
 		case req := <-pa.chAPIPathsGet:
 			pa.doAPIPathsGet(req)
 
+		case req := <-pa.chAPIPathsRecordTrigger:
+			pa.doAPIPathsRecordTrigger(req)
+
 		case <-pa.ctx.Done():
 			return fmt.Errorf("terminated")
 		}
@@ -410,14 +436,25 @@ func (pa *path) doReloadConf(newConf *conf.Path) {
 			newConf.RecordPartDuration != oldConf.RecordPartDuration ||
 			newConf.RecordMaxPartSize != oldConf.RecordMaxPartSize ||
 			newConf.RecordSegmentDuration != oldConf.RecordSegmentDuration ||
-			newConf.RecordDeleteAfter != oldConf.RecordDeleteAfter) {
-		pa.recorder.Close()
-		pa.recorder = nil
+			newConf.RecordDeleteAfter != oldConf.RecordDeleteAfter ||
+			newConf.RecordOnTrigger != oldConf.RecordOnTrigger ||
+			newConf.RecordTriggerBefore != oldConf.RecordTriggerBefore ||
+			newConf.RecordTriggerCommand != oldConf.RecordTriggerCommand) {
+		pa.stopRecording()
 	}
 
 	if newConf.Record && pa.stream != nil && pa.recorder == nil {
 		pa.startRecording()
 	}
+
+	if !newConf.ForwardTargetsEqual(oldConf) {
+		pa.forwarderManager.ReloadConf(
+			newConf.SRTForwardTargets,
+			newConf.WebRTCForwardTargets,
+			newConf.RTSPForwardTargets,
+			newConf.RTMPForwardTargets,
+		)
+	}
 }
 
 func (pa *path) doSourceStaticSetReady(req defs.PathSourceStaticSetReadyReq) {
@@ -609,6 +646,41 @@ func (pa *path) doRemoveReader(req defs.PathRemoveReaderReq) {
 	}
 }
 
+func (pa *path) doAPIPathsRecordTrigger(req pathAPIPathsRecordTriggerReq) {
+	if !pa.conf.Record || !pa.conf.RecordOnTrigger {
+		req.res <- fmt.Errorf("recording on trigger is not enabled on path '%s'", pa.name)
+		return
+	}
+
+	if pa.recorder == nil {
+		req.res <- fmt.Errorf("path '%s' is not ready", pa.name)
+		return
+	}
+
+	before := time.Duration(pa.conf.RecordTriggerBefore)
+	if req.data.Before != nil {
+		if *req.data.Before < 0 || *req.data.Before > before {
+			req.res <- fmt.Errorf("'before' must be between 0 and 'recordTriggerBefore' (%v)", before)
+			return
+		}
+		before = *req.data.Before
+	}
+
+	after := time.Duration(pa.conf.RecordTriggerAfter)
+	if req.data.After != nil {
+		if *req.data.After < 0 {
+			req.res <- fmt.Errorf("'after' cannot be negative")
+			return
+		}
+		after = *req.data.After
+	}
+
+	pa.Log(logger.Info, "recording triggered (before %v, after %v)", before, after)
+	pa.recorder.Trigger(before, after)
+
+	req.res <- nil
+}
+
 func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 	req.res <- pathAPIPathsGetRes{
 		data: &defs.APIPath{
@@ -654,10 +726,31 @@ func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 				}
 				return ret
 			}(),
+			Forwarders: func() []*defs.APIForwarder {
+				if pa.forwarderManager == nil {
+					return []*defs.APIForwarder{}
+				}
+				return pa.forwarderManager.APIForwardersList()
+			}(),
+			TranscoderOutputs: func() []*defs.APITranscoderOutput {
+				if pa.transcoderManager == nil {
+					return []*defs.APITranscoderOutput{}
+				}
+				return pa.transcoderManager.APIOutputsList()
+			}(),
 		},
 	}
 }
 
+// GetTranscoderOutputStream returns the stream of a transcoder output.
+// It is called by the transcoder static source.
+func (pa *path) GetTranscoderOutputStream(name string) *stream.Stream {
+	if pa.transcoderManager == nil {
+		return nil
+	}
+	return pa.transcoderManager.GetOutputStream(name)
+}
+
 func (pa *path) SafeConf() *conf.Path {
 	pa.confMutex.RLock()
 	defer pa.confMutex.RUnlock()
@@ -785,6 +878,14 @@ func (pa *path) setReady(desc *description.Session, generateRTPPackets bool, fil
 		pa.forwarderManager.Start(pa.stream)
 	}
 
+	// start transcoder
+	if pa.transcoderManager != nil {
+		err = pa.transcoderManager.Start(pa.stream)
+		if err != nil {
+			pa.Log(logger.Warn, "failed to start transcoder: %v", err)
+		}
+	}
+
 	pa.parent.pathReady(pa)
 
 	return nil
@@ -812,6 +913,11 @@ func (pa *path) setNotReady() {
 		pa.forwarderManager.Stop()
 	}
 
+	// stop transcoder
+	if pa.transcoderManager != nil {
+		pa.transcoderManager.Stop()
+	}
+
 	for r := range pa.readers {
 		pa.executeRemoveReader(r)
 		r.Close()
@@ -820,8 +926,7 @@ func (pa *path) setNotReady() {
 	pa.onNotReadyHook()
 
 	if pa.recorder != nil {
-		pa.recorder.Close()
-		pa.recorder = nil
+		pa.stopRecording()
 	}
 
 	if pa.stream != nil {
@@ -867,10 +972,63 @@ func (pa *path) startRecording() {
 					env,
 					nil)
 			}
+
+			if pa.conf.RecordUploadURL != "" && pa.recordUploader != nil {
+				pa.recordUploader.Upload(pa.conf, pa.name, segmentPath)
+			}
 		},
-		Parent: pa,
+		OnTrigger: pa.conf.RecordOnTrigger,
+		PreRoll:   time.Duration(pa.conf.RecordTriggerBefore),
+		Parent:    pa,
 	}
 	pa.recorder.Initialize()
+
+	if pa.conf.RecordOnTrigger {
+		pa.onRecordTriggerStop = hooks.RecordTrigger(hooks.RecordTriggerParams{
+			Logger:          pa,
+			ExternalCmdPool: pa.externalCmdPool,
+			Conf:            pa.conf,
+			ExternalCmdEnv:  pa.ExternalCmdEnv(),
+			OnTrigger: func() {
+				err := pa.APIPathsRecordTrigger(&defs.APIPathRecordTriggerReq{})
+				if err != nil {
+					pa.Log(logger.Warn, "unable to trigger recording: %v", err)
+				}
+			},
+		})
+	}
+}
+
+func (pa *path) stopRecording() {
+	if pa.onRecordTriggerStop != nil {
+		pa.onRecordTriggerStop()
+		pa.onRecordTriggerStop = nil
+	}
+
+	pa.recorder.Close()
+	pa.recorder = nil
+}
+
+func (pa *path) onForwardFailure(protocol string, target string, err error) {
+	cnf := pa.SafeConf()
+
+	if cnf.RunOnForwardFailure != "" {
+		env := pa.ExternalCmdEnv()
+		env["MTX_FORWARD_PROTOCOL"] = protocol
+		env["MTX_FORWARD_TARGET"] = target
+		env["MTX_FORWARD_ERROR"] = ""
+		if err != nil {
+			env["MTX_FORWARD_ERROR"] = err.Error()
+		}
+
+		pa.Log(logger.Info, "runOnForwardFailure command launched")
+		externalcmd.NewCmd(
+			pa.externalCmdPool,
+			cnf.RunOnForwardFailure,
+			false,
+			env,
+			nil)
+	}
 }
 
 func (pa *path) executeRemoveReader(r defs.Reader) {
@@ -1030,6 +1188,22 @@ func (pa *path) APIPathsGet(req pathAPIPathsGetReq) (*defs.APIPath, error) {
 	}
 }
 
+// APIPathsRecordTrigger is called by api.
+func (pa *path) APIPathsRecordTrigger(data *defs.APIPathRecordTriggerReq) error {
+	req := pathAPIPathsRecordTriggerReq{
+		data: data,
+		res:  make(chan error),
+	}
+
+	select {
+	case pa.chAPIPathsRecordTrigger <- req:
+		return <-req.res
+
+	case <-pa.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
+
 // origNodeStaticSourceStart starts pulling stream from origin node
 func (pa *path) origNodeStaticSourceStart(query string) {
 	origNodeURL := pa.buildOrigNodeURL()
@@ -3,17 +3,23 @@ package core
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sort"
 	"sync"
 
+	"github.com/google/uuid"
+
 	"github.com/bluenviron/mediamtx/internal/auth"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/forwarder"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/metrics"
+	"github.com/bluenviron/mediamtx/internal/recorduploader"
 	"github.com/bluenviron/mediamtx/internal/servers/hls"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/transcoder"
 )
 
 func pathConfCanBeUpdated(oldPathConf *conf.Path, newPathConf *conf.Path) bool {
@@ -47,6 +53,11 @@ func pathConfCanBeUpdated(oldPathConf *conf.Path, newPathConf *conf.Path) bool {
 	clone.RPICameraIDRPeriod = newPathConf.RPICameraIDRPeriod
 	clone.RPICameraBitrate = newPathConf.RPICameraBitrate
 
+	clone.SRTForwardTargets = newPathConf.SRTForwardTargets
+	clone.WebRTCForwardTargets = newPathConf.WebRTCForwardTargets
+	clone.RTSPForwardTargets = newPathConf.RTSPForwardTargets
+	clone.RTMPForwardTargets = newPathConf.RTMPForwardTargets
+
 	return newPathConf.Equal(clone)
 }
 
@@ -80,27 +91,34 @@ type pathManager struct {
 	rtpMaxPayloadSize int
 	pathConfs         map[string]*conf.Path
 	externalCmdPool   *externalcmd.Pool
+	transcoderPool    *transcoder.ProcessPool
+	recordUploader    *recorduploader.Uploader
 	metrics           *metrics.Metrics
 	parent            pathManagerParent
 
-	ctx       context.Context
-	ctxCancel func()
-	wg        sync.WaitGroup
-	hlsServer *hls.Server
-	paths     map[string]*pathData
+	ctx             context.Context
+	ctxCancel       func()
+	wg              sync.WaitGroup
+	hlsServer       *hls.Server
+	paths           map[string]*pathData
+	abrLadders      map[string][]string   // path name -> names of generated paths
+	generatedConfs  map[string]*conf.Path // configurations generated by ABR ladders and simulcast publishers
+	simulcastLayers map[string]int        // sub-paths of simulcast publishers -> number of publishers
 
 	// in
-	chReloadConf   chan map[string]*conf.Path
-	chSetHLSServer chan pathSetHLSServerReq
-	chClosePath    chan *path
-	chPathReady    chan *path
-	chPathNotReady chan *path
-	chFindPathConf chan defs.PathFindPathConfReq
-	chDescribe     chan defs.PathDescribeReq
-	chAddReader    chan defs.PathAddReaderReq
-	chAddPublisher chan defs.PathAddPublisherReq
-	chAPIPathsList chan pathAPIPathsListReq
-	chAPIPathsGet  chan pathAPIPathsGetReq
+	chReloadConf           chan map[string]*conf.Path
+	chSetHLSServer         chan pathSetHLSServerReq
+	chClosePath            chan *path
+	chPathReady            chan *path
+	chPathNotReady         chan *path
+	chFindPathConf         chan defs.PathFindPathConfReq
+	chAddSimulcastLayer    chan defs.PathAddSimulcastLayerReq
+	chRemoveSimulcastLayer chan defs.PathRemoveSimulcastLayerReq
+	chDescribe             chan defs.PathDescribeReq
+	chAddReader            chan defs.PathAddReaderReq
+	chAddPublisher         chan defs.PathAddPublisherReq
+	chAPIPathsList         chan pathAPIPathsListReq
+	chAPIPathsGet          chan pathAPIPathsGetReq
 }
 
 func (pm *pathManager) initialize() {
@@ -109,12 +127,17 @@ func (pm *pathManager) initialize() {
 	pm.ctx = ctx
 	pm.ctxCancel = ctxCancel
 	pm.paths = make(map[string]*pathData)
+	pm.abrLadders = make(map[string][]string)
+	pm.generatedConfs = make(map[string]*conf.Path)
+	pm.simulcastLayers = make(map[string]int)
 	pm.chReloadConf = make(chan map[string]*conf.Path)
 	pm.chSetHLSServer = make(chan pathSetHLSServerReq)
 	pm.chClosePath = make(chan *path)
 	pm.chPathReady = make(chan *path)
 	pm.chPathNotReady = make(chan *path)
 	pm.chFindPathConf = make(chan defs.PathFindPathConfReq)
+	pm.chAddSimulcastLayer = make(chan defs.PathAddSimulcastLayerReq)
+	pm.chRemoveSimulcastLayer = make(chan defs.PathRemoveSimulcastLayerReq)
 	pm.chDescribe = make(chan defs.PathDescribeReq)
 	pm.chAddReader = make(chan defs.PathAddReaderReq)
 	pm.chAddPublisher = make(chan defs.PathAddPublisherReq)
@@ -134,6 +157,7 @@ func (pm *pathManager) initialize() {
 
 	if pm.metrics != nil {
 		pm.metrics.SetPathManager(pm)
+		pm.metrics.SetForwarderManager(pm)
 	}
 }
 
@@ -142,6 +166,7 @@ func (pm *pathManager) close() {
 
 	if pm.metrics != nil {
 		pm.metrics.SetPathManager(nil)
+		pm.metrics.SetForwarderManager(nil)
 	}
 
 	pm.ctxCancel()
@@ -178,6 +203,12 @@ outer:
 		case req := <-pm.chFindPathConf:
 			pm.doFindPathConf(req)
 
+		case req := <-pm.chAddSimulcastLayer:
+			pm.doAddSimulcastLayer(req)
+
+		case req := <-pm.chRemoveSimulcastLayer:
+			pm.doRemoveSimulcastLayer(req)
+
 		case req := <-pm.chDescribe:
 			pm.doDescribe(req)
 
@@ -202,6 +233,15 @@ outer:
 }
 
 func (pm *pathManager) doReloadConf(newPaths map[string]*conf.Path) {
+	// keep configurations generated by ABR ladders
+	generatedConfs := maps.Clone(pm.generatedConfs)
+	newPaths = maps.Clone(newPaths)
+	for name, pathConf := range generatedConfs {
+		if _, ok := newPaths[name]; !ok {
+			newPaths[name] = pathConf
+		}
+	}
+
 	confsToRecreate := make(map[string]struct{})
 	confsToReload := make(map[string]struct{})
 
@@ -254,6 +294,13 @@ func (pm *pathManager) doReloadConf(newPaths map[string]*conf.Path) {
 		}
 	}
 
+	// drop configurations of ABR ladders that have been removed in the meanwhile
+	for name := range generatedConfs {
+		if _, ok := pm.generatedConfs[name]; !ok {
+			delete(newPaths, name)
+		}
+	}
+
 	pm.pathConfs = newPaths
 
 	// create new static paths
@@ -303,6 +350,8 @@ func (pm *pathManager) doPathReady(pa *path) {
 	if pm.hlsServer != nil {
 		pm.hlsServer.PathReady(pa)
 	}
+
+	pm.createABRLadder(pa)
 }
 
 func (pm *pathManager) doPathNotReady(pa *path) {
@@ -315,6 +364,75 @@ func (pm *pathManager) doPathNotReady(pa *path) {
 	if pm.hlsServer != nil {
 		pm.hlsServer.PathNotReady(pa)
 	}
+
+	pm.removeABRLadder(pa.name)
+}
+
+// createABRLadder creates the paths of the ABR ladder of a path.
+func (pm *pathManager) createABRLadder(pa *path) {
+	pathConf := pa.SafeConf()
+	if len(pathConf.ABRLadder) == 0 || pm.isSimulcastLayerPath(pa.name) {
+		return
+	}
+
+	if _, ok := pm.abrLadders[pa.name]; ok {
+		return
+	}
+
+	pathConfs := maps.Clone(pm.pathConfs)
+	var names []string
+
+	for _, generatedConf := range pathConf.ABRLadderPaths(pa.name) {
+		if _, ok := pathConfs[generatedConf.Name]; ok {
+			pm.Log(logger.Warn, "[path %s] path '%s' is already configured, skipping ABR ladder rendition",
+				pa.name, generatedConf.Name)
+			continue
+		}
+
+		pathConfs[generatedConf.Name] = generatedConf
+		pm.generatedConfs[generatedConf.Name] = generatedConf
+		names = append(names, generatedConf.Name)
+	}
+
+	pm.pathConfs = pathConfs
+	pm.abrLadders[pa.name] = names
+
+	for _, name := range names {
+		// a path with the same name may have been created with the configuration of the parent path
+		if pd, ok := pm.paths[name]; ok {
+			pm.removeAndClosePath(pd.path)
+		}
+
+		pm.createPath(pm.generatedConfs[name], name, nil)
+	}
+
+	pm.Log(logger.Info, "[path %s] ABR ladder created, renditions are available in '%s'",
+		pa.name, conf.SimulcastLayerPath(pa.name, conf.ABRLadderLayer))
+}
+
+// removeABRLadder removes the paths of the ABR ladder of a path.
+func (pm *pathManager) removeABRLadder(pathName string) {
+	names, ok := pm.abrLadders[pathName]
+	if !ok {
+		return
+	}
+
+	delete(pm.abrLadders, pathName)
+
+	pathConfs := maps.Clone(pm.pathConfs)
+	for _, name := range names {
+		delete(pathConfs, name)
+		delete(pm.generatedConfs, name)
+	}
+	pm.pathConfs = pathConfs
+
+	for _, name := range names {
+		if pd, ok := pm.paths[name]; ok {
+			pm.removeAndClosePath(pd.path)
+		}
+	}
+
+	pm.Log(logger.Info, "[path %s] ABR ladder removed", pathName)
 }
 
 func (pm *pathManager) doFindPathConf(req defs.PathFindPathConfReq) {
@@ -333,6 +451,72 @@ func (pm *pathManager) doFindPathConf(req defs.PathFindPathConfReq) {
 	req.Res <- defs.PathFindPathConfRes{Conf: pathConf}
 }
 
+// isSimulcastLayerPath checks whether a path is a simulcast layer,
+// that is a path generated by an ABR ladder or the sub-path of a simulcast publisher.
+func (pm *pathManager) isSimulcastLayerPath(name string) bool {
+	if _, ok := pm.generatedConfs[name]; ok {
+		return true
+	}
+
+	_, ok := pm.simulcastLayers[name]
+	return ok
+}
+
+func (pm *pathManager) doAddSimulcastLayer(req defs.PathAddSimulcastLayerReq) {
+	if _, ok := pm.simulcastLayers[req.LayerPath]; !ok {
+		if _, ok := pm.pathConfs[req.LayerPath]; ok {
+			req.Res <- defs.PathAddSimulcastLayerRes{Err: fmt.Errorf("path '%s' is already configured", req.LayerPath)}
+			return
+		}
+
+		pathConf, _, err := conf.FindPathConf(pm.pathConfs, req.PathName)
+		if err != nil {
+			req.Res <- defs.PathAddSimulcastLayerRes{Err: err}
+			return
+		}
+
+		layerConf := pathConf.SimulcastLayerConf(req.LayerPath)
+
+		pathConfs := maps.Clone(pm.pathConfs)
+		pathConfs[req.LayerPath] = layerConf
+		pm.pathConfs = pathConfs
+		pm.generatedConfs[req.LayerPath] = layerConf
+
+		// a path with the same name may have been created with another configuration
+		if pd, ok := pm.paths[req.LayerPath]; ok {
+			pm.removeAndClosePath(pd.path)
+		}
+	}
+
+	pm.simulcastLayers[req.LayerPath]++
+
+	req.Res <- defs.PathAddSimulcastLayerRes{Conf: pm.generatedConfs[req.LayerPath]}
+}
+
+func (pm *pathManager) doRemoveSimulcastLayer(req defs.PathRemoveSimulcastLayerReq) {
+	defer close(req.Res)
+
+	if _, ok := pm.simulcastLayers[req.LayerPath]; !ok {
+		return
+	}
+
+	pm.simulcastLayers[req.LayerPath]--
+	if pm.simulcastLayers[req.LayerPath] > 0 {
+		return
+	}
+
+	delete(pm.simulcastLayers, req.LayerPath)
+
+	pathConfs := maps.Clone(pm.pathConfs)
+	delete(pathConfs, req.LayerPath)
+	pm.pathConfs = pathConfs
+	delete(pm.generatedConfs, req.LayerPath)
+
+	if pd, ok := pm.paths[req.LayerPath]; ok {
+		pm.removeAndClosePath(pd.path)
+	}
+}
+
 func (pm *pathManager) doDescribe(req defs.PathDescribeReq) {
 	pathConf, pathMatches, err := conf.FindPathConf(pm.pathConfs, req.AccessRequest.Name)
 	if err != nil {
@@ -445,8 +629,11 @@ func (pm *pathManager) createPath(
 		conf:              pathConf,
 		name:              name,
 		matches:           matches,
+		simulcastLayer:    pm.isSimulcastLayerPath(name),
 		wg:                &pm.wg,
 		externalCmdPool:   pm.externalCmdPool,
+		transcoderPool:    pm.transcoderPool,
+		recordUploader:    pm.recordUploader,
 		parent:            pm,
 	}
 	pa.initialize()
@@ -459,6 +646,7 @@ func (pm *pathManager) createPath(
 
 func (pm *pathManager) removePath(pa *path) {
 	delete(pm.paths, pa.name)
+	pm.removeABRLadder(pa.name)
 }
 
 // ReloadPathConfs is called by core.
@@ -509,6 +697,32 @@ func (pm *pathManager) FindPathConf(req defs.PathFindPathConfReq) (*conf.Path, e
 	}
 }
 
+// AddSimulcastLayer is called by a simulcast publisher
+// before publishing a layer on a sub-path.
+func (pm *pathManager) AddSimulcastLayer(req defs.PathAddSimulcastLayerReq) (*conf.Path, error) {
+	req.Res = make(chan defs.PathAddSimulcastLayerRes)
+	select {
+	case pm.chAddSimulcastLayer <- req:
+		res := <-req.Res
+		return res.Conf, res.Err
+
+	case <-pm.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// RemoveSimulcastLayer is called by a simulcast publisher
+// after it stops publishing a layer.
+func (pm *pathManager) RemoveSimulcastLayer(req defs.PathRemoveSimulcastLayerReq) {
+	req.Res = make(chan struct{})
+	select {
+	case pm.chRemoveSimulcastLayer <- req:
+		<-req.Res
+
+	case <-pm.ctx.Done():
+	}
+}
+
 // Describe is called by a reader or publisher.
 func (pm *pathManager) Describe(req defs.PathDescribeReq) defs.PathDescribeRes {
 	req.Res = make(chan defs.PathDescribeRes)
@@ -663,3 +877,138 @@ func (pm *pathManager) APIPathsGet(name string) (*defs.APIPath, error) {
 		return nil, fmt.Errorf("terminated")
 	}
 }
+
+// APIPathsRecordTrigger is called by api.
+func (pm *pathManager) APIPathsRecordTrigger(name string, data *defs.APIPathRecordTriggerReq) error {
+	req := pathAPIPathsGetReq{
+		name: name,
+		res:  make(chan pathAPIPathsGetRes),
+	}
+
+	select {
+	case pm.chAPIPathsGet <- req:
+		res := <-req.res
+		if res.err != nil {
+			return res.err
+		}
+
+		return res.path.APIPathsRecordTrigger(data)
+
+	case <-pm.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
+
+func (pm *pathManager) listPaths() (map[string]*path, error) {
+	req := pathAPIPathsListReq{
+		res: make(chan pathAPIPathsListRes),
+	}
+
+	select {
+	case pm.chAPIPathsList <- req:
+		res := <-req.res
+		return res.paths, nil
+
+	case <-pm.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+func (pm *pathManager) findForwarderManager(id uuid.UUID) (*forwarder.Manager, error) {
+	paths, err := pm.listPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pa := range paths {
+		if pa.forwarderManager != nil {
+			if _, err = pa.forwarderManager.APIForwardersGet(id); err == nil {
+				return pa.forwarderManager, nil
+			}
+		}
+	}
+
+	return nil, forwarder.ErrForwarderNotFound
+}
+
+// APIForwardersList is called by api.
+func (pm *pathManager) APIForwardersList() (*defs.APIForwarderList, error) {
+	paths, err := pm.listPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &defs.APIForwarderList{
+		Items: []*defs.APIForwarder{},
+	}
+
+	for _, pa := range paths {
+		if pa.forwarderManager != nil {
+			data.Items = append(data.Items, pa.forwarderManager.APIForwardersList()...)
+		}
+	}
+
+	sort.Slice(data.Items, func(i, j int) bool {
+		if data.Items[i].Path != data.Items[j].Path {
+			return data.Items[i].Path < data.Items[j].Path
+		}
+		return data.Items[i].Target < data.Items[j].Target
+	})
+
+	return data, nil
+}
+
+// APIForwardersGet is called by api.
+func (pm *pathManager) APIForwardersGet(id uuid.UUID) (*defs.APIForwarder, error) {
+	fm, err := pm.findForwarderManager(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return fm.APIForwardersGet(id)
+}
+
+// APIForwardersStart is called by api.
+func (pm *pathManager) APIForwardersStart(id uuid.UUID) error {
+	fm, err := pm.findForwarderManager(id)
+	if err != nil {
+		return err
+	}
+
+	return fm.APIForwardersStart(id)
+}
+
+// APIForwardersStop is called by api.
+func (pm *pathManager) APIForwardersStop(id uuid.UUID) error {
+	fm, err := pm.findForwarderManager(id)
+	if err != nil {
+		return err
+	}
+
+	return fm.APIForwardersStop(id)
+}
+
+// APIForwardersRestart is called by api.
+func (pm *pathManager) APIForwardersRestart(id uuid.UUID) error {
+	fm, err := pm.findForwarderManager(id)
+	if err != nil {
+		return err
+	}
+
+	return fm.APIForwardersRestart(id)
+}
+
+// APIForwardersAdd is called by api.
+func (pm *pathManager) APIForwardersAdd(pathName string, req *defs.APIForwardReq) (*defs.APIForwarder, error) {
+	paths, err := pm.listPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	pa, ok := paths[pathName]
+	if !ok {
+		return nil, conf.ErrPathNotFound
+	}
+
+	return pa.forwarderManager.APIForwardersAdd(req)
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/test"
 )
 
@@ -185,3 +187,57 @@ func TestPathConfigurationHotReload(t *testing.T) {
 	require.Equal(t, "undefined_stream", pathData.Name)
 	require.Equal(t, "all", pathData.ConfName)
 }
+
+func TestPathManagerSimulcastLayer(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  mypath:\n" +
+		"    maxReaders: 5\n" +
+		"    record: yes\n" +
+		"    runOnReady: ls\n" +
+		"  mypath~x:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	_, err := p.pathManager.FindPathConf(defs.PathFindPathConfReq{
+		AccessRequest: defs.PathAccessRequest{Name: "mypath~h"},
+	})
+	require.EqualError(t, err, "path 'mypath~h' is not configured")
+
+	// the layer is added by two publishers
+	for range 2 {
+		var layerConf *conf.Path
+		layerConf, err = p.pathManager.AddSimulcastLayer(defs.PathAddSimulcastLayerReq{
+			PathName:  "mypath",
+			LayerPath: "mypath~h",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "mypath~h", layerConf.Name)
+		require.Equal(t, 5, layerConf.MaxReaders)
+		require.False(t, layerConf.Record)
+		require.Empty(t, layerConf.RunOnReady)
+	}
+
+	_, err = p.pathManager.AddSimulcastLayer(defs.PathAddSimulcastLayerReq{
+		PathName:  "mypath",
+		LayerPath: "mypath~x",
+	})
+	require.EqualError(t, err, "path 'mypath~x' is already configured")
+
+	for i := range 2 {
+		var pathConf *conf.Path
+		pathConf, err = p.pathManager.FindPathConf(defs.PathFindPathConfReq{
+			AccessRequest: defs.PathAccessRequest{Name: "mypath~h"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "mypath~h", pathConf.Name)
+
+		p.pathManager.RemoveSimulcastLayer(defs.PathRemoveSimulcastLayerReq{LayerPath: "mypath~h"})
+
+		if i == 1 {
+			_, err = p.pathManager.FindPathConf(defs.PathFindPathConfReq{
+				AccessRequest: defs.PathAccessRequest{Name: "mypath~h"},
+			})
+			require.EqualError(t, err, "path 'mypath~h' is not configured")
+		}
+	}
+}
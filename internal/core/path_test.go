@@ -18,6 +18,7 @@ import (
 	"github.com/bluenviron/gortsplib/v5"
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
 	"github.com/bluenviron/gortsplib/v5/pkg/headers"
 	"github.com/bluenviron/gortsplib/v5/pkg/sdp"
 	srt "github.com/datarhei/gosrt"
@@ -782,6 +783,88 @@ func TestPathRecord(t *testing.T) {
 	require.Equal(t, 2, len(files))
 }
 
+func TestPathRecordOnTrigger(t *testing.T) {
+	for _, ca := range []string{
+		"api",
+		"command",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			dir, err := os.MkdirTemp("", "rtsp-path-record")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			cnf := "api: yes\n" +
+				"record: yes\n" +
+				"recordPath: " + filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f") + "\n" +
+				"paths:\n" +
+				"  all_others:\n" +
+				"    recordOnTrigger: yes\n" +
+				"    recordTriggerAfter: 0s\n"
+
+			if ca == "command" {
+				cnf += "    recordTriggerCommand: sh -c 'sleep 1 && echo \"$MTX_PATH\" && sleep 10'\n"
+			}
+
+			p, ok := newInstance(cnf)
+			require.Equal(t, true, ok)
+			defer p.Close()
+
+			media0 := test.UniqueMediaH264()
+
+			source := gortsplib.Client{}
+
+			err = source.StartRecording(
+				"rtsp://localhost:8554/mystream",
+				&description.Session{Medias: []*description.Media{media0}})
+			require.NoError(t, err)
+			defer source.Close()
+
+			writePackets := func(start int) {
+				for i := start; i < start+4; i++ {
+					err = source.WritePacketRTP(media0, &rtp.Packet{
+						Header: rtp.Header{
+							Version:        2,
+							Marker:         true,
+							PayloadType:    96,
+							SequenceNumber: 1123 + uint16(i),
+							Timestamp:      45343 + 90000*uint32(i),
+							SSRC:           563423,
+						},
+						Payload: []byte{5},
+					})
+					require.NoError(t, err)
+				}
+			}
+
+			writePackets(0)
+
+			time.Sleep(500 * time.Millisecond)
+
+			_, err = os.ReadDir(filepath.Join(dir, "mystream"))
+			require.Error(t, err)
+
+			if ca == "api" {
+				tr := &http.Transport{}
+				defer tr.CloseIdleConnections()
+				hc := &http.Client{Transport: tr}
+
+				httpRequest(t, hc, http.MethodPost,
+					"http://localhost:9997/v3/paths/record/trigger/mystream?before=2s", nil, nil)
+			} else {
+				time.Sleep(1 * time.Second)
+			}
+
+			writePackets(4)
+
+			time.Sleep(500 * time.Millisecond)
+
+			files, err := os.ReadDir(filepath.Join(dir, "mystream"))
+			require.NoError(t, err)
+			require.Equal(t, 1, len(files))
+		})
+	}
+}
+
 func TestPathFallback(t *testing.T) {
 	for _, ca := range []string{
 		"absolute",
@@ -1010,3 +1093,158 @@ func TestPathOverridePublisher(t *testing.T) {
 		})
 	}
 }
+
+func TestPathRTSPForward(t *testing.T) {
+	for _, ca := range []string{
+		"udp",
+		"tcp",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			p, ok := newInstance("rtmp: no\n" +
+				"hls: no\n" +
+				"webrtc: no\n" +
+				"paths:\n" +
+				"  source:\n" +
+				"    rtspForwardTargets:\n" +
+				"    - url: rtsp://localhost:8554/dest\n" +
+				"      enable: yes\n" +
+				"      transport: " + ca + "\n" +
+				"  dest:\n")
+			require.Equal(t, true, ok)
+			defer p.Close()
+
+			medi := test.UniqueMediaH264()
+
+			source := gortsplib.Client{}
+
+			err := source.StartRecording("rtsp://localhost:8554/source",
+				&description.Session{Medias: []*description.Media{medi}})
+			require.NoError(t, err)
+			defer source.Close()
+
+			u, err := base.ParseURL("rtsp://localhost:8554/dest")
+			require.NoError(t, err)
+
+			var reader *gortsplib.Client
+			var desc *description.Session
+
+			// wait for the forwarder to publish
+			for range 50 {
+				reader = &gortsplib.Client{
+					Scheme: u.Scheme,
+					Host:   u.Host,
+				}
+
+				err = reader.Start()
+				require.NoError(t, err)
+
+				desc, _, err = reader.Describe(u)
+				if err == nil {
+					break
+				}
+
+				reader.Close()
+				time.Sleep(100 * time.Millisecond)
+			}
+			require.NoError(t, err)
+			defer reader.Close()
+			require.Equal(t, 1, len(desc.Medias))
+
+			err = reader.SetupAll(desc.BaseURL, desc.Medias)
+			require.NoError(t, err)
+
+			frameRecv := make(chan struct{})
+
+			reader.OnPacketRTP(desc.Medias[0], desc.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+				require.Equal(t, []byte{5, 15, 16, 17, 18}, pkt.Payload)
+				close(frameRecv)
+			})
+
+			_, err = reader.Play(nil)
+			require.NoError(t, err)
+
+			err = source.WritePacketRTP(medi, &rtp.Packet{
+				Header: rtp.Header{
+					Version:        0x02,
+					PayloadType:    96,
+					SequenceNumber: 57899,
+					Timestamp:      345234345,
+					SSRC:           978651231,
+					Marker:         true,
+				},
+				Payload: []byte{5, 15, 16, 17, 18},
+			})
+			require.NoError(t, err)
+
+			<-frameRecv
+		})
+	}
+}
+
+func TestPathRTMPForward(t *testing.T) {
+	p, ok := newInstance("hls: no\n" +
+		"webrtc: no\n" +
+		"paths:\n" +
+		"  source:\n" +
+		"    rtmpForwardTargets:\n" +
+		"    - url: rtmp://localhost:1935/dest\n" +
+		"      enable: yes\n" +
+		"  dest:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	medi := test.UniqueMediaH264()
+
+	source := gortsplib.Client{}
+
+	err := source.StartRecording("rtsp://localhost:8554/source",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	u, err := base.ParseURL("rtsp://localhost:8554/dest")
+	require.NoError(t, err)
+
+	var reader *gortsplib.Client
+	var desc *description.Session
+
+	// wait for the forwarder to publish
+	for i := range 50 {
+		// RTMP publishers become ready after receiving the first frames
+		err = source.WritePacketRTP(medi, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: 1234 + uint16(i),
+				Timestamp:      45343 + uint32(i)*9000,
+				SSRC:           563423,
+				Marker:         true,
+			},
+			Payload: []byte{5, 1},
+		})
+		require.NoError(t, err)
+
+		reader = &gortsplib.Client{
+			Scheme: u.Scheme,
+			Host:   u.Host,
+		}
+
+		err = reader.Start()
+		require.NoError(t, err)
+
+		desc, _, err = reader.Describe(u)
+		if err == nil {
+			break
+		}
+
+		reader.Close()
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var forma *format.H264
+	medi2 := desc.FindFormat(&forma)
+	require.NotNil(t, medi2)
+	require.Equal(t, test.FormatH264.SPS, forma.SPS)
+}
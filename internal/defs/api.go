@@ -12,6 +12,7 @@ import (
 type APIPathManager interface {
 	APIPathsList() (*APIPathList, error)
 	APIPathsGet(string) (*APIPath, error)
+	APIPathsRecordTrigger(string, *APIPathRecordTriggerReq) error
 }
 
 // APIHLSServer contains methods used by the API and Metrics server.
@@ -50,6 +51,16 @@ type APIWebRTCServer interface {
 	APISessionsKick(uuid.UUID) error
 }
 
+// APIForwarderManager contains methods used by the API and Metrics server.
+type APIForwarderManager interface {
+	APIForwardersList() (*APIForwarderList, error)
+	APIForwardersGet(uuid.UUID) (*APIForwarder, error)
+	APIForwardersStart(uuid.UUID) error
+	APIForwardersStop(uuid.UUID) error
+	APIForwardersRestart(uuid.UUID) error
+	APIForwardersAdd(string, *APIForwardReq) (*APIForwarder, error)
+}
+
 // APIOK is returned on success.
 type APIOK struct {
 	Status string `json:"status"`
@@ -76,21 +87,33 @@ type APIPathConfList struct {
 
 // APIPathSourceOrReader is a source or a reader.
 type APIPathSourceOrReader struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
+	Type   string               `json:"type"`
+	ID     string               `json:"id"`
+	Layers []*APISimulcastLayer `json:"layers,omitempty"`
+}
+
+// APISimulcastLayer is a layer of a simulcast source.
+type APISimulcastLayer struct {
+	Layer              string `json:"layer"`
+	Path               string `json:"path"`
+	Online             bool   `json:"online"`
+	RTPPacketsReceived uint64 `json:"rtpPacketsReceived"`
+	BytesReceived      uint64 `json:"bytesReceived"`
 }
 
 // APIPath is a path.
 type APIPath struct {
-	Name          string                  `json:"name"`
-	ConfName      string                  `json:"confName"`
-	Source        *APIPathSourceOrReader  `json:"source"`
-	Ready         bool                    `json:"ready"`
-	ReadyTime     *time.Time              `json:"readyTime"`
-	Tracks        []string                `json:"tracks"`
-	BytesReceived uint64                  `json:"bytesReceived"`
-	BytesSent     uint64                  `json:"bytesSent"`
-	Readers       []APIPathSourceOrReader `json:"readers"`
+	Name              string                  `json:"name"`
+	ConfName          string                  `json:"confName"`
+	Source            *APIPathSourceOrReader  `json:"source"`
+	Ready             bool                    `json:"ready"`
+	ReadyTime         *time.Time              `json:"readyTime"`
+	Tracks            []string                `json:"tracks"`
+	BytesReceived     uint64                  `json:"bytesReceived"`
+	BytesSent         uint64                  `json:"bytesSent"`
+	Readers           []APIPathSourceOrReader `json:"readers"`
+	Forwarders        []*APIForwarder         `json:"forwarders"`
+	TranscoderOutputs []*APITranscoderOutput  `json:"transcoderOutputs"`
 }
 
 // APIPathList is a list of paths.
@@ -100,6 +123,13 @@ type APIPathList struct {
 	Items     []*APIPath `json:"items"`
 }
 
+// APIPathRecordTriggerReq is a request to trigger recording.
+// Durations that are not set are taken from the path configuration.
+type APIPathRecordTriggerReq struct {
+	Before *time.Duration
+	After  *time.Duration
+}
+
 // APIHLSMuxer is an HLS muxer.
 type APIHLSMuxer struct {
 	Path        string    `json:"path"`
@@ -387,6 +417,60 @@ type APIWebRTCSessionList struct {
 	Items     []*APIWebRTCSession `json:"items"`
 }
 
+// APIForwarderState is the state of a forwarder.
+type APIForwarderState string
+
+// states.
+const (
+	APIForwarderStateIdle    APIForwarderState = "idle"
+	APIForwarderStateRunning APIForwarderState = "running"
+	APIForwarderStateStopped APIForwarderState = "stopped"
+	APIForwarderStateFailed  APIForwarderState = "failed"
+)
+
+// APIForwarder is a forwarder.
+type APIForwarder struct {
+	ID             uuid.UUID         `json:"id"`
+	Path           string            `json:"path"`
+	Target         string            `json:"target"`
+	Protocol       string            `json:"protocol"`
+	Temporary      bool              `json:"temporary"`
+	State          APIForwarderState `json:"state"`
+	Connected      bool              `json:"connected"`
+	Uptime         conf.Duration     `json:"uptime"`
+	BytesSent      uint64            `json:"bytesSent"`
+	PacketsSent    uint64            `json:"packetsSent"`
+	PacketsLost    uint64            `json:"packetsLost"`
+	ReconnectCount uint64            `json:"reconnectCount"`
+	DroppedGOPs    uint64            `json:"droppedGOPs"`
+	LastError      *string           `json:"lastError"`
+}
+
+// APIForwardReq is a request to start a temporary forwarder.
+// Exactly one target must be provided.
+type APIForwardReq struct {
+	SRT    *conf.SRTForwardTarget    `json:"srt"`
+	WebRTC *conf.WebRTCForwardTarget `json:"webrtc"`
+	RTSP   *conf.RTSPForwardTarget   `json:"rtsp"`
+	RTMP   *conf.RTMPForwardTarget   `json:"rtmp"`
+}
+
+// APIForwarderList is a list of forwarders.
+type APIForwarderList struct {
+	ItemCount int             `json:"itemCount"`
+	PageCount int             `json:"pageCount"`
+	Items     []*APIForwarder `json:"items"`
+}
+
+// APITranscoderOutput is a transcoder output.
+type APITranscoderOutput struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Ready    bool    `json:"ready"`
+	FPS      float64 `json:"fps"`
+	Restarts uint64  `json:"restarts"`
+}
+
 // APIRecordingSegment is a recording segment.
 type APIRecordingSegment struct {
 	Start time.Time `json:"start"`
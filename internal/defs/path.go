@@ -41,6 +41,25 @@ type PathFindPathConfReq struct {
 	Res           chan PathFindPathConfRes
 }
 
+// PathAddSimulcastLayerRes contains the response of AddSimulcastLayer().
+type PathAddSimulcastLayerRes struct {
+	Conf *conf.Path
+	Err  error
+}
+
+// PathAddSimulcastLayerReq contains arguments of AddSimulcastLayer().
+type PathAddSimulcastLayerReq struct {
+	PathName  string
+	LayerPath string
+	Res       chan PathAddSimulcastLayerRes
+}
+
+// PathRemoveSimulcastLayerReq contains arguments of RemoveSimulcastLayer().
+type PathRemoveSimulcastLayerReq struct {
+	LayerPath string
+	Res       chan struct{}
+}
+
 // PathDescribeRes contains the response of Describe().
 type PathDescribeRes struct {
 	Path     Path
@@ -4,6 +4,7 @@ package externalcmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"time"
 )
@@ -26,6 +27,7 @@ type Cmd struct {
 	cmdstr  string
 	restart bool
 	env     Environment
+	stdout  io.Writer
 	onExit  func(error)
 
 	// in
@@ -39,6 +41,18 @@ func NewCmd(
 	restart bool,
 	env Environment,
 	onExit OnExitFunc,
+) *Cmd {
+	return NewCmdWithStdout(pool, cmdstr, restart, env, os.Stdout, onExit)
+}
+
+// NewCmdWithStdout allocates a Cmd whose standard output is written into stdout.
+func NewCmdWithStdout(
+	pool *Pool,
+	cmdstr string,
+	restart bool,
+	env Environment,
+	stdout io.Writer,
+	onExit OnExitFunc,
 ) *Cmd {
 	// replace variables in both Linux and Windows, in order to allow using the
 	// same commands on both of them.
@@ -58,6 +72,7 @@ func NewCmd(
 		cmdstr:    cmdstr,
 		restart:   restart,
 		env:       env,
+		stdout:    stdout,
 		onExit:    onExit,
 		terminate: make(chan struct{}),
 	}
@@ -21,7 +21,7 @@ func (e *Cmd) runOSSpecific(env []string) error {
 	cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
 
 	cmd.Env = env
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = e.stdout
 	cmd.Stderr = os.Stderr
 
 	// set process group in order to allow killing subprocesses
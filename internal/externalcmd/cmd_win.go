@@ -88,7 +88,7 @@ func (e *Cmd) runOSSpecific(env []string) error {
 	}
 
 	cmd.Env = env
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = e.stdout
 	cmd.Stderr = os.Stderr
 
 	// create a process group to kill all subprocesses
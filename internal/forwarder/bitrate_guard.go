@@ -0,0 +1,181 @@
+package forwarder
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	// interval between two measurements of the source bitrate
+	bitrateGuardInterval = 1 * time.Second
+
+	// number of intervals the bitrate is averaged over
+	bitrateGuardWindow = 5
+
+	// the guard is released when the bitrate goes below this fraction of the maximum,
+	// in order to prevent oscillations
+	bitrateGuardHysteresis = 0.9
+)
+
+// bitrateGuard measures the bitrate of the source stream and, when it exceeds maxBitrate,
+// switches the forwarder to a fallback path or, when the fallback path is not set or not available,
+// pauses video and keeps audio.
+type bitrateGuard struct {
+	maxBitrate   uint
+	fallbackPath string
+	pathName     string
+	target       string
+	pathManager  PathManager
+	relay        *relay
+	main         *relaySource
+	logger       logger.Writer
+
+	ctx       context.Context
+	ctxCancel func()
+
+	fallbackReader *pathReader
+	fallback       *relaySource
+
+	done chan struct{}
+}
+
+func (g *bitrateGuard) initialize() {
+	g.ctx, g.ctxCancel = context.WithCancel(context.Background())
+	g.done = make(chan struct{})
+
+	go g.run()
+}
+
+func (g *bitrateGuard) close() {
+	g.ctxCancel()
+	<-g.done
+}
+
+func (g *bitrateGuard) run() {
+	defer close(g.done)
+	defer g.releaseFallback()
+
+	ticker := time.NewTicker(bitrateGuardInterval)
+	defer ticker.Stop()
+
+	samples := []uint64{g.main.stream.BytesReceived()}
+	exceeded := false
+
+	for {
+		var fallbackClosed chan struct{}
+		if g.fallbackReader != nil {
+			fallbackClosed = g.fallbackReader.closed
+		}
+
+		select {
+		case <-ticker.C:
+			samples = append(samples, g.main.stream.BytesReceived())
+			if len(samples) > (bitrateGuardWindow + 1) {
+				samples = samples[1:]
+			}
+
+			bitrate := float64(samples[len(samples)-1]-samples[0]) * 8 /
+				(float64(len(samples)-1) * bitrateGuardInterval.Seconds())
+
+			switch {
+			case !exceeded && bitrate > float64(g.maxBitrate):
+				exceeded = true
+				g.logger.Log(logger.Warn, "source bitrate (%d kbit/s) exceeds maxBitrate (%d kbit/s)",
+					uint64(bitrate)/1000, g.maxBitrate/1000)
+				g.onExceeded()
+
+			case exceeded && bitrate < float64(g.maxBitrate)*bitrateGuardHysteresis:
+				exceeded = false
+				g.logger.Log(logger.Info, "source bitrate (%d kbit/s) is back under maxBitrate",
+					uint64(bitrate)/1000)
+				g.onRecovered()
+			}
+
+		case <-fallbackClosed:
+			g.logger.Log(logger.Warn, "fallback path '%s' is not available anymore, pausing video", g.fallbackPath)
+			g.relay.setVideoPaused(true)
+			g.relay.switchTo(g.main)
+			g.releaseFallback()
+
+		case <-g.ctx.Done():
+			return
+		}
+	}
+}
+
+func (g *bitrateGuard) onExceeded() {
+	if g.fallbackPath != "" {
+		err := g.attachFallback()
+		if err == nil {
+			g.logger.Log(logger.Info, "switching to fallback path '%s'", g.fallbackPath)
+			return
+		}
+
+		g.logger.Log(logger.Warn, "fallback path '%s' is not available: %v", g.fallbackPath, err)
+	}
+
+	g.logger.Log(logger.Info, "pausing video")
+	g.relay.setVideoPaused(true)
+}
+
+func (g *bitrateGuard) onRecovered() {
+	g.relay.setVideoPaused(false)
+
+	if g.fallback == nil {
+		return
+	}
+
+	// keep reading the fallback path until the main stream is in use,
+	// in order to avoid interruptions.
+	g.relay.switchTo(g.main)
+
+	for !g.relay.isActive(g.main) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-g.fallbackReader.closed:
+			g.relay.switchTo(g.main)
+			g.releaseFallback()
+			return
+		case <-g.ctx.Done():
+			return
+		}
+	}
+
+	g.logger.Log(logger.Info, "switching back to main path")
+	g.releaseFallback()
+}
+
+func (g *bitrateGuard) attachFallback() error {
+	fallbackReader, err := readPath(g.pathManager, g.fallbackPath, g.pathName, g.target)
+	if err != nil {
+		return err
+	}
+
+	src, err := g.relay.newSource(fallbackReader.stream, true)
+	if err != nil {
+		fallbackReader.release()
+		return err
+	}
+
+	g.fallbackReader = fallbackReader
+	g.fallback = src
+
+	g.relay.attach(src)
+	g.relay.switchTo(src)
+
+	return nil
+}
+
+func (g *bitrateGuard) releaseFallback() {
+	if g.fallback == nil {
+		return
+	}
+
+	g.relay.detach(g.fallback)
+	g.fallbackReader.release()
+
+	g.fallbackReader = nil
+	g.fallback = nil
+}
@@ -1,6 +1,9 @@
+// Package forwarder contains the stream forwarders.
 package forwarder
 
 import (
+	"time"
+
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
@@ -13,6 +16,7 @@ type Forwarder interface {
 	Stop()
 
 	// IsRunning returns whether the forwarder is running.
+	// A forwarder that gave up reconnecting is not running.
 	IsRunning() bool
 
 	// GetStats returns statistics.
@@ -24,11 +28,16 @@ type Forwarder interface {
 
 // Stats contains forwarder statistics.
 type Stats struct {
-	BytesSent     uint64
-	PacketsSent   uint64
-	PacketsLost   uint64
-	LastError     error
-	Connected     bool
+	BytesSent      uint64
+	PacketsSent    uint64
+	PacketsLost    uint64
+	LastError      error
+	Connected      bool
 	ReconnectCount uint64
+	// GOPs dropped because the output was late
+	DroppedGOPs uint64
+	// The forwarder gave up reconnecting
+	Failed bool
+	// Time elapsed since the current connection was established
+	Uptime time.Duration
 }
-
@@ -0,0 +1,52 @@
+package forwarder
+
+import (
+	"bytes"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/av1"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4video"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/vp9"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// start code of MPEG-1/2 video group of pictures.
+const mpeg1VideoGOPStartCode = 0xB8
+
+// isRandomAccess checks whether decoding can start from a unit.
+// Units of non-video codecs are always random access units.
+func isRandomAccess(u *unit.Unit) bool {
+	if u.NilPayload() {
+		return false
+	}
+
+	switch payload := u.Payload.(type) {
+	case unit.PayloadH264:
+		return h264.IsRandomAccess(payload)
+
+	case unit.PayloadH265:
+		return h265.IsRandomAccess(payload)
+
+	case unit.PayloadAV1:
+		return av1.IsRandomAccess2(payload)
+
+	case unit.PayloadVP9:
+		var h vp9.Header
+		err := h.Unmarshal(payload)
+		return err == nil && !h.NonKeyFrame
+
+	case unit.PayloadVP8:
+		// the first bit of the frame tag is zero in key frames
+		return len(payload) != 0 && (payload[0]&0x01) == 0
+
+	case unit.PayloadMPEG4Video:
+		return bytes.Contains(payload, []byte{0, 0, 1, byte(mpeg4video.GroupOfVOPStartCode)})
+
+	case unit.PayloadMPEG1Video:
+		return bytes.Contains(payload, []byte{0, 0, 1, mpeg1VideoGOPStartCode})
+	}
+
+	return true
+}
@@ -2,23 +2,57 @@ package forwarder
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
+// ErrForwarderNotFound is returned when a forwarder is not found.
+var ErrForwarderNotFound = errors.New("forwarder not found")
+
+// ErrInvalidForwardTarget is returned when a forward target is not valid.
+var ErrInvalidForwardTarget = errors.New("invalid forward target")
+
+type managedForwarder struct {
+	id        uuid.UUID
+	protocol  string
+	forwarder Forwarder
+
+	// identity of the target configuration, used to detect changes
+	key string
+
+	// started through the API, not part of the configuration
+	temporary bool
+
+	// stopped through the API
+	stopped bool
+}
+
 // Manager manages all forwarders.
 type Manager struct {
-	forwarders       []Forwarder
-	stream           *stream.Stream
-	logger           logger.Writer
-	ctx              context.Context
-	ctxCancel        context.CancelFunc
-	writeTimeout     time.Duration
+	forwarders        []*managedForwarder
+	stream            *stream.Stream
+	logger            logger.Writer
+	ctx               context.Context
+	ctxCancel         context.CancelFunc
+	writeTimeout      time.Duration
 	udpMaxPayloadSize int
+	udpReadBufferSize uint
+	pathName          string
+	pathManager       PathManager
+	onFailure         func(protocol string, target string, err error)
+
+	mutex sync.Mutex
 }
 
 // NewManager creates a new forwarder manager.
@@ -26,12 +60,16 @@ func NewManager(
 	ctx context.Context,
 	srtTargets []conf.SRTForwardTarget,
 	webrtcTargets []conf.WebRTCForwardTarget,
+	rtspTargets []conf.RTSPForwardTarget,
+	rtmpTargets []conf.RTMPForwardTarget,
 	stream *stream.Stream,
 	parent logger.Writer,
 	writeTimeout time.Duration,
 	udpMaxPayloadSize int,
 	udpReadBufferSize uint,
 	pathName string,
+	pathManager PathManager,
+	onFailure func(protocol string, target string, err error),
 ) *Manager {
 	ctx, ctxCancel := context.WithCancel(ctx)
 
@@ -42,72 +80,403 @@ func NewManager(
 		ctxCancel:         ctxCancel,
 		writeTimeout:      writeTimeout,
 		udpMaxPayloadSize: udpMaxPayloadSize,
+		udpReadBufferSize: udpReadBufferSize,
+		pathName:          pathName,
+		pathManager:       pathManager,
+		onFailure:         onFailure,
 	}
 
-	// create SRT forwarders
+	for _, target := range enabledTargets(srtTargets, webrtcTargets, rtspTargets, rtmpTargets) {
+		m.add(target, false)
+	}
+
+	return m
+}
+
+// enabledTargets returns the enabled targets, in order of protocol.
+func enabledTargets(
+	srtTargets []conf.SRTForwardTarget,
+	webrtcTargets []conf.WebRTCForwardTarget,
+	rtspTargets []conf.RTSPForwardTarget,
+	rtmpTargets []conf.RTMPForwardTarget,
+) []any {
+	var ret []any
+
 	for _, target := range srtTargets {
-		if !target.Enable {
-			continue
+		if target.Enable {
+			ret = append(ret, &target)
 		}
+	}
 
-		// replace $MTX_PATH variable in URL
-		resolvedURL := strings.ReplaceAll(target.URL, "$MTX_PATH", pathName)
-		
-		// log resolved URL for debugging
-		parent.Log(logger.Debug, "SRT forwarder: resolved URL from '%s' to '%s'", target.URL, resolvedURL)
+	for _, target := range webrtcTargets {
+		if target.Enable {
+			ret = append(ret, &target)
+		}
+	}
 
-		forwarder := newSRTForwarder(resolvedURL, &target, parent, writeTimeout, udpMaxPayloadSize)
-		m.forwarders = append(m.forwarders, forwarder)
+	for _, target := range rtspTargets {
+		if target.Enable {
+			ret = append(ret, &target)
+		}
 	}
 
-	// create WebRTC forwarders
-	for _, target := range webrtcTargets {
-		if !target.Enable {
+	for _, target := range rtmpTargets {
+		if target.Enable {
+			ret = append(ret, &target)
+		}
+	}
+
+	return ret
+}
+
+// targetKey returns the identity of a target configuration.
+func targetKey(target any) string {
+	buf, _ := json.Marshal(target)
+	return fmt.Sprintf("%T:%s", target, buf)
+}
+
+func (m *Manager) resolveURL(protocol string, u string) string {
+	// replace $MTX_PATH variable in URL
+	resolvedURL := strings.ReplaceAll(u, "$MTX_PATH", m.pathName)
+
+	// log resolved URL for debugging
+	m.logger.Log(logger.Debug, "%s forwarder: resolved URL from '%s' to '%s'", protocol, u, resolvedURL)
+
+	return resolvedURL
+}
+
+func (m *Manager) failureCallback(protocol string, target string) func(error) {
+	if m.onFailure == nil {
+		return nil
+	}
+
+	return func(err error) {
+		m.onFailure(protocol, target, err)
+	}
+}
+
+func (m *Manager) add(target any, temporary bool) *managedForwarder {
+	mf := &managedForwarder{
+		id:        uuid.New(),
+		key:       targetKey(target),
+		temporary: temporary,
+	}
+
+	switch target := target.(type) {
+	case *conf.SRTForwardTarget:
+		mf.protocol = "srt"
+		url := m.resolveURL("SRT", target.URL)
+		mf.forwarder = newSRTForwarder(url, target, m.logger,
+			m.writeTimeout, m.udpMaxPayloadSize, m.pathName, m.pathManager, m.failureCallback(mf.protocol, url))
+
+	case *conf.WebRTCForwardTarget:
+		mf.protocol = "webrtc"
+		url := m.resolveURL("WebRTC", target.URL)
+		mf.forwarder = newWebRTCForwarder(url, target, m.logger,
+			m.writeTimeout, m.udpReadBufferSize, m.pathName, m.pathManager, m.failureCallback(mf.protocol, url))
+
+	case *conf.RTSPForwardTarget:
+		mf.protocol = "rtsp"
+		url := m.resolveURL("RTSP", target.URL)
+		mf.forwarder = newRTSPForwarder(url, target, m.logger,
+			m.writeTimeout, m.udpReadBufferSize, m.failureCallback(mf.protocol, url))
+
+	case *conf.RTMPForwardTarget:
+		mf.protocol = "rtmp"
+		url := m.resolveURL("RTMP", target.URL)
+		mf.forwarder = newRTMPForwarder(url, target, m.logger,
+			m.writeTimeout, m.failureCallback(mf.protocol, url))
+	}
+
+	m.forwarders = append(m.forwarders, mf)
+
+	return mf
+}
+
+// ReloadConf applies a new set of targets.
+// Forwarders of new targets are started, forwarders of removed targets are stopped,
+// forwarders of unchanged targets and temporary forwarders are left untouched.
+func (m *Manager) ReloadConf(
+	srtTargets []conf.SRTForwardTarget,
+	webrtcTargets []conf.WebRTCForwardTarget,
+	rtspTargets []conf.RTSPForwardTarget,
+	rtmpTargets []conf.RTMPForwardTarget,
+) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	targets := enabledTargets(srtTargets, webrtcTargets, rtspTargets, rtmpTargets)
+
+	// number of wanted forwarders for each target
+	wanted := make(map[string]int)
+	for _, target := range targets {
+		wanted[targetKey(target)]++
+	}
+
+	var kept []*managedForwarder
+
+	for _, mf := range m.forwarders {
+		if mf.temporary {
+			kept = append(kept, mf)
 			continue
 		}
 
-		// replace $MTX_PATH variable in URL
-		resolvedURL := strings.ReplaceAll(target.URL, "$MTX_PATH", pathName)
-		
-		// log resolved URL for debugging
-		parent.Log(logger.Debug, "WebRTC forwarder: resolved URL from '%s' to '%s'", target.URL, resolvedURL)
+		if wanted[mf.key] > 0 {
+			wanted[mf.key]--
+			kept = append(kept, mf)
+			continue
+		}
 
-		forwarder := newWebRTCForwarder(resolvedURL, &target, parent, writeTimeout, udpReadBufferSize)
-		m.forwarders = append(m.forwarders, forwarder)
+		mf.forwarder.Stop()
+		m.logger.Log(logger.Info, "forwarder %s removed", mf.forwarder.GetTarget())
 	}
 
-	return m
+	m.forwarders = kept
+
+	for _, target := range targets {
+		key := targetKey(target)
+		if wanted[key] == 0 {
+			continue
+		}
+		wanted[key]--
+
+		mf := m.add(target, false)
+		m.logger.Log(logger.Info, "forwarder %s added", mf.forwarder.GetTarget())
+
+		if m.stream != nil {
+			m.startForwarder(mf)
+		}
+	}
 }
 
 // Start starts all forwarders.
 func (m *Manager) Start(stream *stream.Stream) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	m.stream = stream
 
-		for _, f := range m.forwarders {
-		go func(forwarder Forwarder) {
-			err := forwarder.Start(stream)
-			if err != nil {
-				m.logger.Log(logger.Warn, "failed to start forwarder %s: %v", forwarder.GetTarget(), err)
-			}
-		}(f)
+	for _, mf := range m.forwarders {
+		if !mf.stopped {
+			m.startForwarder(mf)
+		}
 	}
 }
 
 // Stop stops all forwarders.
 func (m *Manager) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, mf := range m.forwarders {
+		mf.forwarder.Stop()
+	}
+
+	m.stream = nil
+}
+
+// Close stops all forwarders and releases resources.
+func (m *Manager) Close() {
+	m.Stop()
 	m.ctxCancel()
+}
 
-	for _, f := range m.forwarders {
-		f.Stop()
+func (m *Manager) startForwarder(mf *managedForwarder) {
+	err := mf.forwarder.Start(m.stream)
+	if err != nil {
+		m.logger.Log(logger.Warn, "failed to start forwarder %s: %v", mf.forwarder.GetTarget(), err)
 	}
 }
 
 // GetStats returns statistics for all forwarders.
 func (m *Manager) GetStats() []Stats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	var stats []Stats
-	for _, f := range m.forwarders {
-		stats = append(stats, f.GetStats())
+	for _, mf := range m.forwarders {
+		stats = append(stats, mf.forwarder.GetStats())
 	}
 	return stats
 }
 
+func (m *Manager) apiItem(mf *managedForwarder) *defs.APIForwarder {
+	stats := mf.forwarder.GetStats()
+
+	item := &defs.APIForwarder{
+		ID:        mf.id,
+		Path:      m.pathName,
+		Target:    mf.forwarder.GetTarget(),
+		Protocol:  mf.protocol,
+		Temporary: mf.temporary,
+		State: func() defs.APIForwarderState {
+			switch {
+			case mf.stopped:
+				return defs.APIForwarderStateStopped
+			case stats.Failed:
+				return defs.APIForwarderStateFailed
+			case m.stream == nil:
+				return defs.APIForwarderStateIdle
+			default:
+				return defs.APIForwarderStateRunning
+			}
+		}(),
+		Connected:      stats.Connected,
+		Uptime:         conf.Duration(stats.Uptime),
+		BytesSent:      stats.BytesSent,
+		PacketsSent:    stats.PacketsSent,
+		PacketsLost:    stats.PacketsLost,
+		ReconnectCount: stats.ReconnectCount,
+		DroppedGOPs:    stats.DroppedGOPs,
+	}
+
+	if stats.LastError != nil {
+		v := stats.LastError.Error()
+		item.LastError = &v
+	}
+
+	return item
+}
+
+func (m *Manager) find(id uuid.UUID) *managedForwarder {
+	for _, mf := range m.forwarders {
+		if mf.id == id {
+			return mf
+		}
+	}
+	return nil
+}
+
+// APIForwardersList is called by api.
+func (m *Manager) APIForwardersList() []*defs.APIForwarder {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	items := []*defs.APIForwarder{}
+	for _, mf := range m.forwarders {
+		items = append(items, m.apiItem(mf))
+	}
+	return items
+}
+
+// APIForwardersGet is called by api.
+func (m *Manager) APIForwardersGet(id uuid.UUID) (*defs.APIForwarder, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mf := m.find(id)
+	if mf == nil {
+		return nil, ErrForwarderNotFound
+	}
+
+	return m.apiItem(mf), nil
+}
+
+// APIForwardersStart is called by api.
+func (m *Manager) APIForwardersStart(id uuid.UUID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mf := m.find(id)
+	if mf == nil {
+		return ErrForwarderNotFound
+	}
+
+	mf.stopped = false
+
+	// the forwarder is started as soon as the path becomes ready
+	if m.stream != nil && !mf.forwarder.IsRunning() {
+		// release a forwarder that gave up reconnecting
+		mf.forwarder.Stop()
+		m.startForwarder(mf)
+	}
+
+	return nil
+}
+
+// APIForwardersStop is called by api.
+func (m *Manager) APIForwardersStop(id uuid.UUID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mf := m.find(id)
+	if mf == nil {
+		return ErrForwarderNotFound
+	}
+
+	mf.stopped = true
+	mf.forwarder.Stop()
+
+	return nil
+}
+
+// APIForwardersRestart is called by api.
+func (m *Manager) APIForwardersRestart(id uuid.UUID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mf := m.find(id)
+	if mf == nil {
+		return ErrForwarderNotFound
+	}
+
+	mf.stopped = false
+	mf.forwarder.Stop()
+
+	if m.stream != nil {
+		m.startForwarder(mf)
+	}
+
+	return nil
+}
+
+// APIForwardersAdd is called by api.
+// The forwarder is temporary: it is not saved into the configuration and lasts
+// until the path is closed.
+func (m *Manager) APIForwardersAdd(req *defs.APIForwardReq) (*defs.APIForwarder, error) {
+	var target any
+	n := 0
+
+	if req.SRT != nil {
+		target = req.SRT
+		n++
+	}
+	if req.WebRTC != nil {
+		target = req.WebRTC
+		n++
+	}
+	if req.RTSP != nil {
+		target = req.RTSP
+		n++
+	}
+	if req.RTMP != nil {
+		target = req.RTMP
+		n++
+	}
+
+	if n != 1 {
+		return nil, fmt.Errorf("%w: exactly one of 'srt', 'webrtc', 'rtsp', 'rtmp' must be provided",
+			ErrInvalidForwardTarget)
+	}
+
+	err := target.(interface{ Validate() error }).Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidForwardTarget, err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.ctx.Err() != nil {
+		return nil, fmt.Errorf("terminated")
+	}
+
+	mf := m.add(target, true)
+	m.logger.Log(logger.Info, "temporary forwarder %s added", mf.forwarder.GetTarget())
+
+	if m.stream != nil {
+		m.startForwarder(mf)
+	}
+
+	return m.apiItem(mf), nil
+}
@@ -0,0 +1,167 @@
+package forwarder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func forwarderIDs(m *Manager) map[string]uuid.UUID {
+	ret := make(map[string]uuid.UUID)
+	for _, item := range m.APIForwardersList() {
+		ret[item.Target] = item.ID
+	}
+	return ret
+}
+
+func TestManagerReloadConf(t *testing.T) {
+	m := NewManager(
+		context.Background(),
+		nil,
+		nil,
+		nil,
+		[]conf.RTMPForwardTarget{
+			{URL: "rtmp://server1/live/$MTX_PATH", Enable: true},
+			{URL: "rtmp://server2/live/$MTX_PATH", Enable: true},
+			{URL: "rtmp://server3/live/$MTX_PATH", Enable: false},
+		},
+		nil,
+		test.NilLogger,
+		10*time.Second,
+		1472,
+		0,
+		"mypath",
+		nil,
+		nil,
+	)
+	defer m.Close()
+
+	before := forwarderIDs(m)
+	require.Len(t, before, 2)
+
+	err := m.APIForwardersStop(before["rtmp://server1/live/mypath"])
+	require.NoError(t, err)
+
+	tmp, err := m.APIForwardersAdd(&defs.APIForwardReq{
+		RTMP: &conf.RTMPForwardTarget{URL: "rtmp://server4/live/$MTX_PATH"},
+	})
+	require.NoError(t, err)
+	require.True(t, tmp.Temporary)
+
+	m.ReloadConf(
+		nil,
+		nil,
+		nil,
+		[]conf.RTMPForwardTarget{
+			{URL: "rtmp://server1/live/$MTX_PATH", Enable: true},
+			{URL: "rtmp://server3/live/$MTX_PATH", Enable: true},
+		},
+	)
+
+	after := forwarderIDs(m)
+	require.Len(t, after, 3)
+
+	// unchanged forwarders are untouched
+	require.Equal(t, before["rtmp://server1/live/mypath"], after["rtmp://server1/live/mypath"])
+	item, err := m.APIForwardersGet(after["rtmp://server1/live/mypath"])
+	require.NoError(t, err)
+	require.Equal(t, defs.APIForwarderStateStopped, item.State)
+
+	// removed forwarders are removed, new ones are added
+	require.NotContains(t, after, "rtmp://server2/live/mypath")
+	require.Contains(t, after, "rtmp://server3/live/mypath")
+
+	// temporary forwarders are not affected by the configuration
+	require.Equal(t, tmp.ID, after["rtmp://server4/live/mypath"])
+}
+
+func TestManagerAddInvalid(t *testing.T) {
+	m := NewManager(context.Background(), nil, nil, nil, nil, nil,
+		test.NilLogger, 10*time.Second, 1472, 0, "mypath", nil, nil)
+	defer m.Close()
+
+	_, err := m.APIForwardersAdd(&defs.APIForwardReq{})
+	require.ErrorIs(t, err, ErrInvalidForwardTarget)
+
+	_, err = m.APIForwardersAdd(&defs.APIForwardReq{
+		RTMP: &conf.RTMPForwardTarget{URL: "http://server1/live"},
+	})
+	require.ErrorIs(t, err, ErrInvalidForwardTarget)
+}
+
+func TestManagerFailure(t *testing.T) {
+	strm := &stream.Stream{
+		WriteQueueSize:     512,
+		RTPMaxPayloadSize:  1450,
+		Desc:               &description.Session{Medias: []*description.Media{test.MediaH264}},
+		GenerateRTPPackets: true,
+		Parent:             test.NilLogger,
+	}
+	err := strm.Initialize()
+	require.NoError(t, err)
+	defer strm.Close()
+
+	type failure struct {
+		protocol string
+		target   string
+	}
+	failed := make(chan failure, 1)
+
+	m := NewManager(
+		context.Background(),
+		nil,
+		nil,
+		nil,
+		[]conf.RTMPForwardTarget{{
+			URL:              "rtmp://127.0.0.1:9/live/$MTX_PATH",
+			Enable:           true,
+			Reconnect:        true,
+			ReconnectDelay:   conf.Duration(10 * time.Millisecond),
+			MaxReconnectTime: conf.Duration(100 * time.Millisecond),
+		}},
+		nil,
+		test.NilLogger,
+		10*time.Second,
+		1472,
+		0,
+		"mypath",
+		nil,
+		func(protocol string, target string, err error) {
+			require.Error(t, err)
+			failed <- failure{protocol, target}
+		},
+	)
+	defer m.Close()
+
+	m.Start(strm)
+
+	select {
+	case f := <-failed:
+		require.Equal(t, failure{"rtmp", "rtmp://127.0.0.1:9/live/mypath"}, f)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+
+	items := m.APIForwardersList()
+	require.Equal(t, defs.APIForwarderStateFailed, items[0].State)
+	require.NotZero(t, items[0].ReconnectCount)
+
+	// a failed forwarder can be started again
+	err = m.APIForwardersStart(items[0].ID)
+	require.NoError(t, err)
+
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -0,0 +1,56 @@
+package forwarder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+)
+
+// selectMedias returns a description that contains the medias to forward.
+// medias is empty, "all", "video", "audio" or a comma-separated list of track indexes.
+func selectMedias(desc *description.Session, medias string) (*description.Session, error) {
+	var selected []*description.Media
+
+	switch medias {
+	case "", "all":
+		return desc, nil
+
+	case "video":
+		for _, media := range desc.Medias {
+			if media.Type == description.MediaTypeVideo {
+				selected = append(selected, media)
+			}
+		}
+
+	case "audio":
+		for _, media := range desc.Medias {
+			if media.Type == description.MediaTypeAudio {
+				selected = append(selected, media)
+			}
+		}
+
+	default:
+		for _, v := range strings.Split(medias, ",") {
+			i, err := strconv.ParseUint(strings.TrimSpace(v), 10, 31)
+			if err != nil {
+				return nil, fmt.Errorf("invalid track index: %s", v)
+			}
+
+			if int(i) >= len(desc.Medias) {
+				return nil, fmt.Errorf("stream doesn't contain track %d", i)
+			}
+
+			selected = append(selected, desc.Medias[i])
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("stream doesn't contain any of the selected tracks (%s)", medias)
+	}
+
+	ret := *desc
+	ret.Medias = selected
+	return &ret, nil
+}
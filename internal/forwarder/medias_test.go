@@ -0,0 +1,41 @@
+package forwarder
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestSelectMedias(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		test.UniqueMediaH264(),
+		test.UniqueMediaMPEG4Audio(),
+		test.UniqueMediaMPEG4Audio(),
+	}}
+
+	for _, ca := range []struct {
+		name   string
+		medias string
+		out    []*description.Media
+	}{
+		{"all", "", desc.Medias},
+		{"video", "video", desc.Medias[:1]},
+		{"audio", "audio", desc.Medias[1:]},
+		{"indexes", "0, 2", []*description.Media{desc.Medias[0], desc.Medias[2]}},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			out, err := selectMedias(desc, ca.medias)
+			require.NoError(t, err)
+			require.Equal(t, ca.out, out.Medias)
+		})
+	}
+
+	_, err := selectMedias(desc, "3")
+	require.EqualError(t, err, "stream doesn't contain track 3")
+
+	_, err = selectMedias(&description.Session{Medias: desc.Medias[1:]}, "video")
+	require.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package forwarder
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// default maximum duration of units that can wait in the queue.
+const defaultMaxBufferDuration = 2 * time.Second
+
+type outputQueueEntry struct {
+	o    *relayOutput
+	u    *unit.Unit
+	time time.Time
+}
+
+// outputQueue decouples the relay from the output writer.
+// When the output is late, that is the oldest unit has been waiting for more than maxBufferDuration,
+// the queue is emptied and units are discarded until the next random access unit,
+// therefore whole GOPs are dropped instead of random units.
+type outputQueue struct {
+	maxBufferDuration time.Duration
+	hasVideo          bool
+	droppedGOPs       *uint64
+	logger            logger.Writer
+	onError           func(error)
+
+	mutex        sync.Mutex
+	entries      []outputQueueEntry
+	waitKeyframe bool
+	closed       bool
+
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func (q *outputQueue) initialize() {
+	if q.maxBufferDuration == 0 {
+		q.maxBufferDuration = defaultMaxBufferDuration
+	}
+
+	q.notify = make(chan struct{}, 1)
+	q.done = make(chan struct{})
+
+	go q.run()
+}
+
+func (q *outputQueue) close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.mutex.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	<-q.done
+}
+
+// push adds a unit to the queue.
+func (q *outputQueue) push(o *relayOutput, u *unit.Unit) {
+	now := time.Now()
+
+	q.mutex.Lock()
+
+	if q.closed {
+		q.mutex.Unlock()
+		return
+	}
+
+	if len(q.entries) != 0 && now.Sub(q.entries[0].time) > q.maxBufferDuration {
+		q.entries = nil
+		q.waitKeyframe = true
+		atomic.AddUint64(q.droppedGOPs, 1)
+		q.logger.Log(logger.Warn, "output is late by more than %v, dropping until next keyframe",
+			q.maxBufferDuration)
+	}
+
+	if q.waitKeyframe {
+		if q.hasVideo && (!o.isVideo() || !isRandomAccess(u)) {
+			q.mutex.Unlock()
+			return
+		}
+		q.waitKeyframe = false
+	}
+
+	q.entries = append(q.entries, outputQueueEntry{o, u, now})
+	q.mutex.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *outputQueue) run() {
+	defer close(q.done)
+
+	for {
+		q.mutex.Lock()
+
+		if q.closed {
+			q.mutex.Unlock()
+			return
+		}
+
+		if len(q.entries) == 0 {
+			q.mutex.Unlock()
+			<-q.notify
+			continue
+		}
+
+		entry := q.entries[0]
+		q.entries = q.entries[1:]
+		q.mutex.Unlock()
+
+		err := entry.o.cb(entry.u)
+		if err != nil {
+			q.onError(err)
+
+			q.mutex.Lock()
+			q.entries = nil
+			q.closed = true
+			q.mutex.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestOutputQueueDropUntilKeyframe(t *testing.T) {
+	unblock := make(chan struct{})
+	written := make(chan int64, 16)
+
+	o := &relayOutput{
+		media:  test.MediaH264,
+		format: test.FormatH264,
+		cb: func(u *unit.Unit) error {
+			<-unblock
+			written <- u.PTS
+			return nil
+		},
+	}
+
+	var droppedGOPs uint64
+
+	q := &outputQueue{
+		maxBufferDuration: 50 * time.Millisecond,
+		hasVideo:          true,
+		droppedGOPs:       &droppedGOPs,
+		logger:            test.NilLogger,
+		onError:           func(error) {},
+	}
+	q.initialize()
+	defer q.close()
+
+	videoUnit := func(pts int64, idr bool) *unit.Unit {
+		typ := byte(1)
+		if idr {
+			typ = 5
+		}
+		return &unit.Unit{PTS: pts, Payload: unit.PayloadH264{{typ, 1}}}
+	}
+
+	// the first unit blocks the writer
+	q.push(o, videoUnit(0, true))
+	q.push(o, videoUnit(3000, false))
+	q.push(o, videoUnit(6000, false))
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the output is late: queued units are dropped, together with units until the next keyframe
+	q.push(o, videoUnit(9000, false))
+	q.push(o, videoUnit(12000, true))
+	q.push(o, videoUnit(15000, false))
+
+	require.Equal(t, uint64(1), droppedGOPs)
+
+	close(unblock)
+
+	for _, pts := range []int64{0, 12000, 15000} {
+		select {
+		case v := <-written:
+			require.Equal(t, pts, v)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+}
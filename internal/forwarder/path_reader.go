@@ -0,0 +1,125 @@
+package forwarder
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+// PathManager is the path manager used by forwarders to read other paths.
+type PathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
+// sourcePathName returns the name of the path that is forwarded in place of the path itself,
+// or an empty string.
+func sourcePathName(pathName string, sourcePath string, layer string) string {
+	if layer != "" {
+		return conf.SimulcastLayerPath(pathName, layer)
+	}
+	return strings.ReplaceAll(sourcePath, "$MTX_PATH", pathName)
+}
+
+// outputDesc returns the description of the forwarder output, that contains the tracks of the source path,
+// completed with the tracks of the forwarded path whose type is not provided by the source path.
+// When the source path is not set or not available, the description of the forwarded path is used.
+func outputDesc(
+	main *description.Session,
+	pathManager PathManager,
+	sourcePath string,
+	pathName string,
+	target string,
+	medias string,
+) (*description.Session, error) {
+	desc := main
+
+	if sourcePath != "" {
+		source, err := readPath(pathManager, sourcePath, pathName, target)
+		if err == nil {
+			desc = &description.Session{Medias: source.stream.Desc.Medias}
+			source.release()
+
+			for _, media := range main.Medias {
+				if !hasMediaType(desc, media.Type) {
+					desc.Medias = append(desc.Medias, media)
+				}
+			}
+		}
+	}
+
+	return selectMedias(desc, medias)
+}
+
+func hasMediaType(desc *description.Session, typ description.MediaType) bool {
+	for _, media := range desc.Medias {
+		if media.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// pathReader is a forwarder that reads a path other than the forwarded one.
+type pathReader struct {
+	target string
+	path   defs.Path
+	stream *stream.Stream
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// readPath starts reading a path.
+func readPath(pathManager PathManager, name string, pathName string, target string) (*pathReader, error) {
+	// reading the forwarded path would cause a deadlock when the path is closed
+	if name == pathName {
+		return nil, fmt.Errorf("path '%s' is the forwarded path", name)
+	}
+
+	r := &pathReader{
+		target: target,
+		closed: make(chan struct{}),
+	}
+
+	pa, strm, err := pathManager.AddReader(defs.PathAddReaderReq{
+		Author: r,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     name,
+			SkipAuth: true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.path = pa
+	r.stream = strm
+
+	return r, nil
+}
+
+// Close is called by the path when it is not ready anymore.
+func (r *pathReader) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+	})
+}
+
+// APIReaderDescribe implements defs.Reader.
+func (r *pathReader) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "forwarder",
+		ID:   r.target,
+	}
+}
+
+// release stops reading the path.
+func (r *pathReader) release() {
+	r.path.RemoveReader(defs.PathRemoveReaderReq{Author: r})
+}
@@ -0,0 +1,541 @@
+package forwarder
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// gap between the last unit of a source and the first unit of the next one.
+const relaySwitchGap = 33 * time.Millisecond
+
+// relayOutput is a format of the forwarder output.
+type relayOutput struct {
+	media  *description.Media
+	format format.Format
+	cb     stream.OnDataFunc
+
+	written bool
+	lastPTS int64
+	// difference between RTP timestamps and PTS of written units
+	rtpBase uint32
+}
+
+func (o *relayOutput) isVideo() bool {
+	return o.media.Type == description.MediaTypeVideo
+}
+
+// relaySource is a stream that can feed the forwarder output.
+type relaySource struct {
+	stream  *stream.Stream
+	reader  *stream.Reader
+	outputs map[format.Format]*relayOutput
+
+	detached bool
+
+	// timestamp offset, computed when the source is used for the first time
+	aligned   bool
+	rebase    bool
+	ptsOffset time.Duration
+
+	// timestamp and NTP of the last written unit, used to align other sources
+	written bool
+	refPTS  time.Duration
+	refNTP  time.Time
+}
+
+func (s *relaySource) provides(o *relayOutput) bool {
+	for _, so := range s.outputs {
+		if so == o {
+			return true
+		}
+	}
+	return false
+}
+
+// relayConf contains the options of a relay.
+type relayConf struct {
+	// maximum bitrate of the main stream
+	maxBitrate   uint
+	fallbackPath string
+
+	// path that is read in place of the main stream
+	sourcePath string
+
+	// output buffering
+	dropUntilKeyframe bool
+	maxBufferDuration time.Duration
+	droppedGOPs       *uint64
+
+	pathName    string
+	target      string
+	pathManager PathManager
+}
+
+// relay passes units of a source to the output of a forwarder.
+// The source can be switched at runtime; switches happen at random access units,
+// and timestamps are rewritten in order to be continuous across sources.
+// Outputs that are not provided by the active source are fed by the base source,
+// that is the stream of the forwarded path.
+// Video can be paused while audio keeps flowing.
+type relay struct {
+	outputs []*relayOutput
+	logger  logger.Writer
+
+	mutex        sync.Mutex
+	base         *relaySource
+	active       *relaySource
+	next         *relaySource
+	videoPaused  bool
+	waitKeyframe bool
+
+	// optional queue between the relay and the output
+	queue *outputQueue
+
+	err chan error
+}
+
+// newRelay allocates a relay. Output callbacks are taken from a reader
+// that has been set up on the output description.
+func newRelay(setup *stream.Reader, desc *description.Session, parent logger.Writer) *relay {
+	r := &relay{
+		logger: parent,
+		err:    make(chan error, 1),
+	}
+
+	for _, media := range desc.Medias {
+		for _, forma := range media.Formats {
+			cb := setup.Callback(media, forma)
+			if cb != nil {
+				r.outputs = append(r.outputs, &relayOutput{
+					media:  media,
+					format: forma,
+					cb:     cb,
+				})
+			}
+		}
+	}
+
+	return r
+}
+
+// Error returns a channel that receives errors of the output.
+func (r *relay) Error() <-chan error {
+	return r.err
+}
+
+// newSource allocates a source. Formats of the stream are associated to outputs
+// with the same media type and codec. When requireVideo is true,
+// the stream must provide all video outputs.
+func (r *relay) newSource(strm *stream.Stream, requireVideo bool) (*relaySource, error) {
+	src := &relaySource{
+		stream:  strm,
+		reader:  &stream.Reader{Parent: r.logger},
+		outputs: make(map[format.Format]*relayOutput),
+	}
+
+	for _, o := range r.outputs {
+		media, forma := matchFormat(strm.Desc, o)
+		if forma == nil {
+			if requireVideo && o.isVideo() {
+				return nil, fmt.Errorf("stream doesn't contain a %s track", o.format.Codec())
+			}
+			continue
+		}
+
+		src.outputs[forma] = o
+
+		src.reader.OnData(media, forma, func(u *unit.Unit) error {
+			return r.onUnit(src, o, u)
+		})
+	}
+
+	if len(src.outputs) == 0 {
+		return nil, fmt.Errorf("stream doesn't contain any forwarded track")
+	}
+
+	return src, nil
+}
+
+func matchFormat(desc *description.Session, o *relayOutput) (*description.Media, format.Format) {
+	for _, media := range desc.Medias {
+		for _, forma := range media.Formats {
+			// formats of the output are compared by identity first
+			if forma == o.format {
+				return media, forma
+			}
+		}
+	}
+
+	for _, media := range desc.Medias {
+		if media.Type != o.media.Type {
+			continue
+		}
+		for _, forma := range media.Formats {
+			if forma.Codec() == o.format.Codec() && forma.ClockRate() == o.format.ClockRate() {
+				return media, forma
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// start reads the main stream and, depending on the configuration,
+// starts a sourceFollower or a bitrateGuard.
+// It returns a function that stops reading.
+func (r *relay) start(main *stream.Stream, rc relayConf) (func(), error) {
+	base, err := r.newSource(main, false)
+	if err != nil {
+		if rc.sourcePath == "" {
+			return nil, err
+		}
+
+		// the output can be fed by the source path only
+		base = nil
+	}
+
+	if rc.dropUntilKeyframe {
+		r.queue = &outputQueue{
+			maxBufferDuration: rc.maxBufferDuration,
+			hasVideo:          r.hasVideo(),
+			droppedGOPs:       rc.droppedGOPs,
+			logger:            r.logger,
+			onError:           r.onError,
+		}
+		r.queue.initialize()
+	}
+
+	if base != nil {
+		r.mutex.Lock()
+		r.base = base
+		r.mutex.Unlock()
+
+		r.switchTo(base)
+		r.attach(base)
+	}
+
+	detachBase := func() {
+		if base != nil {
+			r.detach(base)
+		}
+		if r.queue != nil {
+			r.queue.close()
+		}
+	}
+
+	switch {
+	case rc.sourcePath != "":
+		follower := &sourceFollower{
+			sourcePath:  rc.sourcePath,
+			pathName:    rc.pathName,
+			target:      rc.target,
+			pathManager: rc.pathManager,
+			relay:       r,
+			base:        base,
+			logger:      r.logger,
+		}
+		follower.initialize()
+
+		return func() {
+			follower.close()
+			detachBase()
+		}, nil
+
+	case rc.maxBitrate != 0:
+		guard := &bitrateGuard{
+			maxBitrate:   rc.maxBitrate,
+			fallbackPath: rc.fallbackPath,
+			pathName:     rc.pathName,
+			target:       rc.target,
+			pathManager:  rc.pathManager,
+			relay:        r,
+			main:         base,
+			logger:       r.logger,
+		}
+		guard.initialize()
+
+		return func() {
+			guard.close()
+			detachBase()
+		}, nil
+	}
+
+	return detachBase, nil
+}
+
+// attach starts reading a source.
+func (r *relay) attach(src *relaySource) {
+	src.stream.AddReader(src.reader)
+
+	go func() {
+		err, ok := <-src.reader.Error()
+		if !ok {
+			return
+		}
+
+		r.mutex.Lock()
+		detached := src.detached
+		r.mutex.Unlock()
+
+		if !detached {
+			r.onError(err)
+		}
+	}()
+}
+
+func (r *relay) onError(err error) {
+	select {
+	case r.err <- err:
+	default:
+	}
+}
+
+// detach stops reading a source.
+func (r *relay) detach(src *relaySource) {
+	r.mutex.Lock()
+	if src.detached {
+		r.mutex.Unlock()
+		return
+	}
+	src.detached = true
+	if r.active == src {
+		r.active = nil
+	}
+	if r.next == src {
+		r.next = nil
+	}
+	r.mutex.Unlock()
+
+	// reader callbacks lock the mutex, therefore the reader must be removed without holding it
+	src.stream.RemoveReader(src.reader)
+}
+
+// switchTo sets the source that is used as soon as a random access unit is received from it.
+// When nothing has been written yet, the source is used immediately.
+// A nil source means the base source.
+func (r *relay) switchTo(src *relaySource) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if src == nil {
+		src = r.base
+	}
+
+	switch {
+	case r.active == src:
+		r.next = nil
+
+	case r.active == nil && !r.anyWritten():
+		r.active = src
+		r.next = nil
+
+	default:
+		r.next = src
+	}
+}
+
+// isActive checks whether a source is the one in use.
+func (r *relay) isActive(src *relaySource) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.active == src
+}
+
+// setVideoPaused pauses or resumes video. Video is resumed at the next random access unit.
+func (r *relay) setVideoPaused(paused bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if paused == r.videoPaused {
+		return
+	}
+
+	r.videoPaused = paused
+	r.waitKeyframe = true
+}
+
+func (r *relay) anyWritten() bool {
+	for _, o := range r.outputs {
+		if o.written {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *relay) hasVideo() bool {
+	for _, o := range r.outputs {
+		if o.isVideo() {
+			return true
+		}
+	}
+	return false
+}
+
+// align computes the timestamp offset of a source that is used for the first time.
+// When another source is being written, timestamps are aligned to it by using NTP,
+// otherwise they continue the last written ones.
+func (r *relay) align(src *relaySource, o *relayOutput, u *unit.Unit) {
+	t := timestampToDuration(u.PTS, o.format.ClockRate())
+
+	for _, ref := range []*relaySource{r.active, r.base} {
+		if ref != nil && ref != src && !ref.detached && ref.written {
+			src.rebase = true
+			src.ptsOffset = ref.refPTS + u.NTP.Sub(ref.refNTP) - t
+			return
+		}
+	}
+
+	var last time.Duration
+	found := false
+
+	for _, out := range r.outputs {
+		if out.written {
+			found = true
+			last = max(last, timestampToDuration(out.lastPTS, out.format.ClockRate()))
+		}
+	}
+
+	if !found {
+		src.rebase = false
+		return
+	}
+
+	src.rebase = true
+	src.ptsOffset = last + relaySwitchGap - t
+}
+
+// activate makes a source the active one.
+func (r *relay) activate(src *relaySource, o *relayOutput, u *unit.Unit) {
+	r.active = src
+	r.next = nil
+	r.waitKeyframe = false
+
+	if !src.aligned {
+		r.align(src, o, u)
+		src.aligned = true
+	}
+
+	// timestamps of the output must be increasing
+	if o.written {
+		pts := timestampToDuration(u.PTS, o.format.ClockRate())
+		if src.rebase {
+			pts += src.ptsOffset
+		}
+
+		minPTS := timestampToDuration(o.lastPTS, o.format.ClockRate()) + relaySwitchGap
+		if pts < minPTS {
+			src.rebase = true
+			src.ptsOffset += minPTS - pts
+		}
+	}
+}
+
+func (r *relay) onUnit(src *relaySource, o *relayOutput, u *unit.Unit) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// switch at random access units, or at any unit when there's no video
+	if src == r.next && ((o.isVideo() && isRandomAccess(u)) || !r.hasVideo()) {
+		r.activate(src, o, u)
+	}
+
+	if src != r.active {
+		// outputs that are not provided by the active source are fed by the base source
+		if src != r.base || o.isVideo() || (r.active != nil && r.active.provides(o)) {
+			return nil
+		}
+	}
+
+	if o.isVideo() {
+		if r.videoPaused {
+			return nil
+		}
+
+		if r.waitKeyframe {
+			if !isRandomAccess(u) {
+				return nil
+			}
+			r.waitKeyframe = false
+		}
+	}
+
+	if !src.aligned {
+		r.align(src, o, u)
+		src.aligned = true
+	}
+
+	if src.rebase {
+		u = rebaseUnit(u, durationToTimestamp(src.ptsOffset, o.format.ClockRate()), o)
+	}
+
+	// audio timestamps must be monotonic after a switch
+	if !o.isVideo() && o.written && u.PTS <= o.lastPTS {
+		return nil
+	}
+
+	if !o.written {
+		o.written = true
+		if len(u.RTPPackets) != 0 {
+			o.rtpBase = u.RTPPackets[0].Timestamp - uint32(u.PTS)
+		}
+	}
+	o.lastPTS = u.PTS
+
+	src.written = true
+	src.refPTS = timestampToDuration(u.PTS, o.format.ClockRate())
+	src.refNTP = u.NTP
+
+	if r.queue != nil {
+		r.queue.push(o, u)
+		return nil
+	}
+
+	return o.cb(u)
+}
+
+// rebaseUnit returns a copy of the unit with timestamps shifted by an offset.
+// RTP timestamps are aligned to the ones previously written into the output.
+func rebaseUnit(u *unit.Unit, offset int64, o *relayOutput) *unit.Unit {
+	ret := &unit.Unit{
+		PTS:     u.PTS + offset,
+		NTP:     u.NTP,
+		Payload: u.Payload,
+	}
+
+	if len(u.RTPPackets) != 0 {
+		first := u.RTPPackets[0].Timestamp
+		ret.RTPPackets = make([]*rtp.Packet, len(u.RTPPackets))
+
+		for i, pkt := range u.RTPPackets {
+			clone := &rtp.Packet{
+				Header:  pkt.Header,
+				Payload: pkt.Payload,
+			}
+			clone.Timestamp = pkt.Timestamp - first + uint32(ret.PTS) + o.rtpBase
+			ret.RTPPackets[i] = clone
+		}
+	}
+
+	return ret
+}
+
+func timestampToDuration(t int64, clockRate int) time.Duration {
+	// avoid overflows by splitting integer and fractional parts.
+	cr := int64(clockRate)
+	return time.Duration(t/cr)*time.Second + time.Duration(t%cr)*time.Second/time.Duration(cr)
+}
+
+func durationToTimestamp(d time.Duration, clockRate int) int64 {
+	return int64(math.Round(d.Seconds() * float64(clockRate)))
+}
@@ -0,0 +1,236 @@
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type relayTestUnit struct {
+	video bool
+	u     *unit.Unit
+}
+
+func newRelayTestStream(t *testing.T) *stream.Stream {
+	strm := &stream.Stream{
+		WriteQueueSize:     512,
+		RTPMaxPayloadSize:  1450,
+		Desc:               &description.Session{Medias: []*description.Media{test.MediaH264, test.MediaMPEG4Audio}},
+		GenerateRTPPackets: true,
+		Parent:             test.NilLogger,
+	}
+	err := strm.Initialize()
+	require.NoError(t, err)
+	return strm
+}
+
+func newRelayTest(desc *description.Session) (*relay, chan relayTestUnit) {
+	out := make(chan relayTestUnit, 16)
+
+	setup := &stream.Reader{}
+	setup.OnData(test.MediaH264, test.FormatH264, func(u *unit.Unit) error {
+		out <- relayTestUnit{true, u}
+		return nil
+	})
+	setup.OnData(test.MediaMPEG4Audio, test.FormatMPEG4Audio, func(u *unit.Unit) error {
+		out <- relayTestUnit{false, u}
+		return nil
+	})
+
+	return newRelay(setup, desc, test.NilLogger), out
+}
+
+func writeVideo(strm *stream.Stream, pts int64, idr bool) {
+	typ := byte(1)
+	if idr {
+		typ = 5
+	}
+	strm.WriteUnit(strm.Desc.Medias[0], test.FormatH264, &unit.Unit{
+		PTS:     pts,
+		Payload: unit.PayloadH264{{typ, 1}},
+	})
+}
+
+func writeAudio(strm *stream.Stream, pts int64) {
+	strm.WriteUnit(test.MediaMPEG4Audio, test.FormatMPEG4Audio, &unit.Unit{
+		PTS:     pts,
+		Payload: unit.PayloadMPEG4Audio{{1, 2, 3, 4}},
+	})
+}
+
+func readRelayUnit(t *testing.T, out chan relayTestUnit) relayTestUnit {
+	select {
+	case ru := <-out:
+		return ru
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out")
+	}
+	return relayTestUnit{}
+}
+
+func TestRelaySwitch(t *testing.T) {
+	main := newRelayTestStream(t)
+	defer main.Close()
+
+	fallback := newRelayTestStream(t)
+	defer fallback.Close()
+
+	rl, out := newRelayTest(main.Desc)
+
+	stop, err := rl.start(main, relayConf{})
+	require.NoError(t, err)
+	defer stop()
+
+	writeVideo(main, 90000, true)
+	ru := readRelayUnit(t, out)
+	require.Equal(t, int64(90000), ru.u.PTS)
+
+	src, err := rl.newSource(fallback, true)
+	require.NoError(t, err)
+	rl.attach(src)
+	defer rl.detach(src)
+	rl.switchTo(src)
+
+	// units of the new source are discarded until a random access unit
+	writeVideo(fallback, 500000, false)
+	writeVideo(main, 93000, false)
+	ru = readRelayUnit(t, out)
+	require.Equal(t, int64(93000), ru.u.PTS)
+
+	writeVideo(fallback, 503000, true)
+	ru = readRelayUnit(t, out)
+	require.True(t, ru.video)
+	// timestamps are continuous
+	require.Equal(t, int64(93000+2970), ru.u.PTS)
+	require.Equal(t, ru.u.RTPPackets[0].Timestamp-uint32(ru.u.PTS),
+		ru.u.RTPPackets[len(ru.u.RTPPackets)-1].Timestamp-uint32(ru.u.PTS))
+	require.True(t, rl.isActive(src))
+
+	// units of the previous source are discarded
+	writeVideo(main, 96000, true)
+	writeVideo(fallback, 506000, false)
+	ru = readRelayUnit(t, out)
+	require.Equal(t, int64(93000+2970+3000), ru.u.PTS)
+}
+
+func TestRelayVideoPaused(t *testing.T) {
+	main := newRelayTestStream(t)
+	defer main.Close()
+
+	rl, out := newRelayTest(main.Desc)
+
+	stop, err := rl.start(main, relayConf{})
+	require.NoError(t, err)
+	defer stop()
+
+	rl.setVideoPaused(true)
+
+	writeVideo(main, 90000, true)
+	writeAudio(main, 44100)
+	ru := readRelayUnit(t, out)
+	require.False(t, ru.video)
+
+	rl.setVideoPaused(false)
+
+	// video is resumed at a random access unit
+	writeVideo(main, 93000, false)
+	writeVideo(main, 96000, true)
+	ru = readRelayUnit(t, out)
+	require.True(t, ru.video)
+	require.Equal(t, int64(96000), ru.u.PTS)
+}
+
+type dummyPath struct{}
+
+func (dummyPath) Name() string                                  { return "" }
+func (dummyPath) SafeConf() *conf.Path                          { return &conf.Path{} }
+func (dummyPath) ExternalCmdEnv() externalcmd.Environment       { return nil }
+func (dummyPath) RemovePublisher(_ defs.PathRemovePublisherReq) {}
+func (dummyPath) RemoveReader(_ defs.PathRemoveReaderReq)       {}
+
+func TestRelaySourcePath(t *testing.T) {
+	main := newRelayTestStream(t)
+	defer main.Close()
+
+	layer := &stream.Stream{
+		WriteQueueSize:     512,
+		RTPMaxPayloadSize:  1450,
+		Desc:               &description.Session{Medias: []*description.Media{test.UniqueMediaH264()}},
+		GenerateRTPPackets: true,
+		Parent:             test.NilLogger,
+	}
+	err := layer.Initialize()
+	require.NoError(t, err)
+	defer layer.Close()
+
+	authors := make(chan defs.Reader, 1)
+
+	pm := &test.PathManager{
+		AddReaderImpl: func(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+			require.Equal(t, "mypath~low", req.AccessRequest.Name)
+			authors <- req.Author
+			return dummyPath{}, layer, nil
+		},
+	}
+
+	rl, out := newRelayTest(main.Desc)
+
+	stop, err := rl.start(main, relayConf{
+		sourcePath:  "mypath~low",
+		pathName:    "mypath",
+		target:      "target",
+		pathManager: pm,
+	})
+	require.NoError(t, err)
+	defer stop()
+
+	author := <-authors
+
+	require.Eventually(t, func() bool {
+		rl.mutex.Lock()
+		defer rl.mutex.Unlock()
+		return rl.next != nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// the forwarded path is used until a random access unit of the source path is received
+	writeVideo(main, 90000, true)
+	ru := readRelayUnit(t, out)
+	require.True(t, ru.video)
+	require.Equal(t, int64(90000), ru.u.PTS)
+
+	writeVideo(layer, 180000, true)
+	ru = readRelayUnit(t, out)
+	require.True(t, ru.video)
+	require.Equal(t, int64(90000+2970), ru.u.PTS)
+
+	// audio, that is not provided by the source path, is taken from the forwarded path
+	writeVideo(main, 93000, false)
+	writeAudio(main, 44100)
+	ru = readRelayUnit(t, out)
+	require.False(t, ru.video)
+	require.Equal(t, int64(44100), ru.u.PTS)
+
+	// when the source path is not available anymore, the forwarded path is used again
+	author.Close()
+
+	require.Eventually(t, func() bool {
+		rl.mutex.Lock()
+		defer rl.mutex.Unlock()
+		return rl.active == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	writeVideo(main, 96000, false)
+	writeVideo(main, 99000, true)
+	ru = readRelayUnit(t, out)
+	require.True(t, ru.video)
+	require.Equal(t, int64(99000), ru.u.PTS)
+}
@@ -0,0 +1,59 @@
+package forwarder
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// maximum delay between two connection attempts
+	maxReconnectDelay = 60 * time.Second
+
+	// fraction of the delay that is randomized,
+	// in order to prevent forwarders from reconnecting at the same time
+	reconnectJitter = 0.2
+)
+
+// retryPolicy computes the delay between connection attempts.
+// The delay starts from ReconnectDelay and is doubled after every failed attempt,
+// up to a cap. When MaxReconnectTime is not zero, the forwarder gives up
+// after failing for more than MaxReconnectTime.
+type retryPolicy struct {
+	reconnectDelay   time.Duration
+	maxReconnectTime time.Duration
+
+	attempts     int
+	failingSince time.Time
+}
+
+// reset is called when a connection is established.
+func (p *retryPolicy) reset() {
+	p.attempts = 0
+	p.failingSince = time.Time{}
+}
+
+// next returns the delay before the next connection attempt.
+// It returns false when the forwarder must give up.
+func (p *retryPolicy) next(now time.Time) (time.Duration, bool) {
+	if p.failingSince.IsZero() {
+		p.failingSince = now
+	}
+
+	if p.maxReconnectTime > 0 && now.Sub(p.failingSince) >= p.maxReconnectTime {
+		return 0, false
+	}
+
+	delay := p.reconnectDelay
+	for range p.attempts {
+		if delay >= maxReconnectDelay {
+			break
+		}
+		delay *= 2
+	}
+	delay = max(min(delay, maxReconnectDelay), p.reconnectDelay)
+
+	p.attempts++
+
+	jitter := time.Duration((rand.Float64()*2 - 1) * reconnectJitter * float64(delay)) //nolint:gosec
+	return delay + jitter, true
+}
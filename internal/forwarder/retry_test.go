@@ -0,0 +1,44 @@
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	p := &retryPolicy{
+		reconnectDelay:   time.Second,
+		maxReconnectTime: 10 * time.Minute,
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, expected := range []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		32 * time.Second,
+		60 * time.Second,
+		60 * time.Second,
+	} {
+		delay, ok := p.next(now)
+		require.True(t, ok)
+		require.InDelta(t, float64(expected), float64(delay), reconnectJitter*float64(expected))
+		now = now.Add(delay)
+	}
+
+	// connection established
+	p.reset()
+
+	delay, ok := p.next(now)
+	require.True(t, ok)
+	require.InDelta(t, float64(time.Second), float64(delay), reconnectJitter*float64(time.Second))
+
+	// give up after maxReconnectTime
+	_, ok = p.next(now.Add(10 * time.Minute))
+	require.False(t, ok)
+}
@@ -0,0 +1,321 @@
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gortmplib"
+	"github.com/bluenviron/gortmplib/pkg/message"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/rtmp"
+	"github.com/bluenviron/mediamtx/internal/protocols/tls"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+// rtmpCountingConn counts the messages written to a RTMP connection.
+type rtmpCountingConn struct {
+	gortmplib.Conn
+	messagesSent *uint64
+}
+
+// Write implements gortmplib.Conn.
+func (c *rtmpCountingConn) Write(msg message.Message) error {
+	err := c.Conn.Write(msg)
+	if err == nil {
+		atomic.AddUint64(c.messagesSent, 1)
+	}
+	return err
+}
+
+// ensure rtmpForwarder implements Forwarder interface
+var _ Forwarder = (*rtmpForwarder)(nil)
+
+// rtmpForwarder is a RTMP forwarder implementation.
+// It publishes the stream to a remote server, muxing it with rtmp.FromStream.
+type rtmpForwarder struct {
+	url          string
+	config       *conf.RTMPForwardTarget
+	stream       *stream.Stream
+	reader       *stream.Reader
+	conn         *gortmplib.Client
+	logger       logger.Writer
+	ctx          context.Context
+	ctxCancel    context.CancelFunc
+	wg           sync.WaitGroup
+	mutex        sync.RWMutex
+	writeTimeout time.Duration
+
+	// statistics
+	bytesSent      uint64
+	packetsSent    uint64
+	packetsLost    uint64
+	lastError      error
+	connected      bool
+	connectedTime  time.Time
+	reconnectCount uint64
+	failed         bool
+
+	onFailure func(error)
+}
+
+// newRTMPForwarder creates a new RTMP forwarder.
+func newRTMPForwarder(
+	url string,
+	config *conf.RTMPForwardTarget,
+	parent logger.Writer,
+	writeTimeout time.Duration,
+	onFailure func(error),
+) Forwarder {
+	return &rtmpForwarder{
+		url:          url,
+		config:       config,
+		logger:       parent,
+		writeTimeout: writeTimeout,
+		onFailure:    onFailure,
+	}
+}
+
+// Log implements logger.Writer.
+func (f *rtmpForwarder) Log(level logger.Level, format string, args ...any) {
+	f.logger.Log(level, "[RTMP forwarder %s] "+format, append([]any{f.url}, args...)...)
+}
+
+// GetTarget implements Forwarder.
+func (f *rtmpForwarder) GetTarget() string {
+	return f.url
+}
+
+// Start implements Forwarder.
+func (f *rtmpForwarder) Start(strm *stream.Stream) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.stream != nil {
+		return fmt.Errorf("forwarder already started")
+	}
+
+	f.ctx, f.ctxCancel = context.WithCancel(context.Background())
+	f.stream = strm
+	f.failed = false
+	f.wg.Add(1)
+	go f.run()
+
+	return nil
+}
+
+// Stop implements Forwarder.
+func (f *rtmpForwarder) Stop() {
+	f.mutex.RLock()
+	started := f.stream != nil
+	f.mutex.RUnlock()
+
+	if !started {
+		return
+	}
+
+	f.ctxCancel()
+	f.wg.Wait()
+
+	f.mutex.Lock()
+	// reader and connection are released by runInner()
+	f.stream = nil
+	f.reader = nil
+	f.conn = nil
+	f.mutex.Unlock()
+}
+
+// IsRunning implements Forwarder.
+func (f *rtmpForwarder) IsRunning() bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.stream != nil && !f.failed
+}
+
+// GetStats implements Forwarder.
+func (f *rtmpForwarder) GetStats() Stats {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	stats := Stats{
+		BytesSent:      atomic.LoadUint64(&f.bytesSent),
+		PacketsSent:    atomic.LoadUint64(&f.packetsSent),
+		PacketsLost:    atomic.LoadUint64(&f.packetsLost),
+		LastError:      f.lastError,
+		Connected:      f.connected,
+		ReconnectCount: atomic.LoadUint64(&f.reconnectCount),
+		Failed:         f.failed,
+	}
+
+	if f.connected {
+		stats.Uptime = time.Since(f.connectedTime)
+	}
+
+	if f.conn != nil {
+		stats.BytesSent += f.conn.BytesSent()
+	}
+
+	return stats
+}
+
+func (f *rtmpForwarder) run() {
+	defer f.wg.Done()
+
+	retry := &retryPolicy{
+		reconnectDelay:   time.Duration(f.config.ReconnectDelay),
+		maxReconnectTime: time.Duration(f.config.MaxReconnectTime),
+	}
+
+	for {
+		connected, err := f.runInner()
+		if connected {
+			retry.reset()
+		}
+
+		f.mutex.Lock()
+		if err != nil {
+			f.lastError = err
+			f.connected = false
+		}
+		f.mutex.Unlock()
+
+		if err != nil {
+			f.Log(logger.Warn, "error: %v", err)
+		}
+
+		select {
+		case <-f.ctx.Done():
+			return
+		default:
+		}
+
+		if !f.config.Reconnect {
+			f.fail(err)
+			return
+		}
+
+		delay, ok := retry.next(time.Now())
+		if !ok {
+			f.fail(err)
+			return
+		}
+
+		f.Log(logger.Info, "reconnecting in %v...", delay.Round(time.Millisecond))
+
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-time.After(delay):
+			atomic.AddUint64(&f.reconnectCount, 1)
+		}
+	}
+}
+
+// fail puts the forwarder in the failed state.
+func (f *rtmpForwarder) fail(err error) {
+	f.mutex.Lock()
+	f.failed = true
+	f.mutex.Unlock()
+
+	f.Log(logger.Error, "failed, giving up")
+
+	if f.onFailure != nil {
+		f.onFailure(err)
+	}
+}
+
+// runInner runs a session. It returns whether the session was established.
+func (f *rtmpForwarder) runInner() (bool, error) {
+	u, err := url.Parse(f.url)
+	if err != nil {
+		return false, fmt.Errorf("invalid RTMP URL: %w", err)
+	}
+
+	// add default port
+	_, _, err = net.SplitHostPort(u.Host)
+	if err != nil {
+		if u.Scheme == "rtmp" {
+			u.Host = net.JoinHostPort(u.Host, "1935")
+		} else {
+			u.Host = net.JoinHostPort(u.Host, "1936")
+		}
+	}
+
+	f.Log(logger.Debug, "connecting")
+
+	connectCtx, connectCtxCancel := context.WithTimeout(f.ctx, f.writeTimeout)
+	conn := &gortmplib.Client{
+		URL:       u,
+		TLSConfig: tls.MakeConfig(u.Hostname(), f.config.Fingerprint),
+		Publish:   true,
+	}
+	err = conn.Initialize(connectCtx)
+	connectCtxCancel()
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	f.mutex.Lock()
+	f.conn = conn
+	f.connected = true
+	f.connectedTime = time.Now()
+	f.mutex.Unlock()
+
+	defer func() {
+		conn.Close()
+
+		f.mutex.Lock()
+		// keep counters across reconnections
+		atomic.AddUint64(&f.bytesSent, conn.BytesSent())
+		f.conn = nil
+		f.connected = false
+		f.mutex.Unlock()
+	}()
+
+	f.reader = &stream.Reader{Parent: f}
+
+	err = rtmp.FromStream(
+		f.stream.Desc,
+		f.reader,
+		&rtmpCountingConn{Conn: conn, messagesSent: &f.packetsSent},
+		conn.NetConn(),
+		f.writeTimeout)
+	if err != nil {
+		return false, err
+	}
+
+	f.Log(logger.Info, "connected, %s", defs.FormatsInfo(f.reader.Formats()))
+
+	// read and discard server messages in order to detect disconnections
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			_, err2 := conn.Read()
+			if err2 != nil {
+				readErr <- err2
+				return
+			}
+		}
+	}()
+
+	f.stream.AddReader(f.reader)
+	defer f.stream.RemoveReader(f.reader)
+
+	select {
+	case err = <-f.reader.Error():
+		return true, err
+
+	case err = <-readErr:
+		return true, err
+
+	case <-f.ctx.Done():
+		return true, nil
+	}
+}
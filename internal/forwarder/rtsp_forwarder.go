@@ -0,0 +1,326 @@
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/tls"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// ensure rtspForwarder implements Forwarder interface
+var _ Forwarder = (*rtspForwarder)(nil)
+
+// rtspForwarder is a RTSP forwarder implementation.
+// It publishes the stream to a remote server with ANNOUNCE / RECORD.
+type rtspForwarder struct {
+	url               string
+	config            *conf.RTSPForwardTarget
+	stream            *stream.Stream
+	reader            *stream.Reader
+	client            *gortsplib.Client
+	logger            logger.Writer
+	ctx               context.Context
+	ctxCancel         context.CancelFunc
+	wg                sync.WaitGroup
+	mutex             sync.RWMutex
+	writeTimeout      time.Duration
+	udpReadBufferSize uint
+
+	// statistics
+	bytesSent      uint64
+	packetsSent    uint64
+	packetsLost    uint64
+	lastError      error
+	connected      bool
+	connectedTime  time.Time
+	reconnectCount uint64
+	failed         bool
+
+	onFailure func(error)
+}
+
+// newRTSPForwarder creates a new RTSP forwarder.
+func newRTSPForwarder(
+	url string,
+	config *conf.RTSPForwardTarget,
+	parent logger.Writer,
+	writeTimeout time.Duration,
+	udpReadBufferSize uint,
+	onFailure func(error),
+) Forwarder {
+	return &rtspForwarder{
+		url:               url,
+		config:            config,
+		logger:            parent,
+		writeTimeout:      writeTimeout,
+		udpReadBufferSize: udpReadBufferSize,
+		onFailure:         onFailure,
+	}
+}
+
+// Log implements logger.Writer.
+func (f *rtspForwarder) Log(level logger.Level, format string, args ...any) {
+	f.logger.Log(level, "[RTSP forwarder %s] "+format, append([]any{f.url}, args...)...)
+}
+
+// GetTarget implements Forwarder.
+func (f *rtspForwarder) GetTarget() string {
+	return f.url
+}
+
+// Start implements Forwarder.
+func (f *rtspForwarder) Start(strm *stream.Stream) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.stream != nil {
+		return fmt.Errorf("forwarder already started")
+	}
+
+	f.ctx, f.ctxCancel = context.WithCancel(context.Background())
+	f.stream = strm
+	f.failed = false
+	f.wg.Add(1)
+	go f.run()
+
+	return nil
+}
+
+// Stop implements Forwarder.
+func (f *rtspForwarder) Stop() {
+	f.mutex.RLock()
+	started := f.stream != nil
+	f.mutex.RUnlock()
+
+	if !started {
+		return
+	}
+
+	f.ctxCancel()
+	f.wg.Wait()
+
+	f.mutex.Lock()
+	// reader and client are released by runInner()
+	f.stream = nil
+	f.reader = nil
+	f.client = nil
+	f.mutex.Unlock()
+}
+
+// IsRunning implements Forwarder.
+func (f *rtspForwarder) IsRunning() bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.stream != nil && !f.failed
+}
+
+// GetStats implements Forwarder.
+func (f *rtspForwarder) GetStats() Stats {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	stats := Stats{
+		BytesSent:      atomic.LoadUint64(&f.bytesSent),
+		PacketsSent:    atomic.LoadUint64(&f.packetsSent),
+		PacketsLost:    atomic.LoadUint64(&f.packetsLost),
+		LastError:      f.lastError,
+		Connected:      f.connected,
+		ReconnectCount: atomic.LoadUint64(&f.reconnectCount),
+		Failed:         f.failed,
+	}
+
+	if f.connected {
+		stats.Uptime = time.Since(f.connectedTime)
+	}
+
+	if f.client != nil {
+		cs := f.client.Stats()
+		stats.BytesSent += cs.Session.BytesSent
+		stats.PacketsSent += cs.Session.RTPPacketsSent
+	}
+
+	return stats
+}
+
+func (f *rtspForwarder) run() {
+	defer f.wg.Done()
+
+	retry := &retryPolicy{
+		reconnectDelay:   time.Duration(f.config.ReconnectDelay),
+		maxReconnectTime: time.Duration(f.config.MaxReconnectTime),
+	}
+
+	for {
+		connected, err := f.runInner()
+		if connected {
+			retry.reset()
+		}
+
+		f.mutex.Lock()
+		if err != nil {
+			f.lastError = err
+			f.connected = false
+		}
+		f.mutex.Unlock()
+
+		if err != nil {
+			f.Log(logger.Warn, "error: %v", err)
+		}
+
+		select {
+		case <-f.ctx.Done():
+			return
+		default:
+		}
+
+		if !f.config.Reconnect {
+			f.fail(err)
+			return
+		}
+
+		delay, ok := retry.next(time.Now())
+		if !ok {
+			f.fail(err)
+			return
+		}
+
+		f.Log(logger.Info, "reconnecting in %v...", delay.Round(time.Millisecond))
+
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-time.After(delay):
+			atomic.AddUint64(&f.reconnectCount, 1)
+		}
+	}
+}
+
+// fail puts the forwarder in the failed state.
+func (f *rtspForwarder) fail(err error) {
+	f.mutex.Lock()
+	f.failed = true
+	f.mutex.Unlock()
+
+	f.Log(logger.Error, "failed, giving up")
+
+	if f.onFailure != nil {
+		f.onFailure(err)
+	}
+}
+
+// runInner runs a session. It returns whether the session was established.
+func (f *rtspForwarder) runInner() (bool, error) {
+	u, err := base.ParseURL(f.url)
+	if err != nil {
+		return false, fmt.Errorf("invalid RTSP URL: %w", err)
+	}
+
+	f.Log(logger.Debug, "connecting")
+
+	// ANNOUNCE fills Control, Profile and KeyMgmtMikey of medias,
+	// therefore send copies in order not to touch the path description.
+	desc := &description.Session{}
+	mediaMap := make(map[*description.Media]*description.Media)
+
+	for _, medi := range f.stream.Desc.Medias {
+		cmedi := &description.Media{
+			Type:    medi.Type,
+			ID:      medi.ID,
+			Formats: medi.Formats,
+		}
+		desc.Medias = append(desc.Medias, cmedi)
+		mediaMap[medi] = cmedi
+	}
+
+	c := &gortsplib.Client{
+		Protocol:          f.config.Transport.Protocol,
+		TLSConfig:         tls.MakeConfig(u.Hostname(), f.config.Fingerprint),
+		ReadTimeout:       f.writeTimeout,
+		WriteTimeout:      f.writeTimeout,
+		UDPReadBufferSize: int(f.udpReadBufferSize),
+		OnRequest: func(req *base.Request) {
+			f.Log(logger.Debug, "[c->s] %v", req)
+		},
+		OnResponse: func(res *base.Response) {
+			f.Log(logger.Debug, "[s->c] %v", res)
+		},
+		OnTransportSwitch: func(err error) {
+			f.Log(logger.Warn, err.Error())
+		},
+	}
+
+	err = c.StartRecording(u.String(), desc)
+	if err != nil {
+		return false, fmt.Errorf("failed to start recording: %w", err)
+	}
+
+	f.mutex.Lock()
+	f.client = c
+	f.connected = true
+	f.connectedTime = time.Now()
+	f.mutex.Unlock()
+
+	defer func() {
+		c.Close()
+
+		f.mutex.Lock()
+		// keep counters across reconnections
+		cs := c.Stats()
+		atomic.AddUint64(&f.bytesSent, cs.Session.BytesSent)
+		atomic.AddUint64(&f.packetsSent, cs.Session.RTPPacketsSent)
+		f.client = nil
+		f.connected = false
+		f.mutex.Unlock()
+	}()
+
+	f.reader = &stream.Reader{Parent: f}
+
+	for medi, cmedi := range mediaMap {
+		for _, forma := range medi.Formats {
+			f.reader.OnData(medi, forma, func(u *unit.Unit) error {
+				for _, pkt := range u.RTPPackets {
+					// the client overrides the SSRC, do not touch the shared packet
+					pkt2 := *pkt
+
+					err2 := c.WritePacketRTPWithNTP(cmedi, &pkt2, u.NTP)
+					if err2 != nil {
+						return err2
+					}
+				}
+				return nil
+			})
+		}
+	}
+
+	f.stream.AddReader(f.reader)
+	defer f.stream.RemoveReader(f.reader)
+
+	f.Log(logger.Info, "connected")
+
+	clientErr := make(chan error, 1)
+	go func() {
+		clientErr <- c.Wait()
+	}()
+
+	select {
+	case err = <-f.reader.Error():
+		return true, err
+
+	case err = <-clientErr:
+		return true, err
+
+	case <-f.ctx.Done():
+		return true, nil
+	}
+}
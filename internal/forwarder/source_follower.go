@@ -0,0 +1,95 @@
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+// pause between attempts to read the source path
+const sourceFollowerRetryPause = 2 * time.Second
+
+// sourceFollower feeds the forwarder with a path other than the forwarded one,
+// like a simulcast layer or the output of a transcoder.
+// When the source path is not available, the forwarder falls back to the forwarded path,
+// and switches back to the source path as soon as it is available again.
+type sourceFollower struct {
+	sourcePath  string
+	pathName    string
+	target      string
+	pathManager PathManager
+	relay       *relay
+	base        *relaySource
+	logger      logger.Writer
+
+	ctx       context.Context
+	ctxCancel func()
+
+	done chan struct{}
+}
+
+func (f *sourceFollower) initialize() {
+	f.ctx, f.ctxCancel = context.WithCancel(context.Background())
+	f.done = make(chan struct{})
+
+	go f.run()
+}
+
+func (f *sourceFollower) close() {
+	f.ctxCancel()
+	<-f.done
+}
+
+func (f *sourceFollower) run() {
+	defer close(f.done)
+
+	for {
+		err := f.runInner()
+
+		if f.ctx.Err() != nil {
+			return
+		}
+
+		f.logger.Log(logger.Warn, "source path '%s': %v", f.sourcePath, err)
+
+		select {
+		case <-time.After(sourceFollowerRetryPause):
+		case <-f.ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *sourceFollower) runInner() error {
+	source, err := readPath(f.pathManager, f.sourcePath, f.pathName, f.target)
+	if err != nil {
+		return err
+	}
+
+	defer source.release()
+
+	src, err := f.relay.newSource(source.stream, true)
+	if err != nil {
+		return err
+	}
+
+	f.relay.attach(src)
+	f.relay.switchTo(src)
+
+	defer func() {
+		f.relay.switchTo(f.base)
+		f.relay.detach(src)
+	}()
+
+	f.logger.Log(logger.Info, "forwarding source path '%s'", f.sourcePath)
+
+	select {
+	case <-source.closed:
+		return fmt.Errorf("path is not available anymore")
+
+	case <-f.ctx.Done():
+		return nil
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,20 +17,29 @@ import (
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
+const (
+	srtModeListener = "listener"
+
+	// pause between two attempts to connect to a redundant destination
+	srtRedialPause = 2 * time.Second
+)
+
 // srtForwarder is a SRT forwarder implementation.
 type srtForwarder struct {
-	url              string
-	config           *conf.SRTForwardTarget
-	stream           *stream.Stream
-	reader           *stream.Reader
-	sconn            srt.Conn
-	logger           logger.Writer
-	ctx              context.Context
-	ctxCancel        context.CancelFunc
-	wg               sync.WaitGroup
-	mutex            sync.RWMutex
-	writeTimeout     time.Duration
+	url               string
+	config            *conf.SRTForwardTarget
+	stream            *stream.Stream
+	reader            *stream.Reader
+	output            *srtOutput
+	logger            logger.Writer
+	ctx               context.Context
+	ctxCancel         context.CancelFunc
+	wg                sync.WaitGroup
+	mutex             sync.RWMutex
+	writeTimeout      time.Duration
 	udpMaxPayloadSize int
+	pathName          string
+	pathManager       PathManager
 
 	// statistics
 	bytesSent      uint64
@@ -37,7 +47,12 @@ type srtForwarder struct {
 	packetsLost    uint64
 	lastError      error
 	connected      bool
+	connectedTime  time.Time
 	reconnectCount uint64
+	droppedGOPs    uint64
+	failed         bool
+
+	onFailure func(error)
 }
 
 // newSRTForwarder creates a new SRT forwarder.
@@ -47,20 +62,27 @@ func newSRTForwarder(
 	parent logger.Writer,
 	writeTimeout time.Duration,
 	udpMaxPayloadSize int,
+	pathName string,
+	pathManager PathManager,
+	onFailure func(error),
 ) Forwarder {
-	ctx, ctxCancel := context.WithCancel(context.Background())
-
 	return &srtForwarder{
-		url:              url,
-		config:           config,
-		logger:           parent,
-		ctx:              ctx,
-		ctxCancel:        ctxCancel,
-		writeTimeout:     writeTimeout,
+		url:               url,
+		config:            config,
+		logger:            parent,
+		writeTimeout:      writeTimeout,
 		udpMaxPayloadSize: udpMaxPayloadSize,
+		pathName:          pathName,
+		pathManager:       pathManager,
+		onFailure:         onFailure,
 	}
 }
 
+// Log implements logger.Writer.
+func (f *srtForwarder) Log(level logger.Level, format string, args ...any) {
+	f.logger.Log(level, "[SRT forwarder %s] "+format, append([]any{f.url}, args...)...)
+}
+
 // Start starts the forwarder.
 func (f *srtForwarder) Start(strm *stream.Stream) error {
 	f.mutex.Lock()
@@ -70,7 +92,9 @@ func (f *srtForwarder) Start(strm *stream.Stream) error {
 		return fmt.Errorf("forwarder already started")
 	}
 
+	f.ctx, f.ctxCancel = context.WithCancel(context.Background())
 	f.stream = strm
+	f.failed = false
 	f.wg.Add(1)
 	go f.run()
 
@@ -79,18 +103,22 @@ func (f *srtForwarder) Start(strm *stream.Stream) error {
 
 // Stop stops the forwarder.
 func (f *srtForwarder) Stop() {
+	f.mutex.RLock()
+	started := f.stream != nil
+	f.mutex.RUnlock()
+
+	if !started {
+		return
+	}
+
 	f.ctxCancel()
 	f.wg.Wait()
 
 	f.mutex.Lock()
-	// Note: reader is removed by defer in runInner(), so we don't remove it here
+	// Note: reader and connections are closed by defer in runInner(), so we don't close them here
 	// to avoid "close of closed channel" panic
-	if f.sconn != nil {
-		f.sconn.Close()
-	}
 	f.stream = nil
 	f.reader = nil
-	f.sconn = nil
 	f.mutex.Unlock()
 }
 
@@ -98,7 +126,7 @@ func (f *srtForwarder) Stop() {
 func (f *srtForwarder) IsRunning() bool {
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
-	return f.stream != nil
+	return f.stream != nil && !f.failed
 }
 
 // GetStats returns statistics.
@@ -106,19 +134,29 @@ func (f *srtForwarder) GetStats() Stats {
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
 
-	var lastErr error
-	if f.lastError != nil {
-		lastErr = f.lastError
+	stats := Stats{
+		BytesSent:      atomic.LoadUint64(&f.bytesSent),
+		PacketsSent:    atomic.LoadUint64(&f.packetsSent),
+		PacketsLost:    atomic.LoadUint64(&f.packetsLost),
+		LastError:      f.lastError,
+		Connected:      f.connected,
+		ReconnectCount: atomic.LoadUint64(&f.reconnectCount),
+		DroppedGOPs:    atomic.LoadUint64(&f.droppedGOPs),
+		Failed:         f.failed,
 	}
 
-	return Stats{
-		BytesSent:     atomic.LoadUint64(&f.bytesSent),
-		PacketsSent:   atomic.LoadUint64(&f.packetsSent),
-		PacketsLost:   atomic.LoadUint64(&f.packetsLost),
-		LastError:     lastErr,
-		Connected:     f.connected,
-		ReconnectCount: atomic.LoadUint64(&f.reconnectCount),
+	if f.connected {
+		stats.Uptime = time.Since(f.connectedTime)
+	}
+
+	if f.output != nil {
+		s := f.output.stats()
+		stats.BytesSent += s.Accumulated.ByteSent
+		stats.PacketsSent += s.Accumulated.PktSent
+		stats.PacketsLost += s.Accumulated.PktSendLoss
 	}
+
+	return stats
 }
 
 // GetTarget returns the target URL.
@@ -129,47 +167,80 @@ func (f *srtForwarder) GetTarget() string {
 func (f *srtForwarder) run() {
 	defer f.wg.Done()
 
+	retry := &retryPolicy{
+		reconnectDelay:   time.Duration(f.config.ReconnectDelay),
+		maxReconnectTime: time.Duration(f.config.MaxReconnectTime),
+	}
+
 	for {
+		connected, err := f.runInner()
+		if connected {
+			retry.reset()
+		}
+
+		f.mutex.Lock()
+		if err != nil {
+			f.lastError = err
+			f.connected = false
+		}
+		f.mutex.Unlock()
+
+		if err != nil {
+			f.logger.Log(logger.Warn, "SRT forwarder error: %v", err)
+		}
+
 		select {
 		case <-f.ctx.Done():
 			return
 		default:
-			err := f.runInner()
-			if err != nil {
-				f.mutex.Lock()
-				f.lastError = err
-				f.connected = false
-				f.mutex.Unlock()
+		}
 
-				f.logger.Log(logger.Warn, "SRT forwarder error: %v", err)
+		if !f.config.Reconnect {
+			f.fail(err)
+			return
+		}
 
-				if f.config.Reconnect {
-					atomic.AddUint64(&f.reconnectCount, 1)
-					time.Sleep(time.Duration(f.config.ReconnectDelay))
-					continue
-				}
-				return
-			}
+		delay, ok := retry.next(time.Now())
+		if !ok {
+			f.fail(err)
+			return
+		}
+
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-time.After(delay):
+			atomic.AddUint64(&f.reconnectCount, 1)
 		}
 	}
 }
 
-func (f *srtForwarder) runInner() error {
-	// parse URL
+// fail puts the forwarder in the failed state.
+func (f *srtForwarder) fail(err error) {
+	f.mutex.Lock()
+	f.failed = true
+	f.mutex.Unlock()
+
+	f.logger.Log(logger.Error, "SRT forwarder %s: failed, giving up", f.url)
+
+	if f.onFailure != nil {
+		f.onFailure(err)
+	}
+}
+
+// srtConfig returns the configuration and the address of a SRT URL.
+func (f *srtForwarder) srtConfig(u string) (srt.Config, string, error) {
 	srtConf := srt.DefaultConfig()
-	address, err := srtConf.UnmarshalURL(f.url)
+	address, err := srtConf.UnmarshalURL(u)
 	if err != nil {
-		return fmt.Errorf("invalid SRT URL: %w", err)
+		return srt.Config{}, "", fmt.Errorf("invalid SRT URL: %w", err)
 	}
-	
-	// log connection attempt
-	f.logger.Log(logger.Debug, "SRT forwarder: connecting to %s (streamid: %s)", address, srtConf.StreamId)
 
 	// configure SRT
 	if f.config.Passphrase != "" {
 		srtConf.Passphrase = f.config.Passphrase
 	}
-	
+
 	// Note: streamid is already extracted from URL by UnmarshalURL
 	// and stored in srtConf.StreamId, so we don't need to replace it again
 	if f.config.Latency > 0 {
@@ -185,60 +256,234 @@ func (f *srtForwarder) runInner() error {
 
 	err = srtConf.Validate()
 	if err != nil {
-		return fmt.Errorf("invalid SRT config: %w", err)
+		return srt.Config{}, "", fmt.Errorf("invalid SRT config: %w", err)
+	}
+
+	return srtConf, address, nil
+}
+
+// destinations returns the URLs the stream is sent to in caller mode.
+func (f *srtForwarder) destinations() []string {
+	ret := []string{f.url}
+	for _, u := range f.config.RedundantURLs {
+		ret = append(ret, strings.ReplaceAll(u, "$MTX_PATH", f.pathName))
+	}
+	return ret
+}
+
+func (f *srtForwarder) dial(out *srtOutput, u string) error {
+	srtConf, address, err := f.srtConfig(u)
+	if err != nil {
+		return err
 	}
 
-	// establish SRT connection
+	// log connection attempt
+	f.logger.Log(logger.Debug, "SRT forwarder: connecting to %s (streamid: %s)", address, srtConf.StreamId)
+
 	sconn, err := srt.Dial("srt", address, srtConf)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
 
-	f.mutex.Lock()
-	f.sconn = sconn
-	f.connected = true
-	f.mutex.Unlock()
+	out.add(sconn, u)
+	return nil
+}
+
+// redial periodically reconnects redundant destinations that are not connected.
+func (f *srtForwarder) redial(ctx context.Context, out *srtOutput, dests []string) {
+	for {
+		select {
+		case <-time.After(srtRedialPause):
+		case <-ctx.Done():
+			return
+		}
+
+		for _, u := range dests {
+			if out.has(u) {
+				continue
+			}
+
+			err := f.dial(out, u)
+			if err != nil {
+				f.Log(logger.Debug, "destination %s: %v", u, err)
+			} else {
+				f.Log(logger.Info, "connection with %s restored", u)
+			}
+		}
+	}
+}
+
+// runListener accepts connections from readers and adds them to the output.
+func (f *srtForwarder) runListener(ln srt.Listener, out *srtOutput, streamID string) error {
+	for {
+		req, err := ln.Accept2()
+		if err != nil {
+			return err
+		}
+
+		if streamID != "" && req.StreamId() != streamID {
+			f.Log(logger.Warn, "rejected connection from %v: invalid stream ID '%s'", req.RemoteAddr(), req.StreamId())
+			req.Reject(srt.REJ_PEER)
+			continue
+		}
+
+		if f.config.Passphrase != "" {
+			if !req.IsEncrypted() || req.SetPassphrase(f.config.Passphrase) != nil {
+				f.Log(logger.Warn, "rejected connection from %v: invalid passphrase", req.RemoteAddr())
+				req.Reject(srt.REJ_BADSECRET)
+				continue
+			}
+		}
+
+		sconn, err := req.Accept()
+		if err != nil {
+			continue
+		}
+
+		if out.add(sconn, sconn.RemoteAddr().String()) {
+			f.Log(logger.Info, "reader %v connected", sconn.RemoteAddr())
+		}
+	}
+}
+
+// runInner runs a session. It returns whether the session was established.
+func (f *srtForwarder) runInner() (bool, error) {
+	out := &srtOutput{
+		writeTimeout: f.writeTimeout,
+		allowEmpty:   f.config.Mode == srtModeListener,
+		logger:       f,
+		onRemove: func(c srt.Conn) {
+			// keep counters across reconnections
+			var s srt.Statistics
+			c.Stats(&s)
+			atomic.AddUint64(&f.bytesSent, s.Accumulated.ByteSent)
+			atomic.AddUint64(&f.packetsSent, s.Accumulated.PktSent)
+			atomic.AddUint64(&f.packetsLost, s.Accumulated.PktSendLoss)
+		},
+	}
+	out.initialize()
+
+	ctx, ctxCancel := context.WithCancel(f.ctx)
+	var wg sync.WaitGroup
+	listenerErr := make(chan error, 1)
 
 	defer func() {
-		sconn.Close()
+		ctxCancel()
+		wg.Wait()
 		f.mutex.Lock()
-		f.sconn = nil
+		out.close()
+		f.output = nil
 		f.connected = false
 		f.mutex.Unlock()
 	}()
 
+	if f.config.Mode == srtModeListener {
+		srtConf, address, err := f.srtConfig(f.url)
+		if err != nil {
+			return false, err
+		}
+
+		ln, err := srt.Listen("srt", address, srtConf)
+		if err != nil {
+			return false, fmt.Errorf("failed to listen: %w", err)
+		}
+
+		f.Log(logger.Info, "listener opened on %s (UDP)", address)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listenerErr <- f.runListener(ln, out, srtConf.StreamId)
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			ln.Close()
+		}()
+	} else {
+		dests := f.destinations()
+
+		var lastErr error
+		for _, u := range dests {
+			err := f.dial(out, u)
+			if err != nil {
+				if len(dests) > 1 {
+					f.Log(logger.Warn, "destination %s: %v", u, err)
+				}
+				lastErr = err
+			}
+		}
+
+		if out.count() == 0 {
+			return false, lastErr
+		}
+
+		if len(dests) > 1 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f.redial(ctx, out, dests)
+			}()
+		}
+	}
+
+	f.mutex.Lock()
+	f.output = out
+	f.connected = true
+	f.connectedTime = time.Now()
+	f.mutex.Unlock()
+
 	// create buffered writer
 	maxPayloadSize := f.srtMaxPayloadSize()
-	bw := bufio.NewWriterSize(sconn, maxPayloadSize)
+	bw := bufio.NewWriterSize(out, maxPayloadSize)
+
+	// select medias to forward
+	sourcePath := sourcePathName(f.pathName, f.config.SourcePath, f.config.Layer)
+	desc, err := outputDesc(f.stream.Desc, f.pathManager, sourcePath, f.pathName, f.url, f.config.Medias)
+	if err != nil {
+		return false, err
+	}
 
 	// create reader
-	f.reader = &stream.Reader{Parent: f.logger}
+	f.reader = &stream.Reader{Parent: f}
 
-	// use mpegts.FromStream to convert Stream to MPEG-TS and send via SRT
-	err = mpegts.FromStream(f.stream.Desc, f.reader, bw, sconn, f.writeTimeout)
+	// use mpegts.FromStream to convert Stream to MPEG-TS and send via SRT.
+	// write deadlines are set by the output on every connection.
+	err = mpegts.FromStream(desc, f.reader, bw, nil, f.writeTimeout)
 	if err != nil {
-		return fmt.Errorf("failed to setup MPEG-TS writer: %w", err)
+		return false, fmt.Errorf("failed to setup MPEG-TS writer: %w", err)
 	}
 
-	// add reader to stream
-	f.stream.AddReader(f.reader)
-	defer f.stream.RemoveReader(f.reader)
-
-	// wait for connection failure or context cancellation
-	// the connection will be monitored by the reader's error channel
-	errChan := make(chan error, 1)
-	go func() {
-		// wait for reader error
-		err := <-f.reader.Error()
-		errChan <- err
-	}()
+	// feed the writer through a relay, that allows to pause video or switch to other paths
+	rl := newRelay(f.reader, desc, f)
+
+	stop, err := rl.start(f.stream, relayConf{
+		maxBitrate:        f.config.MaxBitrate,
+		fallbackPath:      f.config.MaxBitrateFallbackPath,
+		sourcePath:        sourcePath,
+		dropUntilKeyframe: f.config.DropUntilKeyframe,
+		maxBufferDuration: time.Duration(f.config.MaxBufferDuration),
+		droppedGOPs:       &f.droppedGOPs,
+		pathName:          f.pathName,
+		target:            f.url,
+		pathManager:       f.pathManager,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer stop()
 
 	// wait for error or context cancellation
+	// the connection will be monitored by the relay's error channel
 	select {
-	case err := <-errChan:
-		return err
+	case err := <-rl.Error():
+		return true, err
+	case err := <-listenerErr:
+		return true, err
 	case <-f.ctx.Done():
-		return nil
+		return true, nil
 	}
 }
 
@@ -247,4 +492,3 @@ func (f *srtForwarder) srtMaxPayloadSize() int {
 	// SRT header = 16 bytes, MPEG-TS packet = 188 bytes
 	return ((f.udpMaxPayloadSize - 16) / 188) * 188
 }
-
@@ -0,0 +1,243 @@
+package forwarder
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	srt "github.com/datarhei/gosrt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func readTS(t *testing.T, conn srt.Conn) []byte {
+	buf := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, 0, n%188)
+	require.Equal(t, byte(0x47), buf[0])
+	return buf[:n]
+}
+
+func TestSRTForwarderListener(t *testing.T) {
+	strm := newRelayTestStream(t)
+	defer strm.Close()
+
+	fw := newSRTForwarder(
+		"srt://127.0.0.1:9998?streamid=read:mypath",
+		&conf.SRTForwardTarget{Mode: "listener"},
+		test.NilLogger,
+		10*time.Second,
+		1472,
+		"mypath",
+		nil,
+		nil,
+	)
+
+	err := fw.Start(strm)
+	require.NoError(t, err)
+	defer fw.Stop()
+
+	require.Eventually(t, func() bool {
+		return fw.GetStats().Connected
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// readers with a different stream ID are rejected
+	srtConf := srt.DefaultConfig()
+	srtConf.StreamId = "read:otherpath"
+	_, err = srt.Dial("srt", "127.0.0.1:9998", srtConf)
+	require.Error(t, err)
+
+	srtConf.StreamId = "read:mypath"
+	conn, err := srt.Dial("srt", "127.0.0.1:9998", srtConf)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		pts := int64(90000)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			writeVideo(strm, pts, true)
+			pts += 900
+		}
+	}()
+
+	readTS(t, conn)
+}
+
+func TestSRTForwarderRedundant(t *testing.T) {
+	var conns [2]chan srt.Conn
+
+	for i, addr := range []string{"127.0.0.1:9996", "127.0.0.1:9997"} {
+		ln, err := srt.Listen("srt", addr, srt.DefaultConfig())
+		require.NoError(t, err)
+		defer ln.Close()
+
+		conns[i] = make(chan srt.Conn, 1)
+
+		go func() {
+			req, err2 := ln.Accept2()
+			if err2 != nil {
+				return
+			}
+			c, err2 := req.Accept()
+			if err2 != nil {
+				return
+			}
+			conns[i] <- c
+		}()
+	}
+
+	strm := newRelayTestStream(t)
+	defer strm.Close()
+
+	fw := newSRTForwarder(
+		"srt://127.0.0.1:9996?streamid=publish:mypath",
+		&conf.SRTForwardTarget{RedundantURLs: []string{"srt://127.0.0.1:9997?streamid=publish:$MTX_PATH"}},
+		test.NilLogger,
+		10*time.Second,
+		1472,
+		"mypath",
+		nil,
+		nil,
+	)
+
+	err := fw.Start(strm)
+	require.NoError(t, err)
+	defer fw.Stop()
+
+	var main, backup srt.Conn
+
+	for i, c := range []*srt.Conn{&main, &backup} {
+		select {
+		case *c = <-conns[i]:
+			defer (*c).Close()
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return fw.GetStats().Connected
+	}, 2*time.Second, 10*time.Millisecond)
+
+	writeVideo(strm, 90000, true)
+
+	// destinations receive identical data
+	buf1 := readTS(t, main)
+	buf2 := readTS(t, backup)
+	require.True(t, bytes.Equal(buf1, buf2))
+}
+
+func TestSRTForwarderReconnectBackoff(t *testing.T) {
+	ln, err := srt.Listen("srt", "127.0.0.1:9995", srt.DefaultConfig())
+	require.NoError(t, err)
+	defer ln.Close()
+
+	type attempt struct {
+		time time.Time
+		conn srt.Conn
+	}
+	attempts := make(chan attempt, 16)
+	var rejected atomic.Int32
+
+	go func() {
+		for {
+			req, err2 := ln.Accept2()
+			if err2 != nil {
+				return
+			}
+
+			// reject the first attempts in order to increase the delay
+			if rejected.Add(1) <= 4 {
+				req.Reject(srt.REJ_PEER)
+				attempts <- attempt{time: time.Now()}
+				continue
+			}
+
+			c, err2 := req.Accept()
+			if err2 != nil {
+				return
+			}
+			attempts <- attempt{time: time.Now(), conn: c}
+		}
+	}()
+
+	strm := newRelayTestStream(t)
+	defer strm.Close()
+
+	fw := newSRTForwarder(
+		"srt://127.0.0.1:9995?streamid=publish:mypath",
+		&conf.SRTForwardTarget{
+			Reconnect:        true,
+			ReconnectDelay:   conf.Duration(100 * time.Millisecond),
+			MaxReconnectTime: conf.Duration(2 * time.Second),
+		},
+		test.NilLogger,
+		10*time.Second,
+		1472,
+		"mypath",
+		nil,
+		nil,
+	)
+
+	err = fw.Start(strm)
+	require.NoError(t, err)
+	defer fw.Stop()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		pts := int64(90000)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			writeVideo(strm, pts, true)
+			pts += 900
+		}
+	}()
+
+	readAttempt := func() attempt {
+		select {
+		case a := <-attempts:
+			return a
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+		return attempt{}
+	}
+
+	var a attempt
+	for a.conn == nil {
+		a = readAttempt()
+	}
+
+	// keep the connection open for longer than maxReconnectTime
+	time.Sleep(2500 * time.Millisecond)
+	require.True(t, fw.GetStats().Connected)
+
+	dropTime := time.Now()
+	a.conn.Close()
+
+	// the delay restarts from reconnectDelay
+	a = readAttempt()
+	require.NotNil(t, a.conn)
+	defer a.conn.Close()
+	require.Less(t, a.time.Sub(dropTime), 700*time.Millisecond)
+	require.False(t, fw.GetStats().Failed)
+}
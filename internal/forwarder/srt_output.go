@@ -0,0 +1,126 @@
+package forwarder
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	srt "github.com/datarhei/gosrt"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+// srtOutput writes the same MPEG-TS data to multiple SRT connections.
+// Since data is produced by a single muxer, all connections receive identical TS packets,
+// with the same continuity counters and timestamps, allowing receivers to switch
+// from a connection to another without discontinuities.
+type srtOutput struct {
+	writeTimeout time.Duration
+	// when true, data is discarded when there are no connections, instead of returning an error.
+	allowEmpty bool
+	logger     logger.Writer
+	// called when a connection is removed, in order to keep its statistics.
+	onRemove func(srt.Conn)
+
+	mutex  sync.Mutex
+	conns  map[srt.Conn]string
+	closed bool
+}
+
+func (o *srtOutput) initialize() {
+	o.conns = make(map[srt.Conn]string)
+}
+
+func (o *srtOutput) add(c srt.Conn, label string) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.closed {
+		c.Close()
+		return false
+	}
+
+	o.conns[c] = label
+	return true
+}
+
+func (o *srtOutput) has(label string) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for _, l := range o.conns {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *srtOutput) count() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return len(o.conns)
+}
+
+// stats returns the statistics of connections that are currently open.
+func (o *srtOutput) stats() srt.Statistics {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var ret srt.Statistics
+	for c := range o.conns {
+		var s srt.Statistics
+		c.Stats(&s)
+		ret.Accumulated.ByteSent += s.Accumulated.ByteSent
+		ret.Accumulated.PktSent += s.Accumulated.PktSent
+		ret.Accumulated.PktSendLoss += s.Accumulated.PktSendLoss
+	}
+	return ret
+}
+
+// remove must be called with the mutex locked.
+func (o *srtOutput) remove(c srt.Conn) {
+	c.Close()
+	if o.onRemove != nil {
+		o.onRemove(c)
+	}
+	delete(o.conns, c)
+}
+
+func (o *srtOutput) close() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.closed = true
+
+	for c := range o.conns {
+		o.remove(c)
+	}
+}
+
+// Write implements io.Writer.
+func (o *srtOutput) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var lastErr error
+
+	for c, label := range o.conns {
+		c.SetWriteDeadline(time.Now().Add(o.writeTimeout))
+		_, err := c.Write(p)
+		if err != nil {
+			o.logger.Log(logger.Warn, "connection with %s closed: %v", label, err)
+			o.remove(c)
+			lastErr = err
+		}
+	}
+
+	if len(o.conns) == 0 && !o.allowEmpty {
+		if lastErr != nil {
+			return 0, lastErr
+		}
+		return 0, fmt.Errorf("no connections available")
+	}
+
+	return len(p), nil
+}
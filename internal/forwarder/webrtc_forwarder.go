@@ -10,6 +10,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	pwebrtc "github.com/pion/webrtc/v4"
+
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/tls"
@@ -22,18 +24,20 @@ import (
 var _ Forwarder = (*webrtcForwarder)(nil)
 
 type webrtcForwarder struct {
-	url              string
-	config           *conf.WebRTCForwardTarget
-	stream           *stream.Stream
-	reader           *stream.Reader
-	whipClient       *whip.Client
-	logger           logger.Writer
-	ctx              context.Context
-	ctxCancel        context.CancelFunc
-	wg               sync.WaitGroup
-	mutex            sync.RWMutex
-	writeTimeout     time.Duration
+	url               string
+	config            *conf.WebRTCForwardTarget
+	stream            *stream.Stream
+	reader            *stream.Reader
+	whipClient        *whip.Client
+	logger            logger.Writer
+	ctx               context.Context
+	ctxCancel         context.CancelFunc
+	wg                sync.WaitGroup
+	mutex             sync.RWMutex
+	writeTimeout      time.Duration
 	udpReadBufferSize uint
+	pathName          string
+	pathManager       PathManager
 
 	// statistics
 	bytesSent      uint64
@@ -41,7 +45,12 @@ type webrtcForwarder struct {
 	packetsLost    uint64
 	lastError      error
 	connected      bool
+	connectedTime  time.Time
 	reconnectCount uint64
+	droppedGOPs    uint64
+	failed         bool
+
+	onFailure func(error)
 }
 
 // newWebRTCForwarder creates a new WebRTC forwarder.
@@ -51,17 +60,19 @@ func newWebRTCForwarder(
 	parent logger.Writer,
 	writeTimeout time.Duration,
 	udpReadBufferSize uint,
+	pathName string,
+	pathManager PathManager,
+	onFailure func(error),
 ) Forwarder {
-	ctx, ctxCancel := context.WithCancel(context.Background())
-
 	return &webrtcForwarder{
-		url:              url,
-		config:           config,
-		logger:           parent,
-		ctx:              ctx,
-		ctxCancel:        ctxCancel,
-		writeTimeout:     writeTimeout,
+		url:               url,
+		config:            config,
+		logger:            parent,
+		writeTimeout:      writeTimeout,
 		udpReadBufferSize: udpReadBufferSize,
+		pathName:          pathName,
+		pathManager:       pathManager,
+		onFailure:         onFailure,
 	}
 }
 
@@ -84,7 +95,9 @@ func (f *webrtcForwarder) Start(strm *stream.Stream) error {
 		return fmt.Errorf("forwarder already started")
 	}
 
+	f.ctx, f.ctxCancel = context.WithCancel(context.Background())
 	f.stream = strm
+	f.failed = false
 	f.wg.Add(1)
 	go f.run()
 
@@ -93,6 +106,14 @@ func (f *webrtcForwarder) Start(strm *stream.Stream) error {
 
 // Stop implements Forwarder.
 func (f *webrtcForwarder) Stop() {
+	f.mutex.RLock()
+	started := f.stream != nil
+	f.mutex.RUnlock()
+
+	if !started {
+		return
+	}
+
 	f.ctxCancel()
 	f.wg.Wait()
 
@@ -112,19 +133,31 @@ func (f *webrtcForwarder) Stop() {
 func (f *webrtcForwarder) IsRunning() bool {
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
-	return f.stream != nil && f.whipClient != nil
+	return f.stream != nil && !f.failed
 }
 
 func (f *webrtcForwarder) run() {
 	defer f.wg.Done()
 
+	retry := &retryPolicy{
+		reconnectDelay:   time.Duration(f.config.ReconnectDelay),
+		maxReconnectTime: time.Duration(f.config.MaxReconnectTime),
+	}
+
 	for {
-		err := f.runInner()
+		connected, err := f.runInner()
+		if connected {
+			retry.reset()
+		}
+
+		f.mutex.Lock()
 		if err != nil {
-			f.mutex.Lock()
 			f.lastError = err
 			f.connected = false
-			f.mutex.Unlock()
+		}
+		f.mutex.Unlock()
+
+		if err != nil {
 			f.Log(logger.Warn, "error: %v", err)
 		}
 
@@ -135,34 +168,56 @@ func (f *webrtcForwarder) run() {
 		}
 
 		if !f.config.Reconnect {
+			f.fail(err)
+			return
+		}
+
+		delay, ok := retry.next(time.Now())
+		if !ok {
+			f.fail(err)
 			return
 		}
 
+		f.Log(logger.Info, "reconnecting in %v...", delay.Round(time.Millisecond))
+
 		select {
 		case <-f.ctx.Done():
 			return
-		case <-time.After(time.Duration(f.config.ReconnectDelay)):
+		case <-time.After(delay):
 			atomic.AddUint64(&f.reconnectCount, 1)
-			f.Log(logger.Info, "reconnecting...")
 		}
 	}
 }
 
-func (f *webrtcForwarder) runInner() error {
+// fail puts the forwarder in the failed state.
+func (f *webrtcForwarder) fail(err error) {
+	f.mutex.Lock()
+	f.failed = true
+	f.mutex.Unlock()
+
+	f.Log(logger.Error, "failed, giving up")
+
+	if f.onFailure != nil {
+		f.onFailure(err)
+	}
+}
+
+// runInner runs a session. It returns whether the session was established.
+func (f *webrtcForwarder) runInner() (bool, error) {
 	// parse URL
 	u, err := url.Parse(f.url)
 	if err != nil {
-		return fmt.Errorf("invalid WebRTC URL: %w", err)
+		return false, fmt.Errorf("invalid WebRTC URL: %w", err)
 	}
 
 	// ensure scheme is http or https
 	if u.Scheme != "http" && u.Scheme != "https" {
-		return fmt.Errorf("invalid WebRTC URL scheme: %s (must be http or https)", u.Scheme)
+		return false, fmt.Errorf("invalid WebRTC URL scheme: %s (must be http or https)", u.Scheme)
 	}
 
 	// ensure path ends with /whip
 	if !strings.HasSuffix(u.Path, "/whip") {
-		return fmt.Errorf("invalid WebRTC URL: path must end with /whip")
+		return false, fmt.Errorf("invalid WebRTC URL: path must end with /whip")
 	}
 
 	// log connection attempt
@@ -179,6 +234,13 @@ func (f *webrtcForwarder) runInner() error {
 		Transport: tr,
 	}
 
+	// select medias to forward
+	sourcePath := sourcePathName(f.pathName, f.config.SourcePath, f.config.Layer)
+	desc, err := outputDesc(f.stream.Desc, f.pathManager, sourcePath, f.pathName, f.url, f.config.Medias)
+	if err != nil {
+		return false, err
+	}
+
 	// create reader
 	f.reader = &stream.Reader{Parent: f}
 
@@ -193,35 +255,57 @@ func (f *webrtcForwarder) runInner() error {
 	}
 
 	// setup tracks from stream
-	err = webrtc.FromStream(f.stream.Desc, f.reader, pc)
+	err = webrtc.FromStream(desc, f.reader, pc)
 	if err != nil {
-		return fmt.Errorf("failed to setup WebRTC tracks: %w", err)
+		return false, fmt.Errorf("failed to setup WebRTC tracks: %w", err)
 	}
 
 	// create WHIP client with outgoing tracks
 	whipClient := &whip.Client{
-		URL:               u,
-		Publish:           true,
-		OutgoingTracks:    pc.OutgoingTracks,
-		HTTPClient:        httpClient,
-		UDPReadBufferSize: f.udpReadBufferSize,
-		Log:               f,
+		URL:                u,
+		Publish:            true,
+		OutgoingTracks:     pc.OutgoingTracks,
+		HTTPClient:         httpClient,
+		UDPReadBufferSize:  f.udpReadBufferSize,
+		BearerToken:        f.config.BearerToken,
+		Header:             f.httpHeader(),
+		ICEServers:         f.iceServers(),
+		ICETransportPolicy: f.iceTransportPolicy(),
+		Log:                f,
 	}
 
 	// initialize WHIP client (this will create its own PeerConnection and call setup() on tracks)
 	err = whipClient.Initialize(f.ctx)
 	if err != nil {
-		return fmt.Errorf("failed to initialize WHIP client: %w", err)
+		return false, fmt.Errorf("failed to initialize WHIP client: %w", err)
 	}
 
-	// add reader to stream AFTER whip client is initialized
+	// feed tracks through a relay, that allows to pause video or switch to other paths.
+	// start reading AFTER whip client is initialized
 	// This ensures that tracks are fully set up before data starts flowing
-	f.stream.AddReader(f.reader)
-	defer f.stream.RemoveReader(f.reader)
+	rl := newRelay(f.reader, desc, f)
+
+	stop, err := rl.start(f.stream, relayConf{
+		maxBitrate:        f.config.MaxBitrate,
+		fallbackPath:      f.config.MaxBitrateFallbackPath,
+		sourcePath:        sourcePath,
+		dropUntilKeyframe: f.config.DropUntilKeyframe,
+		maxBufferDuration: time.Duration(f.config.MaxBufferDuration),
+		droppedGOPs:       &f.droppedGOPs,
+		pathName:          f.pathName,
+		target:            f.url,
+		pathManager:       f.pathManager,
+	})
+	if err != nil {
+		whipClient.Close() //nolint:errcheck
+		return false, err
+	}
+	defer stop()
 
 	f.mutex.Lock()
 	f.whipClient = whipClient
 	f.connected = true
+	f.connectedTime = time.Now()
 	f.mutex.Unlock()
 
 	defer func() {
@@ -243,9 +327,11 @@ func (f *webrtcForwarder) runInner() error {
 
 	select {
 	case err := <-errChan:
-		return err
+		return true, err
+	case err := <-rl.Error():
+		return true, err
 	case <-f.ctx.Done():
-		return nil
+		return true, nil
 	}
 }
 
@@ -261,6 +347,12 @@ func (f *webrtcForwarder) GetStats() Stats {
 		LastError:      f.lastError,
 		Connected:      f.connected,
 		ReconnectCount: atomic.LoadUint64(&f.reconnectCount),
+		DroppedGOPs:    atomic.LoadUint64(&f.droppedGOPs),
+		Failed:         f.failed,
+	}
+
+	if f.connected {
+		stats.Uptime = time.Since(f.connectedTime)
 	}
 
 	if f.whipClient != nil && f.whipClient.PeerConnection() != nil {
@@ -275,3 +367,29 @@ func (f *webrtcForwarder) GetStats() Stats {
 	return stats
 }
 
+func (f *webrtcForwarder) httpHeader() http.Header {
+	h := make(http.Header)
+	for key, value := range f.config.Headers {
+		h.Set(key, value)
+	}
+	return h
+}
+
+func (f *webrtcForwarder) iceServers() []pwebrtc.ICEServer {
+	ret := make([]pwebrtc.ICEServer, len(f.config.ICEServers))
+	for i, server := range f.config.ICEServers {
+		ret[i] = pwebrtc.ICEServer{
+			URLs:       []string{server.URL},
+			Username:   server.Username,
+			Credential: server.Password,
+		}
+	}
+	return ret
+}
+
+func (f *webrtcForwarder) iceTransportPolicy() pwebrtc.ICETransportPolicy {
+	if f.config.ICETransportPolicy == "relay" {
+		return pwebrtc.ICETransportPolicyRelay
+	}
+	return pwebrtc.ICETransportPolicyAll
+}
@@ -0,0 +1,56 @@
+package hooks
+
+import (
+	"bytes"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+// triggerWriter calls onTrigger for every line written into it.
+type triggerWriter struct {
+	onTrigger func()
+}
+
+func (w *triggerWriter) Write(p []byte) (int, error) {
+	for range bytes.Count(p, []byte{'\n'}) {
+		w.onTrigger()
+	}
+	return len(p), nil
+}
+
+// RecordTriggerParams are the parameters of RecordTrigger.
+type RecordTriggerParams struct {
+	Logger          logger.Writer
+	ExternalCmdPool *externalcmd.Pool
+	Conf            *conf.Path
+	ExternalCmdEnv  externalcmd.Environment
+	OnTrigger       func()
+}
+
+// RecordTrigger is the RecordTrigger hook.
+// Every line printed by the command on the standard output triggers recording.
+func RecordTrigger(params RecordTriggerParams) func() {
+	var cmd *externalcmd.Cmd
+
+	if params.Conf.RecordTriggerCommand != "" {
+		params.Logger.Log(logger.Info, "recordTriggerCommand command started")
+		cmd = externalcmd.NewCmdWithStdout(
+			params.ExternalCmdPool,
+			params.Conf.RecordTriggerCommand,
+			true,
+			params.ExternalCmdEnv,
+			&triggerWriter{onTrigger: params.OnTrigger},
+			func(err error) {
+				params.Logger.Log(logger.Info, "recordTriggerCommand command exited: %v", err)
+			})
+	}
+
+	return func() {
+		if cmd != nil {
+			cmd.Close()
+			params.Logger.Log(logger.Info, "recordTriggerCommand command stopped")
+		}
+	}
+}
@@ -91,6 +91,8 @@ type Metrics struct {
 	rtmpsServer  defs.APIRTMPServer
 	srtServer    defs.APISRTServer
 	webRTCServer defs.APIWebRTCServer
+
+	forwarderManager defs.APIForwarderManager
 }
 
 // Initialize initializes metrics.
@@ -189,6 +191,7 @@ func (m *Metrics) onMetrics(ctx *gin.Context) {
 	rtmpsConnFilter := ctx.Query("rtmps_conn")
 	srtConnFilter := ctx.Query("srt_conn")
 	webrtcSessionFilter := ctx.Query("webrtc_session")
+	forwarderFilter := ctx.Query("forwarder")
 
 	anyFilterActive := pathFilter != "" ||
 		hlsMuxerFilter != "" ||
@@ -199,7 +202,8 @@ func (m *Metrics) onMetrics(ctx *gin.Context) {
 		rtmpConnFilter != "" ||
 		rtmpsConnFilter != "" ||
 		srtConnFilter != "" ||
-		webrtcSessionFilter != ""
+		webrtcSessionFilter != "" ||
+		forwarderFilter != ""
 
 	out := ""
 
@@ -233,6 +237,71 @@ func (m *Metrics) onMetrics(ctx *gin.Context) {
 		}
 	}
 
+	if (typ == "" || typ == "transcoder_outputs") && (!anyFilterActive || pathFilter != "") {
+		data, err := m.pathManager.APIPathsList()
+		found := false
+
+		if err == nil {
+			for _, pa := range data.Items {
+				if pathFilter == "" || pathFilter == pa.Name {
+					for _, i := range pa.TranscoderOutputs {
+						var state string
+						if i.Ready {
+							state = "ready"
+						} else {
+							state = "notReady"
+						}
+
+						ta := tags(map[string]string{
+							"path":   pa.Name,
+							"output": i.Name,
+							"state":  state,
+						})
+						out += metric("transcoder_outputs", ta, 1)
+						out += metricFloat("transcoder_output_fps", ta, i.FPS)
+						out += metric("transcoder_restarts", ta, int64(i.Restarts))
+						found = true
+					}
+				}
+			}
+		}
+
+		if !found && pathFilter == "" {
+			out += metric("transcoder_outputs", "", 0)
+			out += metricFloat("transcoder_output_fps", "", 0)
+			out += metric("transcoder_restarts", "", 0)
+		}
+	}
+
+	if (typ == "" || typ == "simulcast_layers") && (!anyFilterActive || pathFilter != "") {
+		data, err := m.pathManager.APIPathsList()
+		found := false
+
+		if err == nil {
+			for _, pa := range data.Items {
+				if (pathFilter == "" || pathFilter == pa.Name) && pa.Source != nil {
+					for _, i := range pa.Source.Layers {
+						ta := tags(map[string]string{
+							"path":  pa.Name,
+							"layer": i.Layer,
+							"input": i.Path,
+						})
+						out += metric("simulcast_layers", ta, 1)
+						out += metric("simulcast_layers_rtp_packets_received", ta, int64(i.RTPPacketsReceived))
+						out += metric("simulcast_layers_bytes_received", ta, int64(i.BytesReceived))
+						found = true
+					}
+				}
+			}
+		}
+
+		if !found && pathFilter == "" {
+			out += metric("simulcast_layers", "", 0)
+			out += metric("simulcast_layers_rtp_packets_received", "", 0)
+			out += metric("simulcast_layers_bytes_received", "", 0)
+		}
+	}
+
 	if !interfaceIsEmpty(m.hlsServer) &&
 		(typ == "" || typ == "hls_muxers") &&
 		(!anyFilterActive || hlsMuxerFilter != "") {
@@ -594,6 +663,46 @@ func (m *Metrics) onMetrics(ctx *gin.Context) {
 		}
 	}
 
+	if !interfaceIsEmpty(m.forwarderManager) &&
+		(typ == "" || typ == "forwarders") &&
+		(!anyFilterActive || forwarderFilter != "") {
+		data, err := m.forwarderManager.APIForwardersList()
+		if err == nil && len(data.Items) != 0 {
+			for _, i := range data.Items {
+				if forwarderFilter == "" || forwarderFilter == i.ID.String() {
+					ta := tags(map[string]string{
+						"id":       i.ID.String(),
+						"state":    string(i.State),
+						"path":     i.Path,
+						"protocol": i.Protocol,
+						"target":   i.Target,
+					})
+
+					var connected int64
+					if i.Connected {
+						connected = 1
+					}
+
+					out += metric("forwarders", ta, 1)
+					out += metric("forwarders_connected", ta, connected)
+					out += metric("forwarders_bytes_sent", ta, int64(i.BytesSent))
+					out += metric("forwarders_packets_sent", ta, int64(i.PacketsSent))
+					out += metric("forwarders_packets_lost", ta, int64(i.PacketsLost))
+					out += metric("forwarders_reconnects_total", ta, int64(i.ReconnectCount))
+					out += metric("forwarders_dropped_gops_total", ta, int64(i.DroppedGOPs))
+				}
+			}
+		} else if forwarderFilter == "" {
+			out += metric("forwarders", "", 0)
+			out += metric("forwarders_connected", "", 0)
+			out += metric("forwarders_bytes_sent", "", 0)
+			out += metric("forwarders_packets_sent", "", 0)
+			out += metric("forwarders_packets_lost", "", 0)
+			out += metric("forwarders_reconnects_total", "", 0)
+			out += metric("forwarders_dropped_gops_total", "", 0)
+		}
+	}
+
 	ctx.Writer.WriteHeader(http.StatusOK)
 	io.WriteString(ctx.Writer, out) //nolint:errcheck
 }
@@ -653,3 +762,10 @@ func (m *Metrics) SetWebRTCServer(s defs.APIWebRTCServer) {
 	defer m.mutex.Unlock()
 	m.webRTCServer = s
 }
+
+// SetForwarderManager is called by core.
+func (m *Metrics) SetForwarderManager(s defs.APIForwarderManager) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.forwarderManager = s
+}
@@ -44,6 +44,30 @@ func (dummyPathManager) APIPathsList() (*defs.APIPathList, error) {
 					ID:   "345234423",
 				},
 			},
+			TranscoderOutputs: []*defs.APITranscoderOutput{{
+				Name:     "720p",
+				Type:     "video",
+				Ready:    true,
+				FPS:      29.5,
+				Restarts: 2,
+			}},
+		}, {
+			Name:     "mysimulcast",
+			ConfName: "mysimulcast",
+			Source: &defs.APIPathSourceOrReader{
+				Type: "simulcast",
+				ID:   "simulcast:2_inputs",
+				Layers: []*defs.APISimulcastLayer{{
+					Layer:              "high",
+					Path:               "mypath",
+					RTPPacketsReceived: 123,
+					BytesReceived:      456,
+				}},
+			},
+			Ready:     true,
+			ReadyTime: ptrOf(time.Date(2003, 11, 4, 23, 15, 7, 0, time.UTC)),
+			Tracks:    []string{"H264"},
+			Readers:   []defs.APIPathSourceOrReader{},
 		}},
 	}, nil
 }
@@ -52,6 +76,52 @@ func (dummyPathManager) APIPathsGet(string) (*defs.APIPath, error) {
 	panic("unused")
 }
 
+func (dummyPathManager) APIPathsRecordTrigger(string, *defs.APIPathRecordTriggerReq) error {
+	panic("unused")
+}
+
+type dummyForwarderManager struct{}
+
+func (dummyForwarderManager) APIForwardersList() (*defs.APIForwarderList, error) {
+	return &defs.APIForwarderList{
+		ItemCount: 1,
+		PageCount: 1,
+		Items: []*defs.APIForwarder{{
+			ID:             uuid.MustParse("c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b"),
+			Path:           "mypath",
+			Target:         "rtmp://myserver/live",
+			Protocol:       "rtmp",
+			State:          defs.APIForwarderStateRunning,
+			Connected:      true,
+			BytesSent:      123,
+			PacketsSent:    456,
+			PacketsLost:    789,
+			ReconnectCount: 3,
+			DroppedGOPs:    2,
+		}},
+	}, nil
+}
+
+func (dummyForwarderManager) APIForwardersGet(uuid.UUID) (*defs.APIForwarder, error) {
+	panic("unused")
+}
+
+func (dummyForwarderManager) APIForwardersStart(uuid.UUID) error {
+	panic("unused")
+}
+
+func (dummyForwarderManager) APIForwardersStop(uuid.UUID) error {
+	panic("unused")
+}
+
+func (dummyForwarderManager) APIForwardersRestart(uuid.UUID) error {
+	panic("unused")
+}
+
+func (dummyForwarderManager) APIForwardersAdd(string, *defs.APIForwardReq) (*defs.APIForwarder, error) {
+	panic("unused")
+}
+
 type dummyHLSServer struct{}
 
 func (dummyHLSServer) APIMuxersList() (*defs.APIHLSMuxerList, error) {
@@ -257,6 +327,7 @@ func TestMetrics(t *testing.T) {
 	m.SetRTMPServer(&dummyRTMPServer{})
 	m.SetRTMPSServer(&dummyRTMPServer{})
 	m.SetWebRTCServer(&dummyWebRTCServer{})
+	m.SetForwarderManager(&dummyForwarderManager{})
 
 	tr := &http.Transport{}
 	defer tr.CloseIdleConnections()
@@ -276,6 +347,16 @@ func TestMetrics(t *testing.T) {
 			`paths_bytes_received{name="mypath",state="ready"} 123`+"\n"+
 			`paths_bytes_sent{name="mypath",state="ready"} 456`+"\n"+
 			`paths_readers{name="mypath",state="ready"} 1`+"\n"+
+			`paths{name="mysimulcast",state="ready"} 1`+"\n"+
+			`paths_bytes_received{name="mysimulcast",state="ready"} 0`+"\n"+
+			`paths_bytes_sent{name="mysimulcast",state="ready"} 0`+"\n"+
+			`paths_readers{name="mysimulcast",state="ready"} 0`+"\n"+
+			`transcoder_outputs{output="720p",path="mypath",state="ready"} 1`+"\n"+
+			`transcoder_output_fps{output="720p",path="mypath",state="ready"} 29.5`+"\n"+
+			`transcoder_restarts{output="720p",path="mypath",state="ready"} 2`+"\n"+
+			`simulcast_layers{input="mypath",layer="high",path="mysimulcast"} 1`+"\n"+
+			`simulcast_layers_rtp_packets_received{input="mypath",layer="high",path="mysimulcast"} 123`+"\n"+
+			`simulcast_layers_bytes_received{input="mypath",layer="high",path="mysimulcast"} 456`+"\n"+
 			`hls_muxers{name="mypath"} 1`+"\n"+
 			`hls_muxers_bytes_sent{name="mypath"} 789`+"\n"+
 			`rtsp_conns{id="18294761-f9d1-4ea9-9a35-fe265b62eb41"} 1`+"\n"+
@@ -357,7 +438,21 @@ func TestMetrics(t *testing.T) {
 			`webrtc_sessions_rtcp_packets_received{id="f47ac10b-58cc-4372-a567-0e02b2c3d479",`+
 			`path="mypath",remoteAddr="127.0.0.1:3455",state="read"} 123`+"\n"+
 			`webrtc_sessions_rtcp_packets_sent{id="f47ac10b-58cc-4372-a567-0e02b2c3d479",`+
-			`path="mypath",remoteAddr="127.0.0.1:3455",state="read"} 456`+"\n",
+			`path="mypath",remoteAddr="127.0.0.1:3455",state="read"} 456`+"\n"+
+			`forwarders{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+			`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 1`+"\n"+
+			`forwarders_connected{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+			`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 1`+"\n"+
+			`forwarders_bytes_sent{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+			`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 123`+"\n"+
+			`forwarders_packets_sent{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+			`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 456`+"\n"+
+			`forwarders_packets_lost{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+			`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 789`+"\n"+
+			`forwarders_reconnects_total{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+			`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 3`+"\n"+
+			`forwarders_dropped_gops_total{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+			`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 2`+"\n",
 		string(byts))
 
 	require.True(t, checked)
@@ -418,6 +513,7 @@ func TestFilter(t *testing.T) {
 		"hls_muxer",
 		"rtsp_conn",
 		"rtsp_session",
+		"forwarder",
 		// "rtsps_conn",
 		// "rtsps_session",
 		// "rtmp_conn",
@@ -442,6 +538,7 @@ func TestFilter(t *testing.T) {
 			m.SetHLSServer(&dummyHLSServer{})
 			m.SetRTSPServer(&dummyRTSPServer{})
 			m.SetWebRTCServer(&dummyWebRTCServer{})
+			m.SetForwarderManager(&dummyForwarderManager{})
 
 			tr := &http.Transport{}
 			defer tr.CloseIdleConnections()
@@ -458,6 +555,8 @@ func TestFilter(t *testing.T) {
 				u += "?rtsp_conn=18294761-f9d1-4ea9-9a35-fe265b62eb41"
 			case "rtsp_session":
 				u += "?rtsp_session=124b22ce-9c34-4387-b045-44caf98049f7"
+			case "forwarder":
+				u += "?forwarder=c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b"
 			}
 
 			res, err := hc.Get(u)
@@ -473,7 +572,10 @@ func TestFilter(t *testing.T) {
 					`paths{name="mypath",state="ready"} 1`+"\n"+
 						`paths_bytes_received{name="mypath",state="ready"} 123`+"\n"+
 						`paths_bytes_sent{name="mypath",state="ready"} 456`+"\n"+
-						`paths_readers{name="mypath",state="ready"} 1`+"\n",
+						`paths_readers{name="mypath",state="ready"} 1`+"\n"+
+						`transcoder_outputs{output="720p",path="mypath",state="ready"} 1`+"\n"+
+						`transcoder_output_fps{output="720p",path="mypath",state="ready"} 29.5`+"\n"+
+						`transcoder_restarts{output="720p",path="mypath",state="ready"} 2`+"\n",
 					string(byts))
 
 			case "hls_muxer":
@@ -514,6 +616,24 @@ func TestFilter(t *testing.T) {
 						`rtsp_sessions_rtcp_packets_in_error{id="124b22ce-9c34-4387-b045-44caf98049f7",`+
 						`path="mypath",remoteAddr="124.5.5.5:34542",state="publish"} 456`+"\n",
 					string(byts))
+
+			case "forwarder":
+				require.Equal(t,
+					`forwarders{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+						`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 1`+"\n"+
+						`forwarders_connected{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+						`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 1`+"\n"+
+						`forwarders_bytes_sent{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+						`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 123`+"\n"+
+						`forwarders_packets_sent{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+						`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 456`+"\n"+
+						`forwarders_packets_lost{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+						`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 789`+"\n"+
+						`forwarders_reconnects_total{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+						`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 3`+"\n"+
+						`forwarders_dropped_gops_total{id="c2a8f4b3-5e7f-4a21-9b3e-1d4f6a8c0e2b",`+
+						`path="mypath",protocol="rtmp",state="running",target="rtmp://myserver/live"} 2`+"\n",
+					string(byts))
 			}
 		})
 	}
@@ -32,6 +32,8 @@ func findTrack(tracks []*muxerFMP4Track, id int) *muxerFMP4Track {
 
 type muxerFMP4 struct {
 	w io.Writer
+	// when true, the initialization section is not written.
+	skipInit bool
 
 	init               *fmp4.Init
 	nextSequenceNumber uint32
@@ -170,18 +172,21 @@ func (w *muxerFMP4) innerFlush(final bool) error {
 	w.nextSequenceNumber++
 
 	if w.init != nil {
-		err := w.init.Marshal(&w.outBuf)
-		if err != nil {
-			return err
-		}
+		if !w.skipInit {
+			err := w.init.Marshal(&w.outBuf)
+			if err != nil {
+				return err
+			}
+
+			_, err = w.w.Write(w.outBuf.Bytes())
+			if err != nil {
+				return err
+			}
 
-		_, err = w.w.Write(w.outBuf.Bytes())
-		if err != nil {
-			return err
+			w.outBuf.Reset()
 		}
 
 		w.init = nil
-		w.outBuf.Reset()
 	}
 
 	err := part.Marshal(&w.outBuf)
@@ -0,0 +1,324 @@
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/gin-gonic/gin"
+)
+
+// exportSample is a sample of an exported path.
+// When isFinal is true, it contains the final DTS of a track.
+type exportSample struct {
+	trackID         int
+	isFinal         bool
+	dts             int64
+	ptsOffset       int32
+	isNonSyncSample bool
+	payloadSize     uint32
+	getPayload      func() ([]byte, error)
+}
+
+// muxerExportPath is a muxer that passes the samples of a path to yield,
+// after converting track IDs into the ones of the exported file.
+type muxerExportPath struct {
+	trackIDs map[int]int
+	yield    func(*exportSample) bool
+
+	curTrackID int
+}
+
+func (w *muxerExportPath) writeInit(_ *fmp4.Init) {
+	// initialization is performed once, with the tracks of all paths.
+}
+
+func (w *muxerExportPath) setTrack(trackID int) {
+	w.curTrackID = w.trackIDs[trackID]
+}
+
+func (w *muxerExportPath) writeSample(
+	dts int64,
+	ptsOffset int32,
+	isNonSyncSample bool,
+	payloadSize uint32,
+	getPayload func() ([]byte, error),
+) error {
+	ok := w.yield(&exportSample{
+		trackID:         w.curTrackID,
+		dts:             dts,
+		ptsOffset:       ptsOffset,
+		isNonSyncSample: isNonSyncSample,
+		payloadSize:     payloadSize,
+		getPayload:      getPayload,
+	})
+	if !ok {
+		return errTerminated
+	}
+	return nil
+}
+
+func (w *muxerExportPath) writeFinalDTS(dts int64) {
+	w.yield(&exportSample{
+		trackID: w.curTrackID,
+		isFinal: true,
+		dts:     dts,
+	})
+}
+
+func (w *muxerExportPath) flush() error {
+	// flushing is performed once, after all paths have been written.
+	return nil
+}
+
+// exportCursor reads the samples of a path incrementally, one at a time.
+type exportCursor struct {
+	trackIDs map[int]int
+	read     func(m muxer) error
+
+	next func() (*exportSample, bool)
+	stop func()
+	err  error
+}
+
+func (c *exportCursor) initialize() {
+	c.next, c.stop = iter.Pull(func(yield func(*exportSample) bool) {
+		c.err = c.read(&muxerExportPath{
+			trackIDs: c.trackIDs,
+			yield:    yield,
+		})
+	})
+}
+
+// interleaveExportPaths writes the samples of multiple paths into a muxer in decode order.
+// Each path is read incrementally through a cursor, and the next sample is taken
+// from the cursor whose current sample has the lowest timestamp,
+// therefore output is produced while paths are being read.
+func interleaveExportPaths(m muxer, tracks []*fmp4.InitTrack, cursors []*exportCursor) error {
+	timeScales := make(map[int]uint32)
+	for _, track := range tracks {
+		timeScales[track.ID] = track.TimeScale
+	}
+
+	heads := make([]*exportSample, len(cursors))
+
+	for {
+		nextI := -1
+		var nextDTS time.Duration
+
+		for i, c := range cursors {
+			for heads[i] == nil {
+				sample, ok := c.next()
+				if !ok {
+					if c.err != nil {
+						return c.err
+					}
+					break
+				}
+
+				// the final DTS follows the last sample of the track, that has already been written
+				if sample.isFinal {
+					m.setTrack(sample.trackID)
+					m.writeFinalDTS(sample.dts)
+					continue
+				}
+
+				heads[i] = sample
+			}
+
+			if heads[i] != nil {
+				dts := durationMp4ToGo(heads[i].dts, timeScales[heads[i].trackID])
+				if nextI < 0 || dts < nextDTS {
+					nextI = i
+					nextDTS = dts
+				}
+			}
+		}
+
+		if nextI < 0 {
+			break
+		}
+
+		sample := heads[nextI]
+		heads[nextI] = nil
+
+		m.setTrack(sample.trackID)
+
+		err := m.writeSample(
+			sample.dts,
+			sample.ptsOffset,
+			sample.isNonSyncSample,
+			sample.payloadSize,
+			sample.getPayload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.flush()
+}
+
+type exportPath struct {
+	recordFormat conf.RecordFormat
+	segments     []*recordstore.Segment
+	trackIDs     map[int]int
+}
+
+func (s *Server) onExport(ctx *gin.Context) {
+	pathNames := ctx.QueryArray("path")
+	if len(pathNames) == 0 {
+		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("no paths provided"))
+		return
+	}
+
+	for i, pathName := range pathNames {
+		for _, prev := range pathNames[:i] {
+			if pathName == prev {
+				s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("duplicate path: %s", pathName))
+				return
+			}
+		}
+	}
+
+	for _, pathName := range pathNames {
+		if !s.doAuth(ctx, pathName) {
+			return
+		}
+	}
+
+	start, err := time.Parse(time.RFC3339, ctx.Query("start"))
+	if err != nil {
+		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid start: %w", err))
+		return
+	}
+
+	duration, err := parseDuration(ctx.Query("duration"))
+	if err != nil {
+		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid duration: %w", err))
+		return
+	}
+
+	ww := &writerWrapper{ctx: ctx}
+	var m muxer
+
+	format := ctx.Query("format")
+	switch format {
+	case "", "fmp4":
+		m = &muxerFMP4{w: ww}
+
+	case "mp4":
+		m = &muxerMP4{w: ww}
+
+	default:
+		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid format: %s", format))
+		return
+	}
+
+	end := start.Add(duration)
+	paths := make([]*exportPath, len(pathNames))
+	var tracks []*fmp4.InitTrack
+
+	for i, pathName := range pathNames {
+		var pathConf *conf.Path
+		pathConf, err = s.safeFindPathConf(pathName)
+		if err != nil {
+			s.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		var segments []*recordstore.Segment
+		segments, err = recordstore.FindSegments(pathConf, pathName, &start, &end, s)
+		if err != nil {
+			if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+				s.writeError(ctx, http.StatusNotFound, fmt.Errorf("path '%s': %w", pathName, err))
+			} else {
+				s.writeError(ctx, http.StatusBadRequest, err)
+			}
+			return
+		}
+
+		var init *fmp4.Init
+		var segmentStart time.Time
+		init, segmentStart, err = readSegmentInit(pathConf.RecordFormat, segments[0])
+		if err != nil {
+			s.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		// align tracks on the absolute start time of the first segment
+		firstSegment := *segments[0]
+		firstSegment.Start = segmentStart
+		segments[0] = &firstSegment
+
+		trackIDs := make(map[int]int)
+
+		for _, track := range init.Tracks {
+			id := len(tracks) + 1
+			trackIDs[track.ID] = id
+			tracks = append(tracks, &fmp4.InitTrack{
+				ID:        id,
+				TimeScale: track.TimeScale,
+				Codec:     track.Codec,
+			})
+		}
+
+		paths[i] = &exportPath{
+			recordFormat: pathConf.RecordFormat,
+			segments:     segments,
+			trackIDs:     trackIDs,
+		}
+	}
+
+	m.writeInit(&fmp4.Init{
+		Tracks: tracks,
+	})
+
+	cursors := make([]*exportCursor, len(paths))
+
+	for i, p := range paths {
+		// the fMP4 muxer reads payloads when samples are written,
+		// therefore files can be closed as soon as the next one is opened.
+		files := &fileGroup{closePrevious: (format != "mp4")}
+		defer files.close()
+
+		cursors[i] = &exportCursor{
+			trackIDs: p.trackIDs,
+			read: func(m muxer) error {
+				return seekAndMuxNoFlush(p.recordFormat, p.segments, start, duration, 0, m, files)
+			},
+		}
+		cursors[i].initialize()
+		defer cursors[i].stop()
+	}
+
+	err = interleaveExportPaths(m, tracks, cursors)
+
+	if err != nil {
+		// user aborted the download
+		var neterr *net.OpError
+		if errors.As(err, &neterr) {
+			return
+		}
+
+		// nothing has been written yet; send back JSON
+		if !ww.written {
+			if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+				s.writeError(ctx, http.StatusNotFound, err)
+			} else {
+				s.writeError(ctx, http.StatusBadRequest, err)
+			}
+			return
+		}
+
+		// something has already been written: abort and write logs only
+		s.Log(logger.Error, err.Error())
+		return
+	}
+}
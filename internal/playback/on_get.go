@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
@@ -40,6 +39,69 @@ func parseDuration(raw string) (time.Duration, error) {
 	return time.ParseDuration(raw)
 }
 
+// readSegmentInit returns the tracks and the absolute start time of a segment.
+// The NTP timestamp stored in the mtxi box is preferred to the start time
+// encoded in the file name, since the latter may lack sub-second precision.
+func readSegmentInit(recordFormat conf.RecordFormat, seg *recordstore.Segment) (*fmp4.Init, time.Time, error) {
+	f, err := seg.Open()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+
+	start := seg.Start
+	var init *fmp4.Init
+
+	if recordFormat == conf.RecordFormatMPEGTS {
+		init, err = segmentMPEGTSReadHeader(f)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+	} else {
+		init, _, err = segmentFMP4ReadHeader(f)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		if mtxi := findMtxi(init.UserData); mtxi != nil && mtxi.NTP != 0 {
+			start = time.Unix(0, mtxi.NTP)
+		}
+	}
+
+	return &fmp4.Init{
+		Tracks: init.Tracks,
+	}, start, nil
+}
+
+// fileGroup is a group of segment files that are closed together.
+type fileGroup struct {
+	// when true, files are closed as soon as the next one is opened.
+	// This is possible when payloads are read before the next sample is written.
+	closePrevious bool
+
+	files []recordstore.SegmentReader
+}
+
+func (g *fileGroup) open(seg *recordstore.Segment) (recordstore.SegmentReader, error) {
+	if g.closePrevious {
+		g.close()
+	}
+
+	f, err := seg.Open()
+	if err != nil {
+		return nil, err
+	}
+	g.files = append(g.files, f)
+	return f, nil
+}
+
+func (g *fileGroup) close() {
+	for _, f := range g.files {
+		f.Close()
+	}
+	g.files = g.files[:0]
+}
+
 func seekAndMux(
 	recordFormat conf.RecordFormat,
 	segments []*recordstore.Segment,
@@ -47,76 +109,107 @@ func seekAndMux(
 	duration time.Duration,
 	m muxer,
 ) error {
-	if recordFormat == conf.RecordFormatFMP4 {
-		f, err := os.Open(segments[0].Fpath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
+	var files fileGroup
+	defer files.close()
 
-		firstInit, _, err := segmentFMP4ReadHeader(f)
-		if err != nil {
-			return err
-		}
+	err := seekAndMuxNoFlush(recordFormat, segments, start, duration, 0, m, &files)
+	if err != nil {
+		return err
+	}
 
-		m.writeInit(&fmp4.Init{
-			Tracks: firstInit.Tracks,
-		})
+	return m.flush()
+}
 
-		firstMtxi := findMtxi(firstInit.UserData)
-		startOffset := segments[0].Start.Sub(start) // this is negative
-		dts := startOffset
-		prevInit := firstInit
+// forEachSegment opens the segments that can be concatenated to the first one,
+// reads their header and calls cb with their timestamp relative to start.
+// cb returns the duration of the segment.
+func forEachSegment(
+	recordFormat conf.RecordFormat,
+	segments []*recordstore.Segment,
+	start time.Time,
+	files *fileGroup,
+	cb func(i int, f recordstore.SegmentReader, init *fmp4.Init, dts time.Duration) (time.Duration, error),
+) error {
+	startOffset := segments[0].Start.Sub(start) // this is negative
+	var firstMtxi *recordstore.Mtxi
+	var prevInit *fmp4.Init
+	var segmentEnd time.Time
 
-		segmentDuration, err := segmentFMP4MuxParts(f, dts, duration, firstInit.Tracks, m)
+	for i, seg := range segments {
+		f, err := files.open(seg)
 		if err != nil {
 			return err
 		}
 
-		segmentEnd := segments[0].Start.Add(segmentDuration)
-
-		for _, seg := range segments[1:] {
-			f, err = os.Open(seg.Fpath)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-
-			var init *fmp4.Init
+		var init *fmp4.Init
+		if recordFormat == conf.RecordFormatMPEGTS {
+			init, err = segmentMPEGTSReadHeader(f)
+		} else {
 			init, _, err = segmentFMP4ReadHeader(f)
-			if err != nil {
-				return err
-			}
+		}
+		if err != nil {
+			return err
+		}
 
-			if !segmentFMP4CanBeConcatenated(prevInit, segmentEnd, init, seg.Start) {
-				break
-			}
+		var dts time.Duration
 
-			if firstMtxi != nil {
-				mtxi := findMtxi(init.UserData)
-				dts = time.Duration(mtxi.DTS-firstMtxi.DTS) + startOffset
-			} else { // legacy method
-				dts = seg.Start.Sub(start) // this is positive
-			}
+		switch {
+		case i == 0:
+			firstMtxi = findMtxi(init.UserData)
+			dts = startOffset
 
-			segmentDuration, err = segmentFMP4MuxParts(f, dts, duration, firstInit.Tracks, m)
-			if err != nil {
-				return err
-			}
+		case !segmentFMP4CanBeConcatenated(prevInit, segmentEnd, init, seg.Start):
+			return nil
+
+		case firstMtxi != nil:
+			mtxi := findMtxi(init.UserData)
+			dts = time.Duration(mtxi.DTS-firstMtxi.DTS) + startOffset
 
-			segmentEnd = seg.Start.Add(segmentDuration)
-			prevInit = init
+		default: // MPEG-TS or legacy method
+			dts = seg.Start.Sub(start) // this is positive
 		}
 
-		err = m.flush()
+		segmentDuration, err := cb(i, f, init, dts)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		segmentEnd = seg.Start.Add(segmentDuration)
+		prevInit = init
 	}
 
-	return fmt.Errorf("MPEG-TS format is not supported yet")
+	return nil
+}
+
+// seekAndMuxNoFlush writes segments into a muxer without flushing it.
+// Files are kept open until the muxer is flushed, since samples may be read lazily.
+// Samples that precede from, relative to start, are not needed by the caller,
+// hence MPEG-TS segments are read from the random access point that precedes it.
+func seekAndMuxNoFlush(
+	recordFormat conf.RecordFormat,
+	segments []*recordstore.Segment,
+	start time.Time,
+	duration time.Duration,
+	from time.Duration,
+	m muxer,
+	files *fileGroup,
+) error {
+	var firstInit *fmp4.Init
+
+	return forEachSegment(recordFormat, segments, start, files,
+		func(i int, f recordstore.SegmentReader, init *fmp4.Init, dts time.Duration) (time.Duration, error) {
+			if i == 0 {
+				firstInit = init
+				m.writeInit(&fmp4.Init{
+					Tracks: init.Tracks,
+				})
+			}
+
+			if recordFormat == conf.RecordFormatMPEGTS {
+				return segmentMPEGTSMuxSamples(f, dts, from, duration, init, m)
+			}
+			return segmentFMP4MuxParts(f, dts, duration, firstInit.Tracks, m)
+		})
 }
 
 func (s *Server) onGet(ctx *gin.Context) {
@@ -149,6 +242,10 @@ func (s *Server) onGet(ctx *gin.Context) {
 	case "mp4":
 		m = &muxerMP4{w: ww}
 
+	case "hls":
+		s.onGetHLS(ctx, pathName, start, duration)
+		return
+
 	default:
 		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid format: %s", format))
 		return
@@ -161,7 +258,7 @@ func (s *Server) onGet(ctx *gin.Context) {
 	}
 
 	end := start.Add(duration)
-	segments, err := recordstore.FindSegments(pathConf, pathName, &start, &end)
+	segments, err := recordstore.FindSegments(pathConf, pathName, &start, &end, s)
 	if err != nil {
 		if errors.Is(err, recordstore.ErrNoSegmentsFound) {
 			s.writeError(ctx, http.StatusNotFound, err)
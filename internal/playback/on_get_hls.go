@@ -0,0 +1,574 @@
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4/seekablebuffer"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	hlsMinSegmentDuration = 6 * time.Second
+	hlsTimespanCacheTTL   = 5 * time.Minute
+)
+
+func formatHLSDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// durationMp4ToGoCeil converts a timestamp into a duration
+// that is converted back into the same timestamp by durationGoToMp4().
+func durationMp4ToGoCeil(v int64, timeScale uint32) time.Duration {
+	if v < 0 {
+		return -durationMp4ToGoCeil(-v, timeScale)
+	}
+
+	d := durationMp4ToGo(v, timeScale)
+	if durationGoToMp4(d, timeScale) != v {
+		d++
+	}
+	return d
+}
+
+type hlsSegment struct {
+	// index of the recording segment that contains the start of the segment.
+	file int
+	// timestamp of the recording segment.
+	fileDTS time.Duration
+	// start and end of the segment, relative to the start of the timespan.
+	start time.Duration
+	end   time.Duration
+}
+
+// hlsTimespan is a timespan that is split into HLS segments.
+type hlsTimespan struct {
+	recordFormat conf.RecordFormat
+	files        []*recordstore.Segment
+	init         *fmp4.Init
+	segments     []*hlsSegment
+
+	lastUsed time.Time
+}
+
+// hlsSegmentStart is a random access point that starts a segment.
+type hlsSegmentStart struct {
+	dts  int64
+	file int
+}
+
+// hlsTimespanScanner finds the segment boundaries of a timespan.
+// Segments start with a random access point of the first video track
+// (or of the first track when there is no video) and last at least hlsMinSegmentDuration.
+// The first segment starts from the random access point that precedes the start of the timespan.
+type hlsTimespanScanner struct {
+	track       *fmp4.InitTrack
+	minDuration int64
+
+	curFile  int
+	fileDTSs []time.Duration
+	starts   []hlsSegmentStart
+}
+
+func (w *hlsTimespanScanner) initialize(init *fmp4.Init) {
+	w.track = init.Tracks[0]
+	for _, t := range init.Tracks {
+		if t.Codec.IsVideo() {
+			w.track = t
+			break
+		}
+	}
+
+	w.minDuration = durationGoToMp4(hlsMinSegmentDuration, w.track.TimeScale)
+}
+
+func (w *hlsTimespanScanner) onSegment(i int, dts time.Duration) {
+	w.curFile = i
+	w.fileDTSs = append(w.fileDTSs, dts)
+}
+
+func (w *hlsTimespanScanner) onRandomAccess(dts int64) {
+	start := hlsSegmentStart{
+		dts:  dts,
+		file: w.curFile,
+	}
+
+	switch {
+	case len(w.starts) == 0 || dts <= 0:
+		w.starts = append(w.starts[:0], start)
+
+	case (dts - w.starts[len(w.starts)-1].dts) >= w.minDuration:
+		w.starts = append(w.starts, start)
+	}
+}
+
+// muxerHLSSegment writes the samples of a HLS segment into a muxer.
+// Samples that precede the segment are discarded, each track starts
+// with a random access point, and timestamps are made relative to the first segment,
+// in order to obtain independent segments with continuous timestamps.
+type muxerHLSSegment struct {
+	m      muxer
+	origin time.Duration
+	start  time.Duration
+
+	timeScales map[int]uint32
+	started    map[int]bool
+	curTrackID int
+}
+
+func (w *muxerHLSSegment) writeInit(init *fmp4.Init) {
+	w.timeScales = make(map[int]uint32)
+	w.started = make(map[int]bool)
+
+	for _, track := range init.Tracks {
+		w.timeScales[track.ID] = track.TimeScale
+	}
+
+	w.m.writeInit(init)
+}
+
+func (w *muxerHLSSegment) setTrack(trackID int) {
+	w.curTrackID = trackID
+	w.m.setTrack(trackID)
+}
+
+func (w *muxerHLSSegment) writeSample(
+	dts int64,
+	ptsOffset int32,
+	isNonSyncSample bool,
+	payloadSize uint32,
+	getPayload func() ([]byte, error),
+) error {
+	timeScale := w.timeScales[w.curTrackID]
+
+	if dts < durationGoToMp4(w.start, timeScale) {
+		return nil
+	}
+
+	if !w.started[w.curTrackID] {
+		if isNonSyncSample {
+			return nil
+		}
+		w.started[w.curTrackID] = true
+	}
+
+	return w.m.writeSample(
+		dts-durationGoToMp4(w.origin, timeScale),
+		ptsOffset,
+		isNonSyncSample,
+		payloadSize,
+		getPayload)
+}
+
+func (w *muxerHLSSegment) writeFinalDTS(dts int64) {
+	if w.started[w.curTrackID] {
+		w.m.writeFinalDTS(dts - durationGoToMp4(w.origin, w.timeScales[w.curTrackID]))
+	}
+}
+
+func (w *muxerHLSSegment) flush() error {
+	return w.m.flush()
+}
+
+type hlsTimespanKey struct {
+	pathName string
+	start    int64
+	duration time.Duration
+}
+
+// hlsTimespanCache stores the segments of timespans,
+// in order to avoid finding and parsing recordings at every segment request.
+type hlsTimespanCache struct {
+	mutex   sync.Mutex
+	entries map[hlsTimespanKey]*hlsTimespan
+}
+
+func (c *hlsTimespanCache) get(key hlsTimespanKey) *hlsTimespan {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ts, ok := c.entries[key]
+	if !ok || time.Since(ts.lastUsed) >= hlsTimespanCacheTTL {
+		return nil
+	}
+
+	ts.lastUsed = time.Now()
+	return ts
+}
+
+func (c *hlsTimespanCache) set(key hlsTimespanKey, ts *hlsTimespan) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[hlsTimespanKey]*hlsTimespan)
+	}
+
+	for k, v := range c.entries {
+		if time.Since(v.lastUsed) >= hlsTimespanCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+
+	ts.lastUsed = time.Now()
+	c.entries[key] = ts
+}
+
+// timespanSegments returns the recording segments that may contain a timespan.
+func timespanSegments(segments []*recordstore.Segment, start time.Time, end time.Time) []*recordstore.Segment {
+	i := 0
+	for j, seg := range segments {
+		if !seg.Start.After(start) {
+			i = j
+		}
+	}
+	segments = segments[i:]
+
+	for j, seg := range segments {
+		if j != 0 && seg.Start.After(end) {
+			return segments[:j]
+		}
+	}
+	return segments
+}
+
+// scanHLSTimespan splits a timespan into HLS segments.
+// Random access points are found by reading part headers (fMP4)
+// or packet headers (MPEG-TS), without reading sample payloads.
+func scanHLSTimespan(
+	recordFormat conf.RecordFormat,
+	segments []*recordstore.Segment,
+	start time.Time,
+	duration time.Duration,
+) (*hlsTimespan, error) {
+	var files fileGroup
+	defer files.close()
+
+	var firstInit *fmp4.Init
+	sc := &hlsTimespanScanner{}
+
+	err := forEachSegment(recordFormat, segments, start, &files,
+		func(i int, f recordstore.SegmentReader, init *fmp4.Init, dts time.Duration) (time.Duration, error) {
+			if i == 0 {
+				if len(init.Tracks) == 0 {
+					return 0, recordstore.ErrNoSegmentsFound
+				}
+				firstInit = init
+				sc.initialize(init)
+			}
+
+			sc.onSegment(i, dts)
+
+			if recordFormat == conf.RecordFormatMPEGTS {
+				return segmentMPEGTSScanRandomAccess(f, dts, 0, duration, init, sc.track, sc.onRandomAccess)
+			}
+			return segmentFMP4ScanRandomAccess(f, dts, duration, firstInit.Tracks, sc.track.ID, sc.onRandomAccess)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sc.starts) == 0 {
+		return nil, recordstore.ErrNoSegmentsFound
+	}
+
+	ts := &hlsTimespan{
+		recordFormat: recordFormat,
+		files:        segments,
+		init: &fmp4.Init{
+			Tracks: firstInit.Tracks,
+		},
+		segments: make([]*hlsSegment, len(sc.starts)),
+	}
+
+	for i, st := range sc.starts {
+		ts.segments[i] = &hlsSegment{
+			file:    st.file,
+			fileDTS: sc.fileDTSs[st.file],
+			start:   durationMp4ToGoCeil(st.dts, sc.track.TimeScale),
+		}
+		if i != 0 {
+			ts.segments[i-1].end = ts.segments[i].start
+		}
+	}
+	ts.segments[len(ts.segments)-1].end = duration
+
+	return ts, nil
+}
+
+func (s *Server) findHLSTimespan(
+	pathConf *conf.Path,
+	pathName string,
+	start time.Time,
+	duration time.Duration,
+	segments []*recordstore.Segment,
+) (*hlsTimespan, error) {
+	key := hlsTimespanKey{
+		pathName: pathName,
+		start:    start.UnixNano(),
+		duration: duration,
+	}
+
+	if ts := s.hlsTimespans.get(key); ts != nil && ts.recordFormat == pathConf.RecordFormat {
+		return ts, nil
+	}
+
+	end := start.Add(duration)
+
+	if segments == nil {
+		var err error
+		segments, err = recordstore.FindSegments(pathConf, pathName, &start, &end, s)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		segments = timespanSegments(segments, start, end)
+	}
+
+	ts, err := scanHLSTimespan(pathConf.RecordFormat, segments, start, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	s.hlsTimespans.set(key, ts)
+
+	return ts, nil
+}
+
+type hlsPlaylistEntry struct {
+	start    time.Time
+	duration time.Duration
+	timespan *hlsTimespan
+}
+
+// generateHLSPlaylist generates a VOD playlist that covers the given timespans.
+// Each timespan has its own initialization section and is split into segments,
+// that are generated on the fly by requesting them to the /get endpoint.
+func generateHLSPlaylist(pathName string, entries []hlsPlaylistEntry, jwt string) []byte {
+	var targetDuration time.Duration
+	for _, entry := range entries {
+		for _, seg := range entry.timespan.segments {
+			targetDuration = max(targetDuration, seg.end-seg.start)
+		}
+	}
+
+	var b strings.Builder
+
+	b.WriteString("#EXTM3U\n" +
+		"#EXT-X-VERSION:7\n" +
+		"#EXT-X-TARGETDURATION:" + strconv.FormatInt(int64(math.Ceil(targetDuration.Seconds())), 10) + "\n" +
+		"#EXT-X-MEDIA-SEQUENCE:0\n" +
+		"#EXT-X-PLAYLIST-TYPE:VOD\n" +
+		"#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	for i, entry := range entries {
+		segmentURL := func(segment string) string {
+			v := url.Values{}
+			v.Add("path", pathName)
+			v.Add("start", entry.start.Format(time.RFC3339Nano))
+			v.Add("duration", formatHLSDuration(entry.duration))
+			v.Add("format", "hls")
+			v.Add("segment", segment)
+			if jwt != "" {
+				v.Add("jwt", jwt)
+			}
+			return "get?" + v.Encode()
+		}
+
+		if i != 0 {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+
+		// the first segment starts from the random access point
+		// that precedes the start of the timespan.
+		programDateTime := entry.start.Add(entry.timespan.segments[0].start)
+
+		b.WriteString("#EXT-X-MAP:URI=\"" + segmentURL("init") + "\"\n" +
+			"#EXT-X-PROGRAM-DATE-TIME:" + programDateTime.Format("2006-01-02T15:04:05.999Z07:00") + "\n")
+
+		for n, seg := range entry.timespan.segments {
+			b.WriteString("#EXTINF:" + formatHLSDuration(seg.end-seg.start) + ",\n" +
+				segmentURL(strconv.FormatInt(int64(n), 10)) + "\n")
+		}
+	}
+
+	b.WriteString("#EXT-X-ENDLIST\n")
+
+	return []byte(b.String())
+}
+
+func (s *Server) onGetHLS(ctx *gin.Context, pathName string, start time.Time, duration time.Duration) {
+	pathConf, err := s.safeFindPathConf(pathName)
+	if err != nil {
+		s.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	segment := ctx.Query("segment")
+
+	if segment == "" {
+		s.onGetHLSPlaylist(ctx, pathConf, pathName, start, duration)
+		return
+	}
+
+	// durations of timespans are sent back in seconds
+	// and have been truncated to milliseconds.
+	ts, err := s.findHLSTimespan(pathConf, pathName, start, duration.Round(time.Millisecond), nil)
+	if err != nil {
+		if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+			s.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			s.writeError(ctx, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	if segment == "init" {
+		s.onGetHLSInit(ctx, ts)
+		return
+	}
+
+	n, err := strconv.ParseUint(segment, 10, 31)
+	if err != nil || int(n) >= len(ts.segments) {
+		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid segment: %s", segment))
+		return
+	}
+
+	s.onGetHLSSegment(ctx, ts, int(n))
+}
+
+func (s *Server) onGetHLSPlaylist(
+	ctx *gin.Context,
+	pathConf *conf.Path,
+	pathName string,
+	start time.Time,
+	duration time.Duration,
+) {
+	end := start.Add(duration)
+	segments, err := recordstore.FindSegments(pathConf, pathName, &start, &end, s)
+	if err != nil {
+		if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+			s.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			s.writeError(ctx, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	entries, err := parseAndConcatenate(pathConf.RecordFormat, segments)
+	if err != nil {
+		s.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	entries = clipEntries(entries, &start, &end)
+
+	var playlistEntries []hlsPlaylistEntry
+
+	for _, entry := range entries {
+		// durations are sent back to the server in seconds;
+		// truncate them in order to avoid rounding errors.
+		entryDuration := time.Duration(entry.Duration).Truncate(time.Millisecond)
+		if entryDuration <= 0 {
+			continue
+		}
+
+		var ts *hlsTimespan
+		ts, err = s.findHLSTimespan(pathConf, pathName, entry.Start, entryDuration, segments)
+		if err != nil {
+			if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+				continue
+			}
+			s.writeError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		playlistEntries = append(playlistEntries, hlsPlaylistEntry{
+			start:    entry.Start,
+			duration: entryDuration,
+			timespan: ts,
+		})
+	}
+
+	if len(playlistEntries) == 0 {
+		s.writeError(ctx, http.StatusNotFound, recordstore.ErrNoSegmentsFound)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/vnd.apple.mpegurl",
+		generateHLSPlaylist(pathName, playlistEntries, ctx.Query("jwt")))
+}
+
+func (s *Server) onGetHLSInit(ctx *gin.Context, ts *hlsTimespan) {
+	var buf seekablebuffer.Buffer
+	err := ts.init.Marshal(&buf)
+	if err != nil {
+		s.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "video/mp4", buf.Bytes())
+}
+
+func (s *Server) onGetHLSSegment(ctx *gin.Context, ts *hlsTimespan, n int) {
+	seg := ts.segments[n]
+
+	// start muxing from the recording segment that contains the start of the segment.
+	// Timestamps are computed in the same way as when the timespan was scanned,
+	// hence the segment starts exactly with the random access point that was found.
+	files := ts.files[seg.file:]
+	start := files[0].Start.Add(-seg.fileDTS)
+
+	ww := &writerWrapper{ctx: ctx}
+
+	m := &muxerHLSSegment{
+		m: &muxerFMP4{
+			w:        ww,
+			skipInit: true,
+		},
+		origin: ts.segments[0].start,
+		start:  seg.start,
+	}
+
+	var fg fileGroup
+	defer fg.close()
+
+	err := seekAndMuxNoFlush(ts.recordFormat, files, start, seg.end, seg.start, m, &fg)
+	if err == nil {
+		err = m.flush()
+	}
+
+	if err != nil {
+		// user aborted the download
+		var neterr *net.OpError
+		if errors.As(err, &neterr) {
+			return
+		}
+
+		// nothing has been written yet; send back JSON
+		if !ww.written {
+			if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+				s.writeError(ctx, http.StatusNotFound, err)
+			} else {
+				s.writeError(ctx, http.StatusBadRequest, err)
+			}
+			return
+		}
+
+		// something has already been written: abort and write logs only
+		s.Log(logger.Error, err.Error())
+		return
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"time"
 
@@ -28,13 +27,33 @@ type parsedSegment struct {
 	duration time.Duration
 }
 
-func parseSegment(seg *recordstore.Segment) (*parsedSegment, error) {
-	f, err := os.Open(seg.Fpath)
+func parseSegment(recordFormat conf.RecordFormat, seg *recordstore.Segment) (*parsedSegment, error) {
+	f, err := seg.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
+	if recordFormat == conf.RecordFormatMPEGTS {
+		var init *fmp4.Init
+		init, err = segmentMPEGTSReadHeader(f)
+		if err != nil {
+			return nil, err
+		}
+
+		var duration time.Duration
+		duration, err = segmentMPEGTSReadDuration(f)
+		if err != nil {
+			return nil, err
+		}
+
+		return &parsedSegment{
+			start:    seg.Start,
+			init:     init,
+			duration: duration,
+		}, nil
+	}
+
 	init, duration, err := segmentFMP4ReadHeader(f)
 	if err != nil {
 		return nil, err
@@ -56,7 +75,7 @@ func parseSegment(seg *recordstore.Segment) (*parsedSegment, error) {
 	}, nil
 }
 
-func parseSegments(segments []*recordstore.Segment) ([]*parsedSegment, error) {
+func parseSegments(recordFormat conf.RecordFormat, segments []*recordstore.Segment) ([]*parsedSegment, error) {
 	parsed := make([]*parsedSegment, len(segments))
 	ch := make(chan error)
 
@@ -66,7 +85,7 @@ func parseSegments(segments []*recordstore.Segment) ([]*parsedSegment, error) {
 	for i, seg := range segments {
 		go func(i int, seg *recordstore.Segment) {
 			var err error
-			parsed[i], err = parseSegment(seg)
+			parsed[i], err = parseSegment(recordFormat, seg)
 			ch <- err
 		}(i, seg)
 	}
@@ -119,17 +138,44 @@ func parseAndConcatenate(
 	recordFormat conf.RecordFormat,
 	segments []*recordstore.Segment,
 ) ([]listEntry, error) {
-	if recordFormat == conf.RecordFormatFMP4 {
-		parsed, err := parseSegments(segments)
-		if err != nil {
-			return nil, err
+	parsed, err := parseSegments(recordFormat, segments)
+	if err != nil {
+		return nil, err
+	}
+
+	out := concatenateSegments(parsed)
+	return out, nil
+}
+
+// clipEntries removes the portions of entries that are outside the given range.
+func clipEntries(entries []listEntry, start *time.Time, end *time.Time) []listEntry {
+	if start != nil {
+		firstEntry := entries[0]
+
+		// when start is placed in a gap between the first and second segment,
+		// or when there's no second segment,
+		// the first segment is erroneously included with a negative duration.
+		// remove it.
+		if firstEntry.Start.Add(time.Duration(firstEntry.Duration)).Before(*start) {
+			entries = entries[1:]
+
+			if len(entries) == 0 {
+				return nil
+			}
+		} else if firstEntry.Start.Before(*start) {
+			entries[0].Duration -= listEntryDuration(start.Sub(firstEntry.Start))
+			entries[0].Start = *start
 		}
+	}
 
-		out := concatenateSegments(parsed)
-		return out, nil
+	if end != nil {
+		lastEntry := entries[len(entries)-1]
+		if lastEntry.Start.Add(time.Duration(lastEntry.Duration)).After(*end) {
+			entries[len(entries)-1].Duration = listEntryDuration(end.Sub(lastEntry.Start))
+		}
 	}
 
-	return nil, fmt.Errorf("MPEG-TS format is not supported yet")
+	return entries
 }
 
 func (s *Server) onList(ctx *gin.Context) {
@@ -169,7 +215,7 @@ func (s *Server) onList(ctx *gin.Context) {
 		end = &tmp
 	}
 
-	segments, err := recordstore.FindSegments(pathConf, pathName, start, end)
+	segments, err := recordstore.FindSegments(pathConf, pathName, start, end, s)
 	if err != nil {
 		if errors.Is(err, recordstore.ErrNoSegmentsFound) {
 			s.writeError(ctx, http.StatusNotFound, err)
@@ -185,31 +231,10 @@ func (s *Server) onList(ctx *gin.Context) {
 		return
 	}
 
-	if start != nil {
-		firstEntry := entries[0]
-
-		// when start is placed in a gap between the first and second segment,
-		// or when there's no second segment,
-		// the first segment is erroneously included with a negative duration.
-		// remove it.
-		if firstEntry.Start.Add(time.Duration(firstEntry.Duration)).Before(*start) {
-			entries = entries[1:]
-
-			if len(entries) == 0 {
-				s.writeError(ctx, http.StatusNotFound, recordstore.ErrNoSegmentsFound)
-				return
-			}
-		} else if firstEntry.Start.Before(*start) {
-			entries[0].Duration -= listEntryDuration(start.Sub(firstEntry.Start))
-			entries[0].Start = *start
-		}
-	}
-
-	if end != nil {
-		lastEntry := entries[len(entries)-1]
-		if lastEntry.Start.Add(time.Duration(lastEntry.Duration)).After(*end) {
-			entries[len(entries)-1].Duration = listEntryDuration(end.Sub(lastEntry.Start))
-		}
+	entries = clipEntries(entries, start, end)
+	if len(entries) == 0 {
+		s.writeError(ctx, http.StatusNotFound, recordstore.ErrNoSegmentsFound)
+		return
 	}
 
 	var scheme string
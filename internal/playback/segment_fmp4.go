@@ -16,6 +16,7 @@ import (
 const (
 	sampleFlagIsNonSyncSample = 1 << 16
 	concatenationTolerance    = 1 * time.Second
+	segmentFMP4BlockSize      = 4 * 1024
 )
 
 var errTerminated = errors.New("terminated")
@@ -530,3 +531,151 @@ func segmentFMP4MuxParts(
 
 	return segmentDuration, nil
 }
+
+// segmentFMP4BlockReader reads portions of a segment at arbitrary positions.
+// Data is read in blocks, in order to read a moof box
+// and the header of the following mdat box with a single request.
+type segmentFMP4BlockReader struct {
+	r    io.ReaderAt
+	size int64
+
+	buf    []byte
+	bufPos int64
+}
+
+func (b *segmentFMP4BlockReader) read(pos int64, n int64) ([]byte, error) {
+	if pos < b.bufPos || (pos+n) > (b.bufPos+int64(len(b.buf))) {
+		l := min(max(n, segmentFMP4BlockSize), b.size-pos)
+		if l < n {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		buf := make([]byte, l)
+		_, err := io.ReadFull(io.NewSectionReader(b.r, pos, l), buf)
+		if err != nil {
+			return nil, err
+		}
+
+		b.buf = buf
+		b.bufPos = pos
+	}
+
+	return b.buf[pos-b.bufPos : pos-b.bufPos+n], nil
+}
+
+// segmentFMP4ScanRandomAccess calls cb with the timestamp of each random access point
+// of a track of a fMP4 segment, until duration is reached.
+// Only moof boxes are read, while mdat boxes, that contain sample payloads, are skipped.
+// It returns the duration of the segment, computed in the same way as segmentFMP4MuxParts().
+func segmentFMP4ScanRandomAccess(
+	r readSeekerAt,
+	startDTS time.Duration,
+	duration time.Duration,
+	tracks []*fmp4.InitTrack,
+	trackID int,
+	cb func(dts int64),
+) (time.Duration, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	br := &segmentFMP4BlockReader{
+		r:    r,
+		size: size,
+	}
+
+	var segmentDuration time.Duration
+	terminated := false
+	pos := int64(0)
+
+	for !terminated && (pos+8) <= size {
+		var buf []byte
+		buf, err = br.read(pos, 8)
+		if err != nil {
+			return 0, err
+		}
+
+		boxSize := int64(uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3]))
+
+		// stop at incomplete boxes
+		if boxSize < 8 || (pos+boxSize) > size {
+			break
+		}
+
+		if bytes.Equal(buf[4:], []byte{'m', 'o', 'o', 'f'}) {
+			buf, err = br.read(pos, boxSize)
+			if err != nil {
+				return 0, err
+			}
+
+			var tfhd *amp4.Tfhd
+			var tfdt *amp4.Tfdt
+			var track *fmp4.InitTrack
+
+			_, err = amp4.ReadBoxStructure(bytes.NewReader(buf), func(h *amp4.ReadHandle) (any, error) {
+				switch h.BoxInfo.Type.String() {
+				case "moof", "traf":
+					return h.Expand()
+
+				case "tfhd":
+					box, _, err2 := h.ReadPayload()
+					if err2 != nil {
+						return nil, err2
+					}
+					tfhd = box.(*amp4.Tfhd)
+
+				case "tfdt":
+					box, _, err2 := h.ReadPayload()
+					if err2 != nil {
+						return nil, err2
+					}
+					tfdt = box.(*amp4.Tfdt)
+
+					track = findInitTrack(tracks, int(tfhd.TrackID))
+					if track == nil {
+						return nil, fmt.Errorf("invalid track ID: %v", tfhd.TrackID)
+					}
+
+				case "trun":
+					box, _, err2 := h.ReadPayload()
+					if err2 != nil {
+						return nil, err2
+					}
+					trun := box.(*amp4.Trun)
+
+					startDTSMP4 := durationGoToMp4(startDTS, track.TimeScale)
+					durationMP4 := durationGoToMp4(duration, track.TimeScale)
+					dts := int64(tfdt.BaseMediaDecodeTimeV1) + startDTSMP4
+
+					for _, e := range trun.Entries {
+						if dts >= durationMP4 {
+							terminated = true
+							break
+						}
+
+						if track.ID == trackID && (e.SampleFlags&sampleFlagIsNonSyncSample) == 0 {
+							cb(dts)
+						}
+
+						dts += int64(e.SampleDuration)
+					}
+
+					segmentElapsed := durationMp4ToGo(dts-startDTSMP4, track.TimeScale)
+
+					if segmentElapsed > segmentDuration {
+						segmentDuration = segmentElapsed
+					}
+				}
+				return nil, nil
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		pos += boxSize
+	}
+
+	return segmentDuration, nil
+}
@@ -1,6 +1,7 @@
 package playback
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	amp4 "github.com/abema/go-mp4"
 	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
 	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4/seekablebuffer"
 	mcodecs "github.com/bluenviron/mediacommon/v2/pkg/formats/mp4/codecs"
 	"github.com/bluenviron/mediamtx/internal/recordstore"
 	"github.com/bluenviron/mediamtx/internal/test"
@@ -425,3 +427,78 @@ func TestSegmentFMP4CanBeConcatenated(t *testing.T) {
 		})
 	}
 }
+
+type countingReader struct {
+	readSeekerAt
+	read int64
+}
+
+func (r *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.readSeekerAt.ReadAt(p, off)
+	r.read += int64(n)
+	return n, err
+}
+
+func TestSegmentFMP4ScanRandomAccess(t *testing.T) {
+	tracks := []*fmp4.InitTrack{
+		{
+			ID:        1,
+			TimeScale: 90000,
+			Codec: &mcodecs.H264{
+				SPS: test.FormatH264.SPS,
+				PPS: test.FormatH264.PPS,
+			},
+		},
+		{
+			ID:        2,
+			TimeScale: 48000,
+			Codec: &mcodecs.Opus{
+				ChannelCount: 2,
+			},
+		},
+	}
+
+	var buf seekablebuffer.Buffer
+
+	// 4 parts of 1 second, with a IDR every 2 seconds and large payloads
+	for i := range 4 {
+		parts := fmp4.Parts{{
+			SequenceNumber: uint32(i),
+			Tracks: []*fmp4.PartTrack{
+				{
+					ID:       1,
+					BaseTime: uint64(i) * 90000,
+					Samples: []*fmp4.Sample{{
+						Duration:        90000,
+						IsNonSyncSample: (i%2 != 0),
+						Payload:         bytes.Repeat([]byte{byte(i)}, 100000),
+					}},
+				},
+				{
+					ID:       2,
+					BaseTime: uint64(i) * 48000,
+					Samples: []*fmp4.Sample{{
+						Duration: 48000,
+						Payload:  bytes.Repeat([]byte{byte(i)}, 100000),
+					}},
+				},
+			},
+		}}
+		err := parts.Marshal(&buf)
+		require.NoError(t, err)
+	}
+
+	r := &countingReader{readSeekerAt: bytes.NewReader(buf.Bytes())}
+
+	var dtss []int64
+	segmentDuration, err := segmentFMP4ScanRandomAccess(r, -1*time.Second, 10*time.Second, tracks, 1,
+		func(dts int64) {
+			dtss = append(dtss, dts)
+		})
+	require.NoError(t, err)
+	require.Equal(t, 4*time.Second, segmentDuration)
+	require.Equal(t, []int64{-90000, 90000}, dtss)
+
+	// payloads are not read, and each part is read at once
+	require.Equal(t, int64(4*segmentFMP4BlockSize), r.read)
+}
@@ -0,0 +1,1162 @@
+package playback
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/ac3"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg1audio"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/opus"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	mcodecs "github.com/bluenviron/mediacommon/v2/pkg/formats/mp4/codecs"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+	tscodecs "github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts/codecs"
+)
+
+const (
+	mpegtsPacketSize       = 188
+	mpegtsTimestampMask    = 0x1FFFFFFFF // 33 bits
+	mpegtsClockRate        = 90000
+	mpegtsTailSize         = 1024 * 1024
+	mpegtsReaderBufSize    = 64 * 1024
+	mpegtsPESReaderBufSize = 16 * 1024
+	mpegtsHeaderMaxBuffer  = 32 * 1024 * 1024
+)
+
+func mpegtsToDuration(v int64) time.Duration {
+	return durationMp4ToGo(v, mpegtsClockRate)
+}
+
+func mpeg1audioChannelCount(cm mpeg1audio.ChannelMode) int {
+	switch cm {
+	case mpeg1audio.ChannelModeStereo,
+		mpeg1audio.ChannelModeJointStereo,
+		mpeg1audio.ChannelModeDualChannel:
+		return 2
+
+	default:
+		return 1
+	}
+}
+
+// segmentMPEGTSSample is a sample of a MPEG-TS segment, converted into the fMP4 format.
+type segmentMPEGTSSample struct {
+	track *fmp4.InitTrack
+	// DTS, relative to the first timestamp of the segment, in track time scale
+	dts             int64
+	ptsOffset       int32
+	isNonSyncSample bool
+	payload         []byte
+	duration        int64
+
+	// position of the PES packet that contains the sample, or -1 if unknown
+	pesPos int64
+	pid    uint16
+	// index of the sample inside the PES packet
+	index int
+}
+
+// segmentMPEGTSDemuxer reads samples of a MPEG-TS segment.
+// Tracks are converted into fMP4 tracks; tracks with unsupported codecs are skipped.
+type segmentMPEGTSDemuxer struct {
+	r io.Reader
+	// timestamp of the first PES packet of the segment, timestamps of samples are relative to it
+	firstTS int64
+	// when not nil, it provides the position of the PES packet of each sample
+	indexer  *mpegtsPESIndexer
+	onSample func(*segmentMPEGTSSample) error
+
+	reader      *mpegts.Reader
+	timeDec     mpegts.TimeDecoder
+	init        *fmp4.Init
+	initialized map[*fmp4.InitTrack]bool
+}
+
+func (d *segmentMPEGTSDemuxer) initialize() error {
+	d.reader = &mpegts.Reader{R: bufio.NewReaderSize(d.r, mpegtsReaderBufSize)}
+	err := d.reader.Initialize()
+	if err != nil {
+		return err
+	}
+
+	d.reader.OnDecodeError(func(_ error) {})
+
+	d.init = &fmp4.Init{}
+	d.initialized = make(map[*fmp4.InitTrack]bool)
+	d.timeDec.Decode(d.firstTS)
+
+	pids := make(map[uint16]struct{})
+
+	for _, tsTrack := range d.reader.Tracks() {
+		n := len(d.init.Tracks)
+		d.setupTrack(tsTrack)
+
+		if len(d.init.Tracks) != n {
+			pids[tsTrack.PID] = struct{}{}
+		}
+	}
+
+	if d.indexer != nil {
+		d.indexer.pids = pids
+	}
+
+	if len(d.init.Tracks) == 0 {
+		return fmt.Errorf("no supported tracks found")
+	}
+
+	return nil
+}
+
+// decodeDTS converts a MPEG-TS timestamp into a timestamp relative to the first one of the segment.
+func (d *segmentMPEGTSDemuxer) decodeDTS(dts int64) int64 {
+	return d.timeDec.Decode(dts)
+}
+
+// pesPosition returns the position of the PES packet with the given PID and timestamp.
+func (d *segmentMPEGTSDemuxer) pesPosition(pid uint16, ts int64) int64 {
+	if d.indexer == nil {
+		return -1
+	}
+	return d.indexer.find(pid, ts)
+}
+
+func (d *segmentMPEGTSDemuxer) addTrack(codec mcodecs.Codec, timeScale uint32, initialized bool) *fmp4.InitTrack {
+	track := &fmp4.InitTrack{
+		ID:        len(d.init.Tracks) + 1,
+		TimeScale: timeScale,
+		Codec:     codec,
+	}
+	d.init.Tracks = append(d.init.Tracks, track)
+	d.initialized[track] = initialized
+	return track
+}
+
+func (d *segmentMPEGTSDemuxer) setupTrack(tsTrack *mpegts.Track) {
+	switch tsCodec := tsTrack.Codec.(type) {
+	case *tscodecs.H264:
+		codec := &mcodecs.H264{}
+		track := d.addTrack(codec, mpegtsClockRate, false)
+
+		d.reader.OnDataH264(tsTrack, func(pts int64, dts int64, au [][]byte) error {
+			relDTS := d.decodeDTS(dts)
+
+			for _, nalu := range au {
+				switch h264.NALUType(nalu[0] & 0x1F) {
+				case h264.NALUTypeSPS:
+					if codec.SPS == nil {
+						codec.SPS = nalu
+					}
+
+				case h264.NALUTypePPS:
+					if codec.PPS == nil {
+						codec.PPS = nalu
+					}
+				}
+			}
+
+			if codec.SPS == nil || codec.PPS == nil {
+				return nil
+			}
+			d.initialized[track] = true
+
+			var sampl fmp4.Sample
+			err := sampl.FillH264(int32((pts-dts)&mpegtsTimestampMask), au)
+			if err != nil {
+				return err
+			}
+
+			return d.onSample(&segmentMPEGTSSample{
+				track:           track,
+				dts:             relDTS,
+				ptsOffset:       sampl.PTSOffset,
+				isNonSyncSample: sampl.IsNonSyncSample,
+				payload:         sampl.Payload,
+				pesPos:          d.pesPosition(tsTrack.PID, dts),
+				pid:             tsTrack.PID,
+			})
+		})
+
+	case *tscodecs.H265:
+		codec := &mcodecs.H265{}
+		track := d.addTrack(codec, mpegtsClockRate, false)
+
+		d.reader.OnDataH265(tsTrack, func(pts int64, dts int64, au [][]byte) error {
+			relDTS := d.decodeDTS(dts)
+
+			for _, nalu := range au {
+				switch h265.NALUType((nalu[0] >> 1) & 0b111111) {
+				case h265.NALUType_VPS_NUT:
+					if codec.VPS == nil {
+						codec.VPS = nalu
+					}
+
+				case h265.NALUType_SPS_NUT:
+					if codec.SPS == nil {
+						codec.SPS = nalu
+					}
+
+				case h265.NALUType_PPS_NUT:
+					if codec.PPS == nil {
+						codec.PPS = nalu
+					}
+				}
+			}
+
+			if codec.VPS == nil || codec.SPS == nil || codec.PPS == nil {
+				return nil
+			}
+			d.initialized[track] = true
+
+			var sampl fmp4.Sample
+			err := sampl.FillH265(int32((pts-dts)&mpegtsTimestampMask), au)
+			if err != nil {
+				return err
+			}
+
+			return d.onSample(&segmentMPEGTSSample{
+				track:           track,
+				dts:             relDTS,
+				ptsOffset:       sampl.PTSOffset,
+				isNonSyncSample: sampl.IsNonSyncSample,
+				payload:         sampl.Payload,
+				pesPos:          d.pesPosition(tsTrack.PID, dts),
+				pid:             tsTrack.PID,
+			})
+		})
+
+	case *tscodecs.MPEG4Audio:
+		sampleRate := tsCodec.Config.SampleRate
+		track := d.addTrack(&mcodecs.MPEG4Audio{Config: tsCodec.Config}, uint32(sampleRate), true)
+
+		d.reader.OnDataMPEG4Audio(tsTrack, func(pts int64, aus [][]byte) error {
+			dts := multiplyAndDivide(d.decodeDTS(pts), int64(sampleRate), mpegtsClockRate)
+
+			pesPos := d.pesPosition(tsTrack.PID, pts)
+
+			for i, au := range aus {
+				err := d.onSample(&segmentMPEGTSSample{
+					track:    track,
+					dts:      dts + int64(i)*mpeg4audio.SamplesPerAccessUnit,
+					payload:  au,
+					duration: mpeg4audio.SamplesPerAccessUnit,
+					pesPos:   pesPos,
+					pid:      tsTrack.PID,
+					index:    i,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+	case *tscodecs.Opus:
+		track := d.addTrack(&mcodecs.Opus{ChannelCount: tsCodec.ChannelCount}, 48000, true)
+
+		d.reader.OnDataOpus(tsTrack, func(pts int64, packets [][]byte) error {
+			dts := multiplyAndDivide(d.decodeDTS(pts), 48000, mpegtsClockRate)
+			pesPos := d.pesPosition(tsTrack.PID, pts)
+
+			for i, packet := range packets {
+				duration := opus.PacketDuration2(packet)
+
+				err := d.onSample(&segmentMPEGTSSample{
+					track:    track,
+					dts:      dts,
+					payload:  packet,
+					duration: duration,
+					pesPos:   pesPos,
+					pid:      tsTrack.PID,
+					index:    i,
+				})
+				if err != nil {
+					return err
+				}
+
+				dts += duration
+			}
+
+			return nil
+		})
+
+	case *tscodecs.MPEG1Audio:
+		// parameters are taken from the first frame
+		codec := &mcodecs.MPEG1Audio{}
+		track := d.addTrack(codec, 0, false)
+
+		d.reader.OnDataMPEG1Audio(tsTrack, func(pts int64, frames [][]byte) error {
+			var dts int64
+			pesPos := d.pesPosition(tsTrack.PID, pts)
+
+			for i, frame := range frames {
+				var h mpeg1audio.FrameHeader
+				err := h.Unmarshal(frame)
+				if err != nil {
+					return err
+				}
+
+				if !d.initialized[track] {
+					codec.SampleRate = h.SampleRate
+					codec.ChannelCount = mpeg1audioChannelCount(h.ChannelMode)
+					track.TimeScale = uint32(h.SampleRate)
+					d.initialized[track] = true
+				}
+
+				if i == 0 {
+					dts = multiplyAndDivide(d.decodeDTS(pts), int64(track.TimeScale), mpegtsClockRate)
+				}
+
+				err = d.onSample(&segmentMPEGTSSample{
+					track:    track,
+					dts:      dts,
+					payload:  frame,
+					duration: int64(h.SampleCount()),
+					pesPos:   pesPos,
+					pid:      tsTrack.PID,
+					index:    i,
+				})
+				if err != nil {
+					return err
+				}
+
+				dts += int64(h.SampleCount())
+			}
+
+			return nil
+		})
+
+	case *tscodecs.AC3:
+		// parameters are taken from the first frame
+		codec := &mcodecs.AC3{}
+		track := d.addTrack(codec, 0, false)
+
+		d.reader.OnDataAC3(tsTrack, func(pts int64, frame []byte) error {
+			if !d.initialized[track] {
+				var syncInfo ac3.SyncInfo
+				err := syncInfo.Unmarshal(frame)
+				if err != nil {
+					return err
+				}
+
+				var bsi ac3.BSI
+				err = bsi.Unmarshal(frame[5:])
+				if err != nil {
+					return err
+				}
+
+				codec.SampleRate = syncInfo.SampleRate()
+				codec.ChannelCount = bsi.ChannelCount()
+				codec.Fscod = syncInfo.Fscod
+				codec.Bsid = bsi.Bsid
+				codec.Bsmod = bsi.Bsmod
+				codec.Acmod = bsi.Acmod
+				codec.LfeOn = bsi.LfeOn
+				codec.BitRateCode = syncInfo.Frmsizecod >> 1
+				track.TimeScale = uint32(codec.SampleRate)
+				d.initialized[track] = true
+			}
+
+			return d.onSample(&segmentMPEGTSSample{
+				track:    track,
+				dts:      multiplyAndDivide(d.decodeDTS(pts), int64(track.TimeScale), mpegtsClockRate),
+				payload:  frame,
+				duration: ac3.SamplesPerFrame,
+				pesPos:   d.pesPosition(tsTrack.PID, pts),
+				pid:      tsTrack.PID,
+			})
+		})
+	}
+}
+
+func (d *segmentMPEGTSDemuxer) allInitialized() bool {
+	for _, v := range d.initialized {
+		if !v {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *segmentMPEGTSDemuxer) read() error {
+	return d.reader.Read()
+}
+
+func multiplyAndDivide(v, m, d int64) int64 {
+	secs := v / d
+	dec := v % d
+	return (secs*m + dec*m/d)
+}
+
+// segmentMPEGTSReadHeader reads the tracks of a MPEG-TS segment.
+// Since codec parameters are transmitted in-band, the segment is read until they are found.
+func segmentMPEGTSReadHeader(r io.ReadSeeker) (*fmp4.Init, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &segmentMPEGTSDemuxer{
+		r:        io.LimitReader(r, mpegtsHeaderMaxBuffer),
+		onSample: func(*segmentMPEGTSSample) error { return nil },
+	}
+	err = d.initialize()
+	if err != nil {
+		return nil, err
+	}
+
+	for !d.allInitialized() {
+		err = d.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("unable to find codec parameters")
+			}
+			return nil, err
+		}
+	}
+
+	return d.init, nil
+}
+
+func mpegtsPacketPID(pkt []byte) uint16 {
+	return uint16(pkt[1]&0x1F)<<8 | uint16(pkt[2])
+}
+
+// mpegtsPacketPayload returns the payload of a MPEG-TS packet.
+func mpegtsPacketPayload(pkt []byte) []byte {
+	adaptationFieldControl := (pkt[3] >> 4) & 0x03
+	if (adaptationFieldControl & 0x01) == 0 {
+		return nil
+	}
+
+	pos := 4
+	if (adaptationFieldControl & 0x02) != 0 {
+		pos += 1 + int(pkt[4])
+	}
+
+	if pos >= len(pkt) {
+		return nil
+	}
+
+	return pkt[pos:]
+}
+
+// mpegtsPESTimestamp returns the DTS of the PES packet that starts in a MPEG-TS packet, if any.
+func mpegtsPESTimestamp(pkt []byte) (int64, bool) {
+	if pkt[0] != 0x47 || (pkt[1]&0x40) == 0 {
+		return 0, false
+	}
+
+	pes := mpegtsPacketPayload(pkt)
+	if len(pes) < 14 {
+		return 0, false
+	}
+
+	if !bytes.Equal(pes[:3], []byte{0, 0, 1}) {
+		return 0, false
+	}
+
+	switch pes[3] {
+	case 0xBC, 0xBE, 0xBF, 0xF0, 0xF1, 0xF2, 0xF8, 0xFF: // streams without optional header
+		return 0, false
+	}
+
+	ptsDTSIndicator := pes[7] >> 6
+
+	parseTimestamp := func(b []byte) int64 {
+		return int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 |
+			int64(b[3])<<7 | int64(b[4]>>1)
+	}
+
+	switch ptsDTSIndicator {
+	case 2:
+		return parseTimestamp(pes[9:14]), true
+
+	case 3:
+		if len(pes) < 19 {
+			return 0, false
+		}
+		return parseTimestamp(pes[14:19]), true
+	}
+
+	return 0, false
+}
+
+// mpegtsRandomAccessTimestamp returns the DTS of the PES packet that starts in a MPEG-TS packet,
+// if the packet is a random access point.
+// When the segment contains video, only video packets are taken into consideration.
+func mpegtsRandomAccessTimestamp(pkt []byte, hasVideo bool) (int64, bool) {
+	// random_access_indicator
+	if (pkt[3]&0x20) == 0 || pkt[4] == 0 || (pkt[5]&0x40) == 0 {
+		return 0, false
+	}
+
+	ts, ok := mpegtsPESTimestamp(pkt)
+	if !ok {
+		return 0, false
+	}
+
+	if hasVideo {
+		streamID := mpegtsPacketPayload(pkt)[3]
+		if streamID < 0xE0 || streamID > 0xEF {
+			return 0, false
+		}
+	}
+
+	return ts, true
+}
+
+// mpegtsScanPackets calls cb with each packet in r, and its position relative to the beginning of r.
+func mpegtsScanPackets(r io.Reader, cb func(int64, []byte) bool) error {
+	br := bufio.NewReaderSize(r, mpegtsReaderBufSize)
+	buf := make([]byte, mpegtsPacketSize)
+	pos := int64(0)
+
+	for {
+		_, err := io.ReadFull(br, buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+
+		if !cb(pos, buf) {
+			return nil
+		}
+
+		pos += mpegtsPacketSize
+	}
+}
+
+// mpegtsScanTimestamps calls cb with the position and the timestamp of each PES packet in r.
+func mpegtsScanTimestamps(r io.Reader, cb func(int64, int64) bool) error {
+	return mpegtsScanPackets(r, func(pos int64, pkt []byte) bool {
+		if ts, ok := mpegtsPESTimestamp(pkt); ok {
+			return cb(pos, ts)
+		}
+		return true
+	})
+}
+
+// segmentMPEGTSReadDuration computes the duration of a MPEG-TS segment,
+// by comparing the timestamp of the first PES packet with the greatest one at the end of the segment.
+func segmentMPEGTSReadDuration(r io.ReadSeeker) (time.Duration, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	first := int64(-1)
+
+	err = mpegtsScanTimestamps(r, func(_ int64, ts int64) bool {
+		first = ts
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if first < 0 {
+		return 0, fmt.Errorf("no timestamps found")
+	}
+
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	tailStart := max(size-mpegtsTailSize, 0)
+	tailStart -= tailStart % mpegtsPacketSize
+
+	_, err = r.Seek(tailStart, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	var maxDiff int64
+
+	err = mpegtsScanTimestamps(r, func(_ int64, ts int64) bool {
+		diff := (ts - first) & mpegtsTimestampMask
+		// ignore timestamps that precede the first one
+		if diff < mpegtsTimestampMask/2 && diff > maxDiff {
+			maxDiff = diff
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return mpegtsToDuration(maxDiff), nil
+}
+
+// segmentMPEGTSSeek returns the position of the last random access point
+// whose timestamp, relative to firstTS, is not greater than target.
+// Since random access points are sorted by timestamp, they are found with a binary search.
+func segmentMPEGTSSeek(r io.ReaderAt, size int64, firstTS int64, target int64, hasVideo bool) (int64, error) {
+	// finds the first random access point after a packet
+	find := func(n int64) (int64, int64, error) {
+		pos := int64(-1)
+		var ts int64
+
+		err := mpegtsScanPackets(io.NewSectionReader(r, n*mpegtsPacketSize, size-n*mpegtsPacketSize), func(pktPos int64, pkt []byte) bool {
+			var ok bool
+			ts, ok = mpegtsRandomAccessTimestamp(pkt, hasVideo)
+			if ok {
+				pos = n*mpegtsPacketSize + pktPos
+				return false
+			}
+			return true
+		})
+		return pos, ts, err
+	}
+
+	ret := int64(0)
+	lo := int64(0)
+	hi := size / mpegtsPacketSize
+
+	for lo < hi {
+		mid := (lo + hi) / 2
+
+		pos, ts, err := find(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		diff := (ts - firstTS) & mpegtsTimestampMask
+
+		// timestamps that precede the first one are considered negative
+		if pos >= 0 && (diff <= target || diff > mpegtsTimestampMask/2) {
+			ret = pos
+			lo = pos/mpegtsPacketSize + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	return ret, nil
+}
+
+// mpegtsPESPosition is the position of a PES packet in a MPEG-TS segment.
+type mpegtsPESPosition struct {
+	pos int64
+	ts  int64
+}
+
+// mpegtsPESIndexer is a reader that records the position of the PES packets that are read through it.
+type mpegtsPESIndexer struct {
+	r   io.Reader
+	pos int64
+
+	buf       []byte
+	pids      map[uint16]struct{}
+	positions map[uint16][]mpegtsPESPosition
+}
+
+func (x *mpegtsPESIndexer) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+
+	for buf := p[:n]; len(buf) > 0; {
+		if len(x.buf) == 0 && len(buf) >= mpegtsPacketSize {
+			x.processPacket(buf[:mpegtsPacketSize])
+			buf = buf[mpegtsPacketSize:]
+			continue
+		}
+
+		l := min(mpegtsPacketSize-len(x.buf), len(buf))
+		x.buf = append(x.buf, buf[:l]...)
+		buf = buf[l:]
+
+		if len(x.buf) == mpegtsPacketSize {
+			x.processPacket(x.buf)
+			x.buf = x.buf[:0]
+		}
+	}
+
+	return n, err
+}
+
+func (x *mpegtsPESIndexer) processPacket(pkt []byte) {
+	pos := x.pos
+	x.pos += mpegtsPacketSize
+
+	ts, ok := mpegtsPESTimestamp(pkt)
+	if !ok {
+		return
+	}
+
+	pid := mpegtsPacketPID(pkt)
+
+	// skip tracks that are not decoded
+	if x.pids != nil {
+		if _, ok = x.pids[pid]; !ok {
+			return
+		}
+	}
+
+	if x.positions == nil {
+		x.positions = make(map[uint16][]mpegtsPESPosition)
+	}
+	x.positions[pid] = append(x.positions[pid], mpegtsPESPosition{pos: pos, ts: ts})
+}
+
+// find returns the position of the PES packet with the given PID and timestamp, or -1 if not found.
+// Since PES packets are decoded in order, positions of previous PES packets are discarded.
+func (x *mpegtsPESIndexer) find(pid uint16, ts int64) int64 {
+	positions := x.positions[pid]
+
+	for i, p := range positions {
+		if p.ts == ts {
+			x.positions[pid] = positions[i+1:]
+			return p.pos
+		}
+	}
+
+	return -1
+}
+
+// mpegtsReadPES reads the data of the PES packet with the given PID that starts at the given position.
+func mpegtsReadPES(r io.ReaderAt, size int64, pid uint16, pos int64) ([]byte, error) {
+	var pes []byte
+
+	err := mpegtsScanPackets(io.NewSectionReader(r, pos, size-pos), func(_ int64, pkt []byte) bool {
+		if mpegtsPacketPID(pkt) != pid {
+			return true
+		}
+
+		// PES packets end when the next one starts
+		start := (pkt[1] & 0x40) != 0
+		if start != (pes == nil) {
+			return false
+		}
+
+		pes = append(pes, mpegtsPacketPayload(pkt)...)
+
+		// or when their declared length is reached
+		if len(pes) >= 6 {
+			l := int(pes[4])<<8 | int(pes[5])
+			if l != 0 && len(pes) >= 6+l {
+				pes = pes[:6+l]
+				return false
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pes) < 9 || len(pes) < 9+int(pes[8]) {
+		return nil, fmt.Errorf("PES packet not found")
+	}
+
+	return pes[9+int(pes[8]):], nil
+}
+
+// mpegtsOpusPackets splits the data of an Opus PES packet into packets.
+func mpegtsOpusPackets(data []byte) ([][]byte, error) {
+	var packets [][]byte
+
+	for len(data) > 0 {
+		// control header prefix
+		if len(data) < 3 || data[0] != 0x7F || (data[1]&0xE0) != 0xE0 {
+			return nil, fmt.Errorf("invalid control header")
+		}
+
+		flags := data[1]
+		pos := 2
+		size := 0
+
+		for {
+			if pos >= len(data) {
+				return nil, fmt.Errorf("invalid control header")
+			}
+			size += int(data[pos])
+			pos++
+			if data[pos-1] != 255 {
+				break
+			}
+		}
+
+		if (flags & 0x10) != 0 { // start trim
+			pos += 2
+		}
+		if (flags & 0x08) != 0 { // end trim
+			pos += 2
+		}
+		if (flags & 0x04) != 0 { // control extension
+			if pos >= len(data) {
+				return nil, fmt.Errorf("invalid control header")
+			}
+			pos += 1 + int(data[pos])
+		}
+
+		if len(data) < pos+size {
+			return nil, fmt.Errorf("buffer is too short")
+		}
+
+		packets = append(packets, data[pos:pos+size])
+		data = data[pos+size:]
+	}
+
+	return packets, nil
+}
+
+// mpegtsSamplePayloads converts the data of a PES packet into payloads of fMP4 samples,
+// in the same way the MPEG-TS demuxer does.
+func mpegtsSamplePayloads(codec mcodecs.Codec, data []byte) ([][]byte, error) {
+	switch codec.(type) {
+	case *mcodecs.H264, *mcodecs.H265:
+		var au h264.AnnexB
+		err := au.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+
+		var sampl fmp4.Sample
+
+		if _, ok := codec.(*mcodecs.H264); ok {
+			if au[0][0] == byte(h264.NALUTypeAccessUnitDelimiter) {
+				au = au[1:]
+			}
+			err = sampl.FillH264(0, au)
+		} else {
+			if au[0][0] == byte(h265.NALUType_AUD_NUT<<1) {
+				au = au[1:]
+			}
+			err = sampl.FillH265(0, au)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return [][]byte{sampl.Payload}, nil
+
+	case *mcodecs.MPEG4Audio:
+		var pkts mpeg4audio.ADTSPackets
+		err := pkts.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+
+		aus := make([][]byte, len(pkts))
+		for i, pkt := range pkts {
+			aus[i] = pkt.AU
+		}
+		return aus, nil
+
+	case *mcodecs.Opus:
+		return mpegtsOpusPackets(data)
+
+	case *mcodecs.MPEG1Audio:
+		var frames [][]byte
+
+		for len(data) > 0 {
+			var h mpeg1audio.FrameHeader
+			err := h.Unmarshal(data)
+			if err != nil {
+				return nil, err
+			}
+
+			fl := h.FrameLen()
+			if len(data) < fl {
+				return nil, fmt.Errorf("buffer is too short")
+			}
+
+			frames = append(frames, data[:fl])
+			data = data[fl:]
+		}
+		return frames, nil
+
+	default:
+		return [][]byte{data}, nil
+	}
+}
+
+// segmentMPEGTSReadPayload reads the payload of a sample from the PES packet that contains it.
+func segmentMPEGTSReadPayload(
+	r io.ReaderAt,
+	size int64,
+	sample *segmentMPEGTSSample,
+	payloadSize uint32,
+) ([]byte, error) {
+	data, err := mpegtsReadPES(r, size, sample.pid, sample.pesPos)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads, err := mpegtsSamplePayloads(sample.track.Codec, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if sample.index >= len(payloads) || len(payloads[sample.index]) != int(payloadSize) {
+		return nil, fmt.Errorf("sample not found in PES packet")
+	}
+
+	return payloads[sample.index], nil
+}
+
+// segmentMPEGTSMuxSamples writes samples of a MPEG-TS segment into a muxer.
+// Tracks of the segment must match the ones of init.
+// Reading starts from the random access point that precedes from,
+// and payloads are read lazily from the segment.
+func segmentMPEGTSMuxSamples(
+	r readSeekerAt,
+	startDTS time.Duration,
+	from time.Duration,
+	duration time.Duration,
+	init *fmp4.Init,
+	m muxer,
+) (time.Duration, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	firstPos := int64(-1)
+	var firstTS int64
+
+	err = mpegtsScanTimestamps(io.NewSectionReader(r, 0, size), func(pos int64, ts int64) bool {
+		firstPos = pos
+		firstTS = ts
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if firstPos < 0 {
+		return 0, fmt.Errorf("no timestamps found")
+	}
+
+	start := int64(0)
+
+	if from > startDTS {
+		hasVideo := false
+		for _, track := range init.Tracks {
+			if track.Codec.IsVideo() {
+				hasVideo = true
+			}
+		}
+
+		start, err = segmentMPEGTSSeek(r, size, firstTS, durationGoToMp4(from-startDTS, mpegtsClockRate), hasVideo)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	indexer := &mpegtsPESIndexer{
+		r:   io.NewSectionReader(r, start, size-start),
+		pos: start,
+	}
+
+	var dr io.Reader = indexer
+
+	// PAT and PMT are at the beginning of the segment
+	if start != 0 {
+		dr = io.MultiReader(io.NewSectionReader(r, 0, firstPos), indexer)
+	}
+
+	type trackState struct {
+		started      bool
+		lastDTS      int64
+		lastDuration int64
+		done         bool
+	}
+
+	states := make(map[int]*trackState)
+	for _, track := range init.Tracks {
+		states[track.ID] = &trackState{}
+	}
+	remaining := len(init.Tracks)
+
+	d := &segmentMPEGTSDemuxer{
+		r:       dr,
+		firstTS: firstTS,
+		indexer: indexer,
+		onSample: func(sample *segmentMPEGTSSample) error {
+			st := states[sample.track.ID]
+			if st == nil || st.done {
+				return nil
+			}
+
+			timeScale := sample.track.TimeScale
+			dts := sample.dts + durationGoToMp4(startDTS, timeScale)
+
+			if dts >= durationGoToMp4(duration, timeScale) {
+				st.done = true
+				remaining--
+				if remaining == 0 {
+					return errTerminated
+				}
+				return nil
+			}
+
+			m.setTrack(sample.track.ID)
+
+			payloadSize := uint32(len(sample.payload))
+			var getPayload func() ([]byte, error)
+
+			if sample.pesPos >= 0 {
+				sample.payload = nil
+				getPayload = func() ([]byte, error) {
+					return segmentMPEGTSReadPayload(r, size, sample, payloadSize)
+				}
+			} else {
+				payload := sample.payload
+				getPayload = func() ([]byte, error) {
+					return payload, nil
+				}
+			}
+
+			err2 := m.writeSample(
+				dts,
+				sample.ptsOffset,
+				sample.isNonSyncSample,
+				payloadSize,
+				getPayload,
+			)
+			if err2 != nil {
+				return err2
+			}
+
+			if sample.duration != 0 {
+				st.lastDuration = sample.duration
+			} else if st.started {
+				st.lastDuration = dts - st.lastDTS
+			}
+
+			st.started = true
+			st.lastDTS = dts
+
+			return nil
+		},
+	}
+	err = d.initialize()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		err = d.read()
+		if err != nil {
+			break
+		}
+	}
+
+	if !errors.Is(err, errTerminated) && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	var segmentDuration time.Duration
+
+	for _, track := range d.init.Tracks {
+		st := states[track.ID]
+		if st == nil || !st.started {
+			continue
+		}
+
+		finalDTS := st.lastDTS + st.lastDuration
+
+		m.setTrack(track.ID)
+		m.writeFinalDTS(finalDTS)
+
+		segmentElapsed := durationMp4ToGo(finalDTS, track.TimeScale) - startDTS
+		if segmentElapsed > segmentDuration {
+			segmentDuration = segmentElapsed
+		}
+	}
+
+	return segmentDuration, nil
+}
+
+// segmentMPEGTSScanRandomAccess calls cb with the timestamp of each random access point
+// of a MPEG-TS segment, in the time scale of track, until duration is reached.
+// Only headers of MPEG-TS and PES packets are parsed, and reading starts
+// from the random access point that precedes from.
+// It returns the duration of the segment.
+func segmentMPEGTSScanRandomAccess(
+	r readSeekerAt,
+	startDTS time.Duration,
+	from time.Duration,
+	duration time.Duration,
+	init *fmp4.Init,
+	track *fmp4.InitTrack,
+	cb func(dts int64),
+) (time.Duration, error) {
+	segmentDuration, err := segmentMPEGTSReadDuration(r)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	firstTS := int64(-1)
+
+	err = mpegtsScanTimestamps(io.NewSectionReader(r, 0, size), func(_ int64, ts int64) bool {
+		firstTS = ts
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	hasVideo := false
+	for _, t := range init.Tracks {
+		if t.Codec.IsVideo() {
+			hasVideo = true
+		}
+	}
+
+	start := int64(0)
+
+	if from > startDTS {
+		start, err = segmentMPEGTSSeek(r, size, firstTS, durationGoToMp4(from-startDTS, mpegtsClockRate), hasVideo)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	startDTSMP4 := durationGoToMp4(startDTS, track.TimeScale)
+	durationMP4 := durationGoToMp4(duration, track.TimeScale)
+
+	err = mpegtsScanPackets(io.NewSectionReader(r, start, size-start), func(_ int64, pkt []byte) bool {
+		ts, ok := mpegtsRandomAccessTimestamp(pkt, hasVideo)
+		if !ok {
+			return true
+		}
+
+		diff := (ts - firstTS) & mpegtsTimestampMask
+
+		// timestamps that precede the first one are considered negative
+		if diff > mpegtsTimestampMask/2 {
+			diff -= mpegtsTimestampMask + 1
+		}
+
+		dts := multiplyAndDivide(diff, int64(track.TimeScale), mpegtsClockRate) + startDTSMP4
+		if dts >= durationMP4 {
+			return false
+		}
+
+		cb(dts)
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return segmentDuration, nil
+}
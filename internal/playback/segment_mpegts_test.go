@@ -0,0 +1,295 @@
+package playback
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	mcodecs "github.com/bluenviron/mediacommon/v2/pkg/formats/mp4/codecs"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+	tscodecs "github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts/codecs"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+var testMPEG4AudioConfig = mpeg4audio.AudioSpecificConfig{
+	Type:         2,
+	SampleRate:   44100,
+	ChannelCount: 2,
+}
+
+// writeSegmentMPEGTS writes a MPEG-TS segment with 1.5 seconds of video and audio.
+// A video frame is written every 0.5 seconds, with a IDR every second.
+func writeSegmentMPEGTS(t *testing.T, fpath string, baseTS int64) {
+	var buf bytes.Buffer
+
+	videoTrack := &mpegts.Track{Codec: &tscodecs.H264{}}
+	audioTrack := &mpegts.Track{Codec: &tscodecs.MPEG4Audio{Config: testMPEG4AudioConfig}}
+
+	w := &mpegts.Writer{W: &buf, Tracks: []*mpegts.Track{videoTrack, audioTrack}}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	for i := int64(0); i < 4; i++ {
+		ts := (baseTS + i*45000) & mpegtsTimestampMask
+
+		var au [][]byte
+		if i%2 == 0 {
+			au = [][]byte{test.FormatH264.SPS, test.FormatH264.PPS, {5, byte(i)}}
+		} else {
+			au = [][]byte{{1, byte(i)}}
+		}
+
+		err = w.WriteH264(videoTrack, ts, ts, au)
+		require.NoError(t, err)
+
+		err = w.WriteMPEG4Audio(audioTrack, ts, [][]byte{{1, 2, 3, byte(i)}})
+		require.NoError(t, err)
+	}
+
+	err = os.WriteFile(fpath, buf.Bytes(), 0o644)
+	require.NoError(t, err)
+}
+
+func TestSegmentMPEGTSReadHeader(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-playback")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// timestamps wrap around in the middle of the segment
+	fpath := dir + "/segment.ts"
+	writeSegmentMPEGTS(t, fpath, mpegtsTimestampMask-50000)
+
+	f, err := os.Open(fpath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	init, err := segmentMPEGTSReadHeader(f)
+	require.NoError(t, err)
+
+	require.Equal(t, &fmp4.Init{
+		Tracks: []*fmp4.InitTrack{
+			{
+				ID:        1,
+				TimeScale: 90000,
+				Codec: &mcodecs.H264{
+					SPS: test.FormatH264.SPS,
+					PPS: test.FormatH264.PPS,
+				},
+			},
+			{
+				ID:        2,
+				TimeScale: 44100,
+				Codec: &mcodecs.MPEG4Audio{
+					Config: testMPEG4AudioConfig,
+				},
+			},
+		},
+	}, init)
+
+	duration, err := segmentMPEGTSReadDuration(f)
+	require.NoError(t, err)
+	require.Equal(t, 1500*time.Millisecond, duration)
+}
+
+type testMuxerSample struct {
+	trackID         int
+	dts             int64
+	isNonSyncSample bool
+	payload         []byte
+}
+
+// testMuxer stores samples, reading their payload after all samples have been written.
+type testMuxer struct {
+	curTrack    int
+	samples     []*testMuxerSample
+	getPayloads []func() ([]byte, error)
+}
+
+func (m *testMuxer) writeInit(_ *fmp4.Init) {}
+
+func (m *testMuxer) setTrack(trackID int) {
+	m.curTrack = trackID
+}
+
+func (m *testMuxer) writeSample(
+	dts int64,
+	_ int32,
+	isNonSyncSample bool,
+	_ uint32,
+	getPayload func() ([]byte, error),
+) error {
+	m.samples = append(m.samples, &testMuxerSample{
+		trackID:         m.curTrack,
+		dts:             dts,
+		isNonSyncSample: isNonSyncSample,
+	})
+	m.getPayloads = append(m.getPayloads, getPayload)
+	return nil
+}
+
+func (m *testMuxer) writeFinalDTS(_ int64) {}
+
+func (m *testMuxer) flush() error {
+	for i, getPayload := range m.getPayloads {
+		payload, err := getPayload()
+		if err != nil {
+			return err
+		}
+		m.samples[i].payload = payload
+	}
+	return nil
+}
+
+func TestSegmentMPEGTSMuxSamples(t *testing.T) {
+	var buf bytes.Buffer
+
+	videoTrack := &mpegts.Track{Codec: &tscodecs.H264{}}
+	audioTrack := &mpegts.Track{Codec: &tscodecs.MPEG4Audio{Config: testMPEG4AudioConfig}}
+
+	w := &mpegts.Writer{W: &buf, Tracks: []*mpegts.Track{videoTrack, audioTrack}}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	// 3 seconds of video at 10 fps with a IDR every second,
+	// with frames that span multiple MPEG-TS packets.
+	for i := range 30 {
+		ts := int64(90000 + i*9000)
+		frame := bytes.Repeat([]byte{byte(i)}, 1000)
+
+		var au [][]byte
+		if i%10 == 0 {
+			au = [][]byte{test.FormatH264.SPS, test.FormatH264.PPS, append([]byte{5}, frame...)}
+		} else {
+			au = [][]byte{append([]byte{1}, frame...)}
+		}
+
+		err = w.WriteH264(videoTrack, ts, ts, au)
+		require.NoError(t, err)
+
+		err = w.WriteMPEG4Audio(audioTrack, ts, [][]byte{{1, 2, byte(i), 0}, {1, 2, byte(i), 1}})
+		require.NoError(t, err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+
+	init, err := segmentMPEGTSReadHeader(r)
+	require.NoError(t, err)
+
+	m := &testMuxer{}
+	_, err = segmentMPEGTSMuxSamples(r, 0, 0, 10*time.Second, init, m)
+	require.NoError(t, err)
+
+	err = m.flush()
+	require.NoError(t, err)
+	require.Len(t, m.samples, 90)
+
+	require.Equal(t, &testMuxerSample{
+		trackID:         1,
+		dts:             9000,
+		isNonSyncSample: true,
+		payload:         append([]byte{0, 0, 3, 0xe9, 1}, bytes.Repeat([]byte{1}, 1000)...),
+	}, m.samples[3])
+
+	require.Equal(t, &testMuxerSample{
+		trackID: 2,
+		dts:     4410 + 1024,
+		payload: []byte{1, 2, 1, 1},
+	}, m.samples[5])
+
+	// reading starts from the IDR that precedes the start position
+	m = &testMuxer{}
+	_, err = segmentMPEGTSMuxSamples(r, -1500*time.Millisecond, 0, 10*time.Second, init, m)
+	require.NoError(t, err)
+
+	err = m.flush()
+	require.NoError(t, err)
+
+	require.Equal(t, 1, m.samples[0].trackID)
+	require.Equal(t, int64(-45000), m.samples[0].dts)
+	require.False(t, m.samples[0].isNonSyncSample)
+	require.Equal(t, byte(10), m.samples[0].payload[len(m.samples[0].payload)-1])
+	require.Len(t, m.samples, 60)
+}
+
+func TestSegmentMPEGTSScanRandomAccess(t *testing.T) {
+	var buf bytes.Buffer
+
+	videoTrack := &mpegts.Track{Codec: &tscodecs.H264{}}
+	audioTrack := &mpegts.Track{Codec: &tscodecs.MPEG4Audio{Config: testMPEG4AudioConfig}}
+
+	w := &mpegts.Writer{W: &buf, Tracks: []*mpegts.Track{videoTrack, audioTrack}}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	// 3 seconds of video at 10 fps with a IDR every second
+	for i := range 30 {
+		ts := int64(90000 + i*9000)
+
+		var au [][]byte
+		if i%10 == 0 {
+			au = [][]byte{test.FormatH264.SPS, test.FormatH264.PPS, {5, byte(i)}}
+		} else {
+			au = [][]byte{{1, byte(i)}}
+		}
+
+		err = w.WriteH264(videoTrack, ts, ts, au)
+		require.NoError(t, err)
+
+		err = w.WriteMPEG4Audio(audioTrack, ts, [][]byte{{1, 2, byte(i), 0}})
+		require.NoError(t, err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+
+	init, err := segmentMPEGTSReadHeader(r)
+	require.NoError(t, err)
+
+	var dtss []int64
+	segmentDuration, err := segmentMPEGTSScanRandomAccess(r, -1500*time.Millisecond, 0, 1*time.Second,
+		init, init.Tracks[0], func(dts int64) {
+			dtss = append(dtss, dts)
+		})
+	require.NoError(t, err)
+	require.Equal(t, 2900*time.Millisecond, segmentDuration)
+
+	// reading starts from the IDR that precedes the start position
+	require.Equal(t, []int64{-45000, 45000}, dtss)
+}
+
+func TestSegmentMPEGTSMuxSamplesOpus(t *testing.T) {
+	var buf bytes.Buffer
+
+	track := &mpegts.Track{Codec: &tscodecs.Opus{ChannelCount: 2}}
+
+	w := &mpegts.Writer{W: &buf, Tracks: []*mpegts.Track{track}}
+	err := w.Initialize()
+	require.NoError(t, err)
+
+	for i := range 3 {
+		err = w.WriteOpus(track, int64(90000+i*1800), [][]byte{
+			append([]byte{0xF8}, bytes.Repeat([]byte{byte(i)}, 300)...),
+			{0xF8, byte(i)},
+		})
+		require.NoError(t, err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+
+	init, err := segmentMPEGTSReadHeader(r)
+	require.NoError(t, err)
+
+	m := &testMuxer{}
+	_, err = segmentMPEGTSMuxSamples(r, 0, 0, 10*time.Second, init, m)
+	require.NoError(t, err)
+
+	err = m.flush()
+	require.NoError(t, err)
+	require.Len(t, m.samples, 6)
+
+	require.Equal(t, append([]byte{0xF8}, bytes.Repeat([]byte{2}, 300)...), m.samples[4].payload)
+	require.Equal(t, []byte{0xF8, 2}, m.samples[5].payload)
+}
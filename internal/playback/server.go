@@ -33,8 +33,9 @@ type Server struct {
 	AuthManager    serverAuthManager
 	Parent         logger.Writer
 
-	httpServer *httpp.Server
-	mutex      sync.RWMutex
+	httpServer   *httpp.Server
+	mutex        sync.RWMutex
+	hlsTimespans hlsTimespanCache
 }
 
 // Initialize initializes Server.
@@ -46,6 +47,7 @@ func (s *Server) Initialize() error {
 
 	router.GET("/list", s.onList)
 	router.GET("/get", s.onGet)
+	router.GET("/export", s.onExport)
 
 	s.httpServer = &httpp.Server{
 		Address:      s.Address,
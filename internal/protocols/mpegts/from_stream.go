@@ -38,6 +38,13 @@ func FromStream(
 	var w *mcmpegts.Writer
 	var tracks []*mcmpegts.Track
 
+	// sconn is nil when writing to a pipe
+	setWriteDeadline := func() {
+		if sconn != nil {
+			sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		}
+	}
+
 	addTrack := func(
 		media *description.Media,
 		forma format.Format,
@@ -77,7 +84,7 @@ func FromStream(
 								usePTSAsDTS = true
 								// Use PTS as DTS approximation for low latency
 								dts := u.PTS
-								sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+								setWriteDeadline()
 								err := (*w).WriteH265(
 									track,
 									u.PTS, // no conversion is needed since clock rate is 90khz in both MPEG-TS and RTSP
@@ -115,7 +122,7 @@ func FromStream(
 							}
 						}
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err = (*w).WriteH265(
 							track,
 							u.PTS, // no conversion is needed since clock rate is 90khz in both MPEG-TS and RTSP
@@ -151,7 +158,7 @@ func FromStream(
 								usePTSAsDTS = true
 								// Use PTS as DTS approximation for low latency
 								dts := u.PTS
-								sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+								setWriteDeadline()
 								err := (*w).WriteH264(
 									track,
 									u.PTS, // no conversion is needed since clock rate is 90khz in both MPEG-TS and RTSP
@@ -189,7 +196,7 @@ func FromStream(
 							}
 						}
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err = (*w).WriteH264(
 							track,
 							u.PTS, // no conversion is needed since clock rate is 90khz in both MPEG-TS and RTSP
@@ -223,7 +230,7 @@ func FromStream(
 						}
 						lastPTS = u.PTS
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err := (*w).WriteMPEG4Video(
 							track,
 							u.PTS, // no conversion is needed since clock rate is 90khz in both MPEG-TS and RTSP
@@ -256,7 +263,7 @@ func FromStream(
 						}
 						lastPTS = u.PTS
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err := (*w).WriteMPEG1Video(
 							track,
 							u.PTS, // no conversion is needed since clock rate is 90khz in both MPEG-TS and RTSP
@@ -281,7 +288,7 @@ func FromStream(
 							return nil
 						}
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err := (*w).WriteOpus(
 							track,
 							multiplyAndDivide(u.PTS, 90000, int64(clockRate)),
@@ -308,7 +315,7 @@ func FromStream(
 							return nil
 						}
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err := (*w).WriteKLV(track, multiplyAndDivide(u.PTS, 90000, 90000), u.Payload.(unit.PayloadKLV))
 						if err != nil {
 							return err
@@ -330,7 +337,7 @@ func FromStream(
 							return nil
 						}
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err := (*w).WriteMPEG4Audio(
 							track,
 							multiplyAndDivide(u.PTS, 90000, int64(clockRate)),
@@ -372,7 +379,7 @@ func FromStream(
 								return err
 							}
 
-							sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+							setWriteDeadline()
 							err = (*w).WriteMPEG4AudioLATM(
 								track,
 								multiplyAndDivide(u.PTS, 90000, int64(clockRate)),
@@ -392,7 +399,7 @@ func FromStream(
 								return nil
 							}
 
-							sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+							setWriteDeadline()
 							err := (*w).WriteMPEG4AudioLATM(
 								track,
 								multiplyAndDivide(u.PTS, 90000, int64(clockRate)),
@@ -416,7 +423,7 @@ func FromStream(
 							return nil
 						}
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						setWriteDeadline()
 						err := (*w).WriteMPEG1Audio(
 							track,
 							u.PTS, // no conversion is needed since clock rate is 90khz in both MPEG-TS and RTSP
@@ -442,7 +449,7 @@ func FromStream(
 						for i, frame := range u.Payload.(unit.PayloadAC3) {
 							framePTS := u.PTS + int64(i)*ac3.SamplesPerFrame
 
-							sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+							setWriteDeadline()
 							err := (*w).WriteAC3(
 								track,
 								multiplyAndDivide(framePTS, 90000, int64(clockRate)),
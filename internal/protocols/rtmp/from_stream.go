@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/bluenviron/gortmplib"
+	"github.com/bluenviron/gortmplib/pkg/amf0"
 	"github.com/bluenviron/gortmplib/pkg/codecs"
 	"github.com/bluenviron/gortmplib/pkg/message"
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
@@ -37,14 +38,33 @@ func timestampToDuration(t int64, clockRate int) time.Duration {
 	return multiplyAndDivide2(time.Duration(t), time.Second, time.Duration(clockRate))
 }
 
+// supportedFourCCs returns the Enhanced RTMP codecs that can be sent through conn.
+func supportedFourCCs(conn gortmplib.Conn) amf0.StrictArray {
+	if sconn, ok := conn.(*gortmplib.ServerConn); ok {
+		return sconn.FourCcList
+	}
+
+	// when publishing, the server doesn't advertise supported codecs.
+	return amf0.StrictArray{
+		fourCCToString(message.FourCCAV1),
+		fourCCToString(message.FourCCVP9),
+		fourCCToString(message.FourCCHEVC),
+		fourCCToString(message.FourCCOpus),
+		fourCCToString(message.FourCCAC3),
+	}
+}
+
 // FromStream maps a MediaMTX stream to a RTMP stream.
+// conn can be either a *gortmplib.ServerConn (reading) or a *gortmplib.Client (publishing).
 func FromStream(
 	desc *description.Session,
 	r *stream.Reader,
-	conn *gortmplib.ServerConn,
+	conn gortmplib.Conn,
 	nconn net.Conn,
 	writeTimeout time.Duration,
 ) error {
+	fourCcList := supportedFourCCs(conn)
+
 	var tracks []*gortmplib.Track
 	var w *gortmplib.Writer
 
@@ -52,7 +72,7 @@ func FromStream(
 		for _, forma := range media.Formats {
 			switch forma := forma.(type) {
 			case *format.AV1:
-				if slices.Contains(conn.FourCcList, any(fourCCToString(message.FourCCAV1))) {
+				if slices.Contains(fourCcList, any(fourCCToString(message.FourCCAV1))) {
 					track := &gortmplib.Track{
 						Codec: &codecs.AV1{},
 					}
@@ -75,7 +95,7 @@ func FromStream(
 				}
 
 			case *format.VP9:
-				if slices.Contains(conn.FourCcList, any(fourCCToString(message.FourCCVP9))) {
+				if slices.Contains(fourCcList, any(fourCCToString(message.FourCCVP9))) {
 					track := &gortmplib.Track{
 						Codec: &codecs.VP9{},
 					}
@@ -98,7 +118,7 @@ func FromStream(
 				}
 
 			case *format.H265:
-				if slices.Contains(conn.FourCcList, any(fourCCToString(message.FourCCHEVC))) {
+				if slices.Contains(fourCcList, any(fourCCToString(message.FourCCHEVC))) {
 					vps, sps, pps := forma.SafeParams()
 					track := &gortmplib.Track{
 						Codec: &codecs.H265{
@@ -201,7 +221,7 @@ func FromStream(
 					})
 
 			case *format.Opus:
-				if slices.Contains(conn.FourCcList, any(fourCCToString(message.FourCCOpus))) {
+				if slices.Contains(fourCcList, any(fourCCToString(message.FourCCOpus))) {
 					track := &gortmplib.Track{
 						Codec: &codecs.Opus{
 							ChannelCount: forma.ChannelCount,
@@ -343,7 +363,7 @@ func FromStream(
 					})
 
 			case *format.AC3:
-				if slices.Contains(conn.FourCcList, any(fourCCToString(message.FourCCAC3))) {
+				if slices.Contains(fourCcList, any(fourCCToString(message.FourCCAC3))) {
 					track := &gortmplib.Track{
 						Codec: &codecs.AC3{
 							SampleRate:   forma.SampleRate,
@@ -0,0 +1,227 @@
+// Package s3 contains a client for S3-compatible object storage.
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrObjectNotFound is returned when an object does not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// StatusError is returned when the server replies with an error status code.
+type StatusError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("bad status code: %v (%s: %s)", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("bad status code: %v", e.StatusCode)
+}
+
+// Object is an object stored in a bucket.
+type Object struct {
+	Key  string
+	Size int64
+}
+
+type errorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// Client is a client for a bucket of a S3-compatible object storage.
+// Buckets are addressed with the path style, that is supported by AWS
+// and by self-hosted services like MinIO.
+type Client struct {
+	// URL of the bucket, in the format http(s)://host[:port]/bucket
+	URL             *url.URL
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	HTTPClient      *http.Client
+}
+
+func (c *Client) objectURL(key string) *url.URL {
+	u := *c.URL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+
+	// use the same encoding of the signature
+	u.RawPath = uriEncode(u.Path, false)
+
+	return &u
+}
+
+func (c *Client) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	if c.AccessKeyID != "" {
+		region := c.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		sign(req, c.AccessKeyID, c.SecretAccessKey, region, payloadHash, time.Now())
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+
+	var er errorResponse
+	xml.NewDecoder(io.LimitReader(res.Body, 64*1024)).Decode(&er) //nolint:errcheck
+
+	return nil, &StatusError{
+		StatusCode: res.StatusCode,
+		Code:       er.Code,
+		Message:    er.Message,
+	}
+}
+
+// PutObject uploads an object.
+func (c *Client) PutObject(ctx context.Context, key string, body io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.objectURL(key).String(), body)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	res, err := c.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, res.Body) //nolint:errcheck
+
+	return nil
+}
+
+// GetObject downloads length bytes of an object, starting from offset.
+// When length is zero, the object is read until its end.
+func (c *Client) GetObject(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset != 0 || length != 0 {
+		rang := "bytes=" + strconv.FormatInt(offset, 10) + "-"
+		if length != 0 {
+			rang += strconv.FormatInt(offset+length-1, 10)
+		}
+		req.Header.Set("Range", rang)
+	}
+
+	res, err := c.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Body, nil
+}
+
+// ListObjects returns all objects whose key starts with prefix.
+func (c *Client) ListObjects(ctx context.Context, prefix string) ([]*Object, error) {
+	var ret []*Object
+	continuationToken := ""
+
+	for {
+		v := url.Values{}
+		v.Set("list-type", "2")
+		v.Set("prefix", prefix)
+		if continuationToken != "" {
+			v.Set("continuation-token", continuationToken)
+		}
+
+		u := *c.URL
+		u.RawQuery = v.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := c.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range result.Contents {
+			ret = append(ret, &Object{
+				Key:  entry.Key,
+				Size: entry.Size,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return ret, nil
+		}
+
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// DeleteObject deletes an object.
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, res.Body) //nolint:errcheck
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestClient(t *testing.T) {
+	s := &test.S3Server{Bucket: "mybucket"}
+	s.Initialize()
+	defer s.Close()
+
+	u, err := url.Parse(s.URL())
+	require.NoError(t, err)
+
+	c := &Client{
+		URL:             u,
+		AccessKeyID:     "myuser",
+		SecretAccessKey: "mypass",
+	}
+
+	payload := bytes.Repeat([]byte{1, 2, 3, 4}, 1000)
+
+	err = c.PutObject(context.Background(), "mypath/segment 1.mp4", bytes.NewReader(payload), int64(len(payload)))
+	require.NoError(t, err)
+
+	err = c.PutObject(context.Background(), "other/segment.mp4", bytes.NewReader(nil), 0)
+	require.NoError(t, err)
+
+	objects, err := c.ListObjects(context.Background(), "mypath/")
+	require.NoError(t, err)
+	require.Equal(t, []*Object{{Key: "mypath/segment 1.mp4", Size: 4000}}, objects)
+
+	body, err := c.GetObject(context.Background(), "mypath/segment 1.mp4", 4, 8)
+	require.NoError(t, err)
+	buf, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 4, 1, 2, 3, 4}, buf)
+
+	r := &ObjectReader{
+		Context: context.Background(),
+		Client:  c,
+		Key:     "mypath/segment 1.mp4",
+		Size:    4000,
+	}
+
+	_, err = r.Seek(-2, io.SeekEnd)
+	require.NoError(t, err)
+	buf, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, []byte{3, 4}, buf)
+
+	err = c.DeleteObject(context.Background(), "mypath/segment 1.mp4")
+	require.NoError(t, err)
+
+	_, err = c.GetObject(context.Background(), "mypath/segment 1.mp4", 0, 0)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+}
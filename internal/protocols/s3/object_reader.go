@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+const (
+	objectReaderChunkSize = 1 * 1024 * 1024
+)
+
+// ObjectReader allows to read and seek an object,
+// by downloading the required portions with range requests.
+type ObjectReader struct {
+	Context context.Context
+	Client  *Client
+	Key     string
+	Size    int64
+
+	pos      int64
+	chunk    []byte
+	chunkPos int64
+}
+
+// Read implements io.Reader.
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.pos >= r.Size {
+		return 0, io.EOF
+	}
+
+	if r.chunk == nil || r.pos < r.chunkPos || r.pos >= r.chunkPos+int64(len(r.chunk)) {
+		err := r.fetch()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.chunk[r.pos-r.chunkPos:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *ObjectReader) fetch() error {
+	length := min(int64(objectReaderChunkSize), r.Size-r.pos)
+
+	body, err := r.Client.GetObject(r.Context, r.Key, r.pos, length)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if int64(cap(r.chunk)) < length {
+		r.chunk = make([]byte, length)
+	}
+	r.chunk = r.chunk[:length]
+
+	_, err = io.ReadFull(body, r.chunk)
+	if err != nil {
+		r.chunk = nil
+		return err
+	}
+
+	r.chunkPos = r.pos
+	return nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.Size {
+		return 0, io.EOF
+	}
+
+	// serve the request from the current chunk when possible
+	if r.chunk != nil && off >= r.chunkPos && off+int64(len(p)) <= r.chunkPos+int64(len(r.chunk)) {
+		return copy(p, r.chunk[off-r.chunkPos:]), nil
+	}
+
+	length := min(int64(len(p)), r.Size-off)
+
+	body, err := r.Client.GetObject(r.Context, r.Key, off, length)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:length])
+	if err != nil {
+		return n, err
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek implements io.Seeker.
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+
+	case io.SeekCurrent:
+		pos = r.pos + offset
+
+	case io.SeekEnd:
+		pos = r.Size + offset
+
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	r.pos = pos
+	return pos, nil
+}
+
+// Close implements io.Closer.
+func (r *ObjectReader) Close() error {
+	r.chunk = nil
+	return nil
+}
@@ -0,0 +1,330 @@
+package webrtc
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	thinnerClockRate   = 90000
+	thinnerWindow      = thinnerClockRate // 1 second
+	thinnerMaxTemporal = 7
+)
+
+// FrameThinner drops video frames in order to limit the frame rate sent to a reader,
+// without breaking decoding:
+//   - with H264 and H265, non-reference frames are dropped;
+//   - with VP8, VP9 and AV1, frames of upper temporal layers are dropped.
+//
+// Since reference frames are never dropped, the resulting frame rate can be higher than
+// the maximum one. Frames are dropped before packetization, therefore RTP sequence numbers
+// are contiguous.
+//
+// VP8 and VP9 temporal layers are read from the RTP payload descriptor,
+// therefore they can be detected only when the stream is received with RTP.
+//
+// Streams without non-reference frames or temporal layers (like most H264 streams)
+// can't be thinned; this is reported once through Log.
+type FrameThinner struct {
+	Log logger.Writer
+
+	mutex  sync.Mutex
+	maxFPS float64
+
+	started     bool
+	lastPTS     int64
+	lastKeptPTS int64
+
+	// temporal layer measurement
+	windowStart      int64
+	layerFrames      [thinnerMaxTemporal + 1]int
+	maxTemporalLayer int
+	nextMaxTemporal  int
+	inputFPS         float64
+
+	// measurement of frames that can be dropped
+	droppableFrames   int
+	thinnable         bool
+	unavailableLogged bool
+}
+
+// FrameThinnerFromQuery creates a FrameThinner from the "maxfps" query parameter.
+// It returns nil if the parameter is not present.
+func FrameThinnerFromQuery(query url.Values) (*FrameThinner, error) {
+	v := query.Get("maxfps")
+	if v == "" {
+		return nil, nil
+	}
+
+	fps, err := strconv.ParseFloat(v, 64)
+	if err != nil || fps <= 0 {
+		return nil, fmt.Errorf("invalid 'maxfps': %s", v)
+	}
+
+	t := &FrameThinner{}
+	t.SetMaxFPS(fps)
+	return t, nil
+}
+
+// SetMaxFPS sets the maximum frame rate. Zero disables thinning.
+func (t *FrameThinner) SetMaxFPS(fps float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.maxFPS = fps
+}
+
+// MaxFPS returns the maximum frame rate.
+func (t *FrameThinner) MaxFPS() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.maxFPS
+}
+
+// InputFPS returns the measured frame rate of the input, before thinning.
+func (t *FrameThinner) InputFPS() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.inputFPS
+}
+
+// Thinnable checks whether the input contains frames that can be dropped.
+// It returns false until the input has been measured.
+func (t *FrameThinner) Thinnable() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.thinnable
+}
+
+// Keep checks whether a unit must be sent.
+func (t *FrameThinner) Keep(u *unit.Unit) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// the PTS is not continuous when the source changes, reset the state
+	if !t.started || u.PTS < t.lastPTS || (u.PTS-t.lastPTS) > thinnerWindow {
+		t.started = true
+		t.lastKeptPTS = u.PTS - thinnerWindow
+		t.windowStart = u.PTS
+		t.layerFrames = [thinnerMaxTemporal + 1]int{}
+		t.maxTemporalLayer = thinnerMaxTemporal
+		t.nextMaxTemporal = thinnerMaxTemporal
+		t.droppableFrames = 0
+	}
+	t.lastPTS = u.PTS
+
+	switch payload := u.Payload.(type) {
+	case unit.PayloadH264:
+		return t.keepNonReference(u.PTS, h264IsNonReference(payload))
+
+	case unit.PayloadH265:
+		return t.keepNonReference(u.PTS, h265IsNonReference(payload))
+
+	case unit.PayloadVP8:
+		return t.keepTemporalLayer(u.PTS, vp8TemporalLayer(u))
+
+	case unit.PayloadVP9:
+		return t.keepTemporalLayer(u.PTS, vp9TemporalLayer(u))
+
+	case unit.PayloadAV1:
+		return t.keepTemporalLayer(u.PTS, av1TemporalLayer(payload))
+	}
+
+	return true
+}
+
+// keepNonReference drops non-reference frames that exceed the maximum frame rate.
+func (t *FrameThinner) keepNonReference(pts int64, nonReference bool) bool {
+	t.measure(pts, 0, nonReference)
+
+	if t.maxFPS > 0 && nonReference &&
+		float64(pts-t.lastKeptPTS) < thinnerClockRate/t.maxFPS {
+		return false
+	}
+
+	t.lastKeptPTS = pts
+	return true
+}
+
+// keepTemporalLayer drops the upper temporal layers that exceed the maximum frame rate.
+func (t *FrameThinner) keepTemporalLayer(pts int64, layer int) bool {
+	t.measure(pts, layer, layer != 0)
+
+	// switch layer at base layer frames only, since they don't depend on other layers
+	if layer == 0 {
+		t.maxTemporalLayer = t.nextMaxTemporal
+	}
+
+	return layer <= t.maxTemporalLayer
+}
+
+// measure measures the frame rate of each temporal layer
+// and selects the highest temporal layer that fits into the maximum frame rate.
+func (t *FrameThinner) measure(pts int64, layer int, droppable bool) {
+	if elapsed := pts - t.windowStart; elapsed >= thinnerWindow {
+		secs := float64(elapsed) / thinnerClockRate
+		fps := 0.0
+		t.nextMaxTemporal = 0
+
+		for i, n := range t.layerFrames {
+			fps += float64(n) / secs
+
+			if t.maxFPS <= 0 || fps <= t.maxFPS {
+				t.nextMaxTemporal = i
+			}
+		}
+
+		t.inputFPS = fps
+		t.layerFrames = [thinnerMaxTemporal + 1]int{}
+		t.windowStart = pts
+
+		t.thinnable = t.droppableFrames != 0
+		t.droppableFrames = 0
+
+		if !t.thinnable && t.maxFPS > 0 && fps > t.maxFPS && !t.unavailableLogged && t.Log != nil {
+			t.unavailableLogged = true
+			t.Log.Log(logger.Warn, "unable to limit frame rate to %.1f fps, since the stream does not "+
+				"contain frames that can be dropped (non-reference frames or temporal layers)", t.maxFPS)
+		}
+	}
+
+	t.layerFrames[min(layer, thinnerMaxTemporal)]++
+
+	if droppable {
+		t.droppableFrames++
+	}
+}
+
+func h264IsNonReference(au [][]byte) bool {
+	vcl := false
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		typ := h264.NALUType(nalu[0] & 0x1F)
+		if typ >= h264.NALUTypeNonIDR && typ <= h264.NALUTypeIDR {
+			vcl = true
+
+			// nal_ref_idc
+			if (nalu[0]>>5)&0x03 != 0 {
+				return false
+			}
+		}
+	}
+
+	return vcl
+}
+
+func h265IsNonReference(au [][]byte) bool {
+	vcl := false
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		typ := h265.NALUType((nalu[0] >> 1) & 0x3F)
+		if typ < 32 {
+			vcl = true
+
+			// sub-layer non-reference pictures have an even type lower than 16
+			if typ > 14 || typ%2 != 0 {
+				return false
+			}
+		}
+	}
+
+	return vcl
+}
+
+// vp8TemporalLayer reads the temporal layer from the VP8 payload descriptor.
+// Specification: RFC7741, section 4.2
+func vp8TemporalLayer(u *unit.Unit) int {
+	if len(u.RTPPackets) == 0 {
+		return 0
+	}
+
+	buf := u.RTPPackets[0].Payload
+	if len(buf) < 2 || (buf[0]&0x80) == 0 {
+		return 0
+	}
+
+	ext := buf[1]
+	pos := 2
+
+	// picture ID
+	if (ext & 0x80) != 0 {
+		if len(buf) <= pos {
+			return 0
+		}
+		if (buf[pos] & 0x80) != 0 {
+			pos += 2
+		} else {
+			pos++
+		}
+	}
+
+	// TL0PICIDX
+	if (ext & 0x40) != 0 {
+		pos++
+	}
+
+	// TID
+	if (ext&0x20) == 0 || len(buf) <= pos {
+		return 0
+	}
+
+	return int(buf[pos] >> 6)
+}
+
+// vp9TemporalLayer reads the temporal layer from the VP9 payload descriptor.
+// Specification: RFC9628, section 4.2
+func vp9TemporalLayer(u *unit.Unit) int {
+	if len(u.RTPPackets) == 0 {
+		return 0
+	}
+
+	buf := u.RTPPackets[0].Payload
+	if len(buf) < 1 || (buf[0]&0x20) == 0 {
+		return 0
+	}
+
+	pos := 1
+
+	// picture ID
+	if (buf[0] & 0x80) != 0 {
+		if len(buf) <= pos {
+			return 0
+		}
+		if (buf[pos] & 0x80) != 0 {
+			pos += 2
+		} else {
+			pos++
+		}
+	}
+
+	if len(buf) <= pos {
+		return 0
+	}
+
+	return int(buf[pos] >> 5)
+}
+
+// av1TemporalLayer reads the temporal layer from the extension header of OBUs.
+func av1TemporalLayer(tu [][]byte) int {
+	for _, obu := range tu {
+		if len(obu) >= 2 && (obu[0]>>2)&0x01 != 0 {
+			return int(obu[1] >> 5)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+package webrtc
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestFrameThinnerFromQuery(t *testing.T) {
+	th, err := FrameThinnerFromQuery(url.Values{})
+	require.NoError(t, err)
+	require.Nil(t, th)
+
+	th, err = FrameThinnerFromQuery(url.Values{"maxfps": []string{"15"}})
+	require.NoError(t, err)
+	require.Equal(t, float64(15), th.MaxFPS())
+
+	_, err = FrameThinnerFromQuery(url.Values{"maxfps": []string{"0"}})
+	require.Error(t, err)
+}
+
+func TestFrameThinnerH264(t *testing.T) {
+	th := &FrameThinner{}
+	th.SetMaxFPS(15)
+
+	kept := 0
+
+	// 30 fps, one reference frame every two frames
+	for i := range 60 {
+		var au unit.PayloadH264
+		if i%2 == 0 {
+			au = unit.PayloadH264{{0x41, 0x01}} // reference
+		} else {
+			au = unit.PayloadH264{{0x01, 0x01}} // non-reference
+		}
+
+		if th.Keep(&unit.Unit{PTS: int64(i) * 3000, Payload: au}) {
+			kept++
+		}
+	}
+
+	require.Equal(t, 30, kept)
+	require.InDelta(t, 30, th.InputFPS(), 1)
+	require.True(t, th.Thinnable())
+
+	// reference frames are never dropped
+	th = &FrameThinner{}
+	th.SetMaxFPS(5)
+
+	for i := range 30 {
+		require.True(t, th.Keep(&unit.Unit{PTS: int64(i) * 3000, Payload: unit.PayloadH264{{0x41, 0x01}}}))
+	}
+}
+
+func TestFrameThinnerNoDroppableFrames(t *testing.T) {
+	var logged []string
+
+	th := &FrameThinner{
+		Log: test.Logger(func(_ logger.Level, format string, args ...any) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		}),
+	}
+	th.SetMaxFPS(15)
+
+	// 30 fps IPPP stream, where all frames are reference frames
+	for i := range 90 {
+		require.True(t, th.Keep(&unit.Unit{PTS: int64(i) * 3000, Payload: unit.PayloadH264{{0x41, 0x01}}}))
+	}
+
+	require.False(t, th.Thinnable())
+	require.Equal(t, []string{
+		"unable to limit frame rate to 15.0 fps, since the stream does not contain " +
+			"frames that can be dropped (non-reference frames or temporal layers)",
+	}, logged)
+}
+
+func TestFrameThinnerTemporalLayers(t *testing.T) {
+	th := &FrameThinner{}
+	th.SetMaxFPS(15)
+
+	// 30 fps AV1 stream with two temporal layers (L0 L1 L0 L1 ...)
+	obu := func(tid byte) unit.PayloadAV1 {
+		return unit.PayloadAV1{{0x36, tid << 5, 0x01, 0xAA}}
+	}
+
+	var kept []int
+
+	for i := range 90 {
+		if th.Keep(&unit.Unit{PTS: int64(i) * 3000, Payload: obu(byte(i % 2))}) {
+			kept = append(kept, i)
+		}
+	}
+
+	// the first second is not thinned, since the frame rate of layers is being measured,
+	// then half of the remaining frames are dropped.
+	require.Equal(t, 60, len(kept))
+
+	for _, i := range kept[31:] {
+		require.Equal(t, 0, i%2)
+	}
+}
+
+func TestVP8TemporalLayer(t *testing.T) {
+	u := &unit.Unit{RTPPackets: []*rtp.Packet{{
+		// X=1, I=1 (15-bit picture ID), L=1, T=1, TID=2
+		Payload: []byte{0x90, 0xE0, 0x80, 0x01, 0x05, 0x80, 0x00},
+	}}}
+	require.Equal(t, 2, vp8TemporalLayer(u))
+
+	u = &unit.Unit{RTPPackets: []*rtp.Packet{{Payload: []byte{0x10, 0x00}}}}
+	require.Equal(t, 0, vp8TemporalLayer(u))
+}
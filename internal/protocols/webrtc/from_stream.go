@@ -69,7 +69,7 @@ func setupVideoTrack(
 	desc *description.Session,
 	r *stream.Reader,
 	pc *PeerConnection,
-	pathConf interface{ SafeConf() *conf.Path },
+	opts FromStreamOptions,
 ) (format.Format, error) {
 	var av1Format *format.AV1
 	media := desc.FindFormat(&av1Format)
@@ -83,11 +83,16 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
+		rid, err := setupSimulcastEncodings(track, opts.PathConf)
+		if err != nil {
+			return nil, err
+		}
+
 		encoder := &rtpav1.Encoder{
 			PayloadType:    105,
 			PayloadMaxSize: webrtcPayloadMaxSize,
 		}
-		err := encoder.Init()
+		err = encoder.Init()
 		if err != nil {
 			return nil, err
 		}
@@ -100,7 +105,20 @@ func setupVideoTrack(
 					return nil
 				}
 
-				packets, err2 := encoder.Encode(u.Payload.(unit.PayloadAV1))
+				if opts.FrameThinner != nil && !opts.FrameThinner.Keep(u) {
+					return nil
+				}
+
+				tu := u.Payload.(unit.PayloadAV1)
+
+				if opts.SVCFilter != nil {
+					tu = opts.SVCFilter.FilterAV1(tu)
+					if tu == nil {
+						return nil
+					}
+				}
+
+				packets, err2 := encoder.Encode(tu)
 				if err2 != nil {
 					return nil //nolint:nilerr
 				}
@@ -108,7 +126,7 @@ func setupVideoTrack(
 				for _, pkt := range packets {
 					ntp := u.NTP.Add(timestampToDuration(int64(pkt.Timestamp), 90000))
 					pkt.Timestamp += u.RTPPackets[0].Timestamp
-					track.WriteRTPWithNTP(pkt, ntp) //nolint:errcheck
+					track.WriteRTPWithRID(pkt, ntp, rid) //nolint:errcheck
 				}
 
 				return nil
@@ -130,12 +148,17 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
+		rid, err := setupSimulcastEncodings(track, opts.PathConf)
+		if err != nil {
+			return nil, err
+		}
+
 		encoder := &rtpvp9.Encoder{
 			PayloadType:      96,
 			PayloadMaxSize:   webrtcPayloadMaxSize,
 			InitialPictureID: ptrOf(uint16(8445)),
 		}
-		err := encoder.Init()
+		err = encoder.Init()
 		if err != nil {
 			return nil, err
 		}
@@ -148,7 +171,20 @@ func setupVideoTrack(
 					return nil
 				}
 
-				packets, err2 := encoder.Encode(u.Payload.(unit.PayloadVP9))
+				if opts.FrameThinner != nil && !opts.FrameThinner.Keep(u) {
+					return nil
+				}
+
+				frame := u.Payload.(unit.PayloadVP9)
+
+				if opts.SVCFilter != nil {
+					frame = opts.SVCFilter.FilterVP9(frame)
+					if frame == nil {
+						return nil
+					}
+				}
+
+				packets, err2 := encoder.Encode(frame)
 				if err2 != nil {
 					return nil //nolint:nilerr
 				}
@@ -156,7 +192,7 @@ func setupVideoTrack(
 				for _, pkt := range packets {
 					ntp := u.NTP.Add(timestampToDuration(int64(pkt.Timestamp), 90000))
 					pkt.Timestamp += u.RTPPackets[0].Timestamp
-					track.WriteRTPWithNTP(pkt, ntp) //nolint:errcheck
+					track.WriteRTPWithRID(pkt, ntp, rid) //nolint:errcheck
 				}
 
 				return nil
@@ -177,11 +213,16 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
+		rid, err := setupSimulcastEncodings(track, opts.PathConf)
+		if err != nil {
+			return nil, err
+		}
+
 		encoder := &rtpvp8.Encoder{
 			PayloadType:    96,
 			PayloadMaxSize: webrtcPayloadMaxSize,
 		}
-		err := encoder.Init()
+		err = encoder.Init()
 		if err != nil {
 			return nil, err
 		}
@@ -194,6 +235,10 @@ func setupVideoTrack(
 					return nil
 				}
 
+				if opts.FrameThinner != nil && !opts.FrameThinner.Keep(u) {
+					return nil
+				}
+
 				packets, err2 := encoder.Encode(u.Payload.(unit.PayloadVP8))
 				if err2 != nil {
 					return nil //nolint:nilerr
@@ -202,7 +247,7 @@ func setupVideoTrack(
 				for _, pkt := range packets {
 					ntp := u.NTP.Add(timestampToDuration(int64(pkt.Timestamp), 90000))
 					pkt.Timestamp += u.RTPPackets[0].Timestamp
-					track.WriteRTPWithNTP(pkt, ntp) //nolint:errcheck
+					track.WriteRTPWithRID(pkt, ntp, rid) //nolint:errcheck
 				}
 
 				return nil
@@ -224,11 +269,16 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
+		rid, err := setupSimulcastEncodings(track, opts.PathConf)
+		if err != nil {
+			return nil, err
+		}
+
 		encoder := &rtph265.Encoder{
 			PayloadType:    96,
 			PayloadMaxSize: webrtcPayloadMaxSize,
 		}
-		err := encoder.Init()
+		err = encoder.Init()
 		if err != nil {
 			return nil, err
 		}
@@ -251,6 +301,10 @@ func setupVideoTrack(
 				}
 				lastPTS = u.PTS
 
+				if opts.FrameThinner != nil && !opts.FrameThinner.Keep(u) {
+					return nil
+				}
+
 				packets, err2 := encoder.Encode(u.Payload.(unit.PayloadH265))
 				if err2 != nil {
 					return nil //nolint:nilerr
@@ -259,7 +313,7 @@ func setupVideoTrack(
 				for _, pkt := range packets {
 					ntp := u.NTP.Add(timestampToDuration(int64(pkt.Timestamp), 90000))
 					pkt.Timestamp += u.RTPPackets[0].Timestamp
-					track.WriteRTPWithNTP(pkt, ntp) //nolint:errcheck
+					track.WriteRTPWithRID(pkt, ntp, rid) //nolint:errcheck
 				}
 
 				return nil
@@ -281,39 +335,16 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
-		// Configure simulcast if path has simulcast config
-		if pathConf != nil {
-			conf := pathConf.SafeConf()
-			if conf != nil && conf.SimulcastConfig != nil && conf.SimulcastConfig.Enable {
-				encodings := make([]webrtc.RTPEncodingParameters, 0)
-				for _, input := range conf.SimulcastConfig.Inputs {
-					if input.Type == "video" && input.Layer != "" {
-						ssrc, err := randUint32()
-						if err != nil {
-							return nil, fmt.Errorf("failed to generate SSRC for layer %s: %w", input.Layer, err)
-						}
-						encodings = append(encodings, webrtc.RTPEncodingParameters{
-							RTPCodingParameters: webrtc.RTPCodingParameters{
-								RID:  input.Layer,
-								SSRC: webrtc.SSRC(ssrc),
-							},
-						})
-					}
-				}
-				if len(encodings) > 0 {
-					err := track.ConfigureSimulcast(encodings)
-					if err != nil {
-						return nil, fmt.Errorf("failed to configure simulcast: %w", err)
-					}
-				}
-			}
+		rid, err := setupSimulcastEncodings(track, opts.PathConf)
+		if err != nil {
+			return nil, err
 		}
 
 		encoder := &rtph264.Encoder{
 			PayloadType:    96,
 			PayloadMaxSize: webrtcPayloadMaxSize,
 		}
-		err := encoder.Init()
+		err = encoder.Init()
 		if err != nil {
 			return nil, err
 		}
@@ -321,26 +352,6 @@ func setupVideoTrack(
 		firstReceived := false
 		var lastPTS int64
 
-		// Determine which RID to use for this track
-		// For simulcast, we need to determine which layer this stream represents
-		// This will be handled by the simulcast source when writing packets
-		var currentRID string
-		if pathConf != nil {
-			conf := pathConf.SafeConf()
-			if conf != nil && conf.SimulcastConfig != nil && conf.SimulcastConfig.Enable {
-				// For simulcast, the source will handle RID selection
-				// We'll use the first layer as default
-				if len(conf.SimulcastConfig.Inputs) > 0 {
-					for _, input := range conf.SimulcastConfig.Inputs {
-						if input.Type == "video" && input.Layer != "" {
-							currentRID = input.Layer
-							break
-						}
-					}
-				}
-			}
-		}
-
 		r.OnData(
 			media,
 			h264Format,
@@ -356,6 +367,10 @@ func setupVideoTrack(
 				}
 				lastPTS = u.PTS
 
+				if opts.FrameThinner != nil && !opts.FrameThinner.Keep(u) {
+					return nil
+				}
+
 				packets, err2 := encoder.Encode(u.Payload.(unit.PayloadH264))
 				if err2 != nil {
 					return nil //nolint:nilerr
@@ -364,7 +379,7 @@ func setupVideoTrack(
 				for _, pkt := range packets {
 					ntp := u.NTP.Add(timestampToDuration(int64(pkt.Timestamp), 90000))
 					pkt.Timestamp += u.RTPPackets[0].Timestamp
-					track.WriteRTPWithRID(pkt, ntp, currentRID) //nolint:errcheck
+					track.WriteRTPWithRID(pkt, ntp, rid) //nolint:errcheck
 				}
 
 				return nil
@@ -376,6 +391,53 @@ func setupVideoTrack(
 	return nil, nil
 }
 
+// setupSimulcastEncodings configures the simulcast encodings of a video track
+// when the path has a simulcast configuration.
+// It returns the RID that is used to write packets.
+func setupSimulcastEncodings(
+	track *OutgoingTrack,
+	pathConf interface{ SafeConf() *conf.Path },
+) (string, error) {
+	if pathConf == nil {
+		return "", nil
+	}
+
+	pconf := pathConf.SafeConf()
+	if pconf == nil || pconf.SimulcastConfig == nil || !pconf.SimulcastConfig.Enable {
+		return "", nil
+	}
+
+	var encodings []webrtc.RTPEncodingParameters
+
+	for _, input := range pconf.SimulcastConfig.Inputs {
+		if input.Type == "video" && input.Layer != "" {
+			ssrc, err := randUint32()
+			if err != nil {
+				return "", fmt.Errorf("failed to generate SSRC for layer %s: %w", input.Layer, err)
+			}
+
+			encodings = append(encodings, webrtc.RTPEncodingParameters{
+				RTPCodingParameters: webrtc.RTPCodingParameters{
+					RID:  input.Layer,
+					SSRC: webrtc.SSRC(ssrc),
+				},
+			})
+		}
+	}
+
+	if len(encodings) == 0 {
+		return "", nil
+	}
+
+	err := track.ConfigureSimulcast(encodings)
+	if err != nil {
+		return "", fmt.Errorf("failed to configure simulcast: %w", err)
+	}
+
+	// packets are written with the RID of the first layer
+	return encodings[0].RID, nil
+}
+
 func setupAudioTrack(
 	desc *description.Session,
 	r *stream.Reader,
@@ -697,6 +759,116 @@ func setupAudioTrack(
 	return nil, nil
 }
 
+func setupSimulcastVideoTrack(
+	layers []SimulcastLayer,
+	switcher *SimulcastSwitcher,
+	thinner *FrameThinner,
+	pc *PeerConnection,
+) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("no simulcast layers")
+	}
+
+	_, firstForma := simulcastVideoFormat(layers[0].Desc)
+	if firstForma == nil {
+		return fmt.Errorf("simulcast layer '%s' doesn't contain a video track", layers[0].Name)
+	}
+
+	for _, layer := range layers[1:] {
+		_, forma := simulcastVideoFormat(layer.Desc)
+		if forma == nil {
+			return fmt.Errorf("simulcast layer '%s' doesn't contain a video track", layer.Name)
+		}
+
+		if forma.Codec() != firstForma.Codec() {
+			return fmt.Errorf("simulcast layers must use the same codec, but layer '%s' uses %s "+
+				"and layer '%s' uses %s", layers[0].Name, firstForma.Codec(), layer.Name, forma.Codec())
+		}
+	}
+
+	codec, err := newSimulcastCodec(firstForma)
+	if err != nil {
+		return err
+	}
+
+	track := &OutgoingTrack{
+		Caps: codec.caps,
+	}
+	pc.OutgoingTracks = append(pc.OutgoingTracks, track)
+
+	for _, layer := range layers {
+		media, forma := simulcastVideoFormat(layer.Desc)
+
+		firstReceived := false
+		var lastPTS int64
+
+		layer.Reader.OnData(
+			media,
+			forma,
+			func(u *unit.Unit) error {
+				if u.NilPayload() {
+					return nil
+				}
+
+				if !firstReceived {
+					firstReceived = true
+				} else if u.PTS < lastPTS {
+					return fmt.Errorf("WebRTC doesn't support %s streams with B-frames", forma.Codec())
+				}
+				lastPTS = u.PTS
+
+				return switcher.WriteUnit(layer.Name, codec.isRandomAccess(u.Payload), u, func(ts uint32) error {
+					if thinner != nil && !thinner.Keep(u) {
+						return nil
+					}
+
+					packets, err2 := codec.encode(u.Payload)
+					if err2 != nil {
+						return nil //nolint:nilerr
+					}
+
+					for _, pkt := range packets {
+						ntp := u.NTP.Add(timestampToDuration(int64(pkt.Timestamp), 90000))
+						pkt.Timestamp += ts
+						track.WriteRTPWithNTP(pkt, ntp) //nolint:errcheck
+					}
+
+					return nil
+				})
+			})
+	}
+
+	return nil
+}
+
+// SimulcastLayer is a simulcast layer that can feed the video track.
+type SimulcastLayer struct {
+	Name   string
+	Desc   *description.Session
+	Reader *stream.Reader
+}
+
+// FromSimulcastStream maps a MediaMTX simulcast stream to a WebRTC connection.
+// Video is read from the layers, that are selected by the switcher,
+// while audio is read from the main stream.
+// The thinner is optional and reduces the frame rate of the selected layer.
+func FromSimulcastStream(
+	desc *description.Session,
+	r *stream.Reader,
+	layers []SimulcastLayer,
+	switcher *SimulcastSwitcher,
+	thinner *FrameThinner,
+	pc *PeerConnection,
+) error {
+	err := setupSimulcastVideoTrack(layers, switcher, thinner, pc)
+	if err != nil {
+		return err
+	}
+
+	_, err = setupAudioTrack(desc, r, pc)
+	return err
+}
+
 // FromStream maps a MediaMTX stream to a WebRTC connection
 func FromStream(
 	desc *description.Session,
@@ -713,7 +885,29 @@ func FromStreamWithConfig(
 	pc *PeerConnection,
 	pathConf interface{ SafeConf() *conf.Path },
 ) error {
-	videoFormat, err := setupVideoTrack(desc, r, pc, pathConf)
+	return FromStreamWithOptions(desc, r, pc, FromStreamOptions{PathConf: pathConf})
+}
+
+// FromStreamOptions contains optional parameters of FromStreamWithOptions.
+type FromStreamOptions struct {
+	// path configuration, used to set up simulcast encodings
+	PathConf interface{ SafeConf() *conf.Path }
+
+	// drops layers of SVC streams
+	SVCFilter *SVCFilter
+
+	// drops frames in order to limit the frame rate
+	FrameThinner *FrameThinner
+}
+
+// FromStreamWithOptions maps a MediaMTX stream to a WebRTC connection with options.
+func FromStreamWithOptions(
+	desc *description.Session,
+	r *stream.Reader,
+	pc *PeerConnection,
+	opts FromStreamOptions,
+) error {
+	videoFormat, err := setupVideoTrack(desc, r, pc, opts)
 	if err != nil {
 		return err
 	}
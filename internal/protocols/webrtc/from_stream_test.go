@@ -199,3 +199,38 @@ func TestFromStreamResampleOpus(t *testing.T) {
 
 	<-done
 }
+
+func TestFromSimulcastStreamMixedCodecs(t *testing.T) {
+	newLayer := func(name string, forma format.Format) SimulcastLayer {
+		return SimulcastLayer{
+			Name: name,
+			Desc: &description.Session{Medias: []*description.Media{{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{forma},
+			}}},
+			Reader: &stream.Reader{Parent: test.NilLogger},
+		}
+	}
+
+	switcher := &SimulcastSwitcher{ClockRate: 90000}
+	err := switcher.Initialize()
+	require.NoError(t, err)
+
+	pc := &PeerConnection{}
+
+	err = FromSimulcastStream(&description.Session{}, &stream.Reader{Parent: test.NilLogger},
+		[]SimulcastLayer{
+			newLayer("high", &format.VP9{PayloadTyp: 96}),
+			newLayer("low", &format.VP9{PayloadTyp: 96}),
+		}, switcher, nil, pc)
+	require.NoError(t, err)
+	require.Equal(t, "video/VP9", pc.OutgoingTracks[0].Caps.MimeType)
+
+	err = FromSimulcastStream(&description.Session{}, &stream.Reader{Parent: test.NilLogger},
+		[]SimulcastLayer{
+			newLayer("high", &format.AV1{PayloadTyp: 96}),
+			newLayer("low", &format.H264{PayloadTyp: 96, PacketizationMode: 1}),
+		}, switcher, nil, &PeerConnection{})
+	require.EqualError(t, err, "simulcast layers must use the same codec, "+
+		"but layer 'high' uses AV1 and layer 'low' uses H264")
+}
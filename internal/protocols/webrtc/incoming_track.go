@@ -1,6 +1,7 @@
 package webrtc
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/rtpreceiver"
@@ -245,6 +246,8 @@ type IncomingTrack struct {
 
 	packetsLost *counterdumper.CounterDumper
 	rtpReceiver *rtpreceiver.Receiver
+
+	rtpPacketsReceived uint64
 }
 
 func (t *IncomingTrack) initialize() {
@@ -256,6 +259,16 @@ func (t *IncomingTrack) Codec() webrtc.RTPCodecParameters {
 	return t.track.Codec()
 }
 
+// RID returns the RTP stream ID of the track, that is filled in case of simulcast.
+func (t *IncomingTrack) RID() string {
+	return t.track.RID()
+}
+
+// RTPPacketsReceived returns the number of received RTP packets.
+func (t *IncomingTrack) RTPPacketsReceived() uint64 {
+	return atomic.LoadUint64(&t.rtpPacketsReceived)
+}
+
 // ClockRate returns the clock rate. Needed by rtptime.GlobalDecoder
 func (t *IncomingTrack) ClockRate() int {
 	return int(t.track.Codec().ClockRate)
@@ -299,7 +312,14 @@ func (t *IncomingTrack) start() {
 	go func() {
 		buf := make([]byte, 1500)
 		for {
-			n, _, err2 := t.receiver.Read(buf)
+			var n int
+			var err2 error
+
+			if rid := t.track.RID(); rid != "" {
+				n, _, err2 = t.receiver.ReadSimulcast(buf, rid)
+			} else {
+				n, _, err2 = t.receiver.Read(buf)
+			}
 			if err2 != nil {
 				return
 			}
@@ -344,6 +364,8 @@ func (t *IncomingTrack) start() {
 				return
 			}
 
+			atomic.AddUint64(&t.rtpPacketsReceived, 1)
+
 			packets, lost := t.rtpReceiver.ProcessPacket2(pkt, time.Now(), true)
 
 			if lost != 0 {
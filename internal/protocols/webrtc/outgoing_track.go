@@ -136,12 +136,17 @@ func (t *OutgoingTrack) WriteRTPWithRID(pkt *rtp.Packet, ntp time.Time, rid stri
 }
 
 // ConfigureSimulcast configures Simulcast encodings for this track.
+// It can be used with any video codec.
 // This method stores the encodings for later use when writing RTP packets and SDP generation.
 func (t *OutgoingTrack) ConfigureSimulcast(encodings []webrtc.RTPEncodingParameters) error {
 	if len(encodings) == 0 {
 		return fmt.Errorf("encodings cannot be empty")
 	}
 
+	if !t.isVideo() {
+		return fmt.Errorf("simulcast can be used with video tracks only")
+	}
+
 	// Store encodings for later use
 	t.simulcastEncodings = encodings
 
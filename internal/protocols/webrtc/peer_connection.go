@@ -15,6 +15,7 @@ import (
 
 	"github.com/pion/ice/v4"
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/twcc"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v4"
 
@@ -62,6 +63,7 @@ func interfaceIPs(interfaceList []string) ([]string, error) {
 
 // * skip ConfigureRTCPReports
 // * add statsInterceptor
+// * add TWCC sequence numbers to outgoing packets, in order to receive TWCC feedback
 func registerInterceptors(
 	mediaEngine *webrtc.MediaEngine,
 	interceptorRegistry *interceptor.Registry,
@@ -82,6 +84,13 @@ func registerInterceptors(
 		return err
 	}
 
+	twccHeaderExtension, err := twcc.NewHeaderExtensionInterceptor()
+	if err != nil {
+		return err
+	}
+
+	interceptorRegistry.Add(twccHeaderExtension)
+
 	interceptorRegistry.Add(&statsInterceptorFactory{
 		onCreate: onStatsInterceptor,
 	})
@@ -125,6 +134,47 @@ func TracksAreValid(medias []*sdp.MediaDescription) error {
 	return nil
 }
 
+// simulcastRIDs returns the RIDs of the layers sent by the remote peer,
+// in the order in which they are listed in the a=simulcast attribute.
+func simulcastRIDs(media *sdp.MediaDescription) []string {
+	var rids []string
+
+	for _, attr := range media.Attributes {
+		if attr.Key != "simulcast" {
+			continue
+		}
+
+		parts := strings.Fields(attr.Value)
+		if len(parts) < 2 || parts[0] != "send" {
+			continue
+		}
+
+		// alternatives are separated by commas, the first one is used.
+		// paused layers are prefixed by "~".
+		for _, stream := range strings.Split(parts[1], ";") {
+			rid := strings.TrimPrefix(strings.Split(stream, ",")[0], "~")
+			if rid != "" {
+				rids = append(rids, rid)
+			}
+		}
+	}
+
+	if rids != nil {
+		return rids
+	}
+
+	for _, attr := range media.Attributes {
+		if attr.Key == "rid" {
+			parts := strings.Fields(attr.Value)
+			if len(parts) >= 2 && parts[1] == "send" {
+				rids = append(rids, parts[0])
+			}
+		}
+	}
+
+	return rids
+}
+
 type trackRecvPair struct {
 	track    *webrtc.TrackRemote
 	receiver *webrtc.RTPReceiver
@@ -137,6 +187,7 @@ type PeerConnection struct {
 	ICEUDPMux             ice.UDPMux
 	ICETCPMux             *TCPMuxWrapper
 	ICEServers            []webrtc.ICEServer
+	ICETransportPolicy    webrtc.ICETransportPolicy
 	IPsFromInterfaces     bool
 	IPsFromInterfacesList []string
 	AdditionalHosts       []string
@@ -153,6 +204,7 @@ type PeerConnection struct {
 	incomingTracks   []*IncomingTrack
 	startedReading   *int64
 	statsInterceptor *statsInterceptor
+	incomingRIDs     []string
 
 	newLocalCandidate chan *webrtc.ICECandidateInit
 	incomingTrack     chan trackRecvPair
@@ -291,7 +343,8 @@ func (co *PeerConnection) Start() error {
 		webrtc.WithInterceptorRegistry(interceptorRegistry))
 
 	co.wr, err = api.NewPeerConnection(webrtc.Configuration{
-		ICEServers: co.ICEServers,
+		ICEServers:         co.ICEServers,
+		ICETransportPolicy: co.ICETransportPolicy,
 	})
 	if err != nil {
 		return err
@@ -780,7 +833,17 @@ func (co *PeerConnection) GatherIncomingTracks() error {
 	var sdp sdp.SessionDescription
 	sdp.Unmarshal([]byte(co.wr.RemoteDescription().SDP)) //nolint:errcheck
 
-	maxTrackCount := len(sdp.MediaDescriptions)
+	// each simulcast layer is received as a separate track
+	maxTrackCount := 0
+	for _, media := range sdp.MediaDescriptions {
+		rids := simulcastRIDs(media)
+		if len(rids) != 0 {
+			co.incomingRIDs = append(co.incomingRIDs, rids...)
+			maxTrackCount += len(rids)
+		} else {
+			maxTrackCount++
+		}
+	}
 
 	t := time.NewTimer(time.Duration(co.TrackGatherTimeout))
 	defer t.Stop()
@@ -841,6 +904,23 @@ func (co *PeerConnection) IncomingTracks() []*IncomingTrack {
 	return co.incomingTracks
 }
 
+// IncomingRIDs returns the RIDs of incoming simulcast layers,
+// in the order in which they are listed in the remote description.
+func (co *PeerConnection) IncomingRIDs() []string {
+	var rids []string
+
+	for _, rid := range co.incomingRIDs {
+		for _, tr := range co.incomingTracks {
+			if tr.RID() == rid {
+				rids = append(rids, rid)
+				break
+			}
+		}
+	}
+
+	return rids
+}
+
 // StartReading starts reading incoming tracks.
 func (co *PeerConnection) StartReading() {
 	select {
@@ -935,3 +1015,8 @@ func (co *PeerConnection) Stats() *Stats {
 		RTCPPacketsSent:     atomic.LoadUint64(co.statsInterceptor.rtcpPacketsSent),
 	}
 }
+
+// FeedbackStats returns statistics about the congestion feedback sent by the remote peer.
+func (co *PeerConnection) FeedbackStats() *FeedbackStats {
+	return co.statsInterceptor.feedbackStats()
+}
@@ -593,3 +593,42 @@ func TestPeerConnectionFallbackCodecs(t *testing.T) {
 		},
 	}, s.MediaDescriptions)
 }
+
+func TestSimulcastRIDs(t *testing.T) {
+	for _, ca := range []struct {
+		name  string
+		attrs []sdp.Attribute
+		rids  []string
+	}{
+		{
+			"simulcast",
+			[]sdp.Attribute{
+				{Key: "rid", Value: "l send"},
+				{Key: "rid", Value: "m send"},
+				{Key: "rid", Value: "h send"},
+				{Key: "simulcast", Value: "send h;m,x;~l"},
+			},
+			[]string{"h", "m", "l"},
+		},
+		{
+			"rid only",
+			[]sdp.Attribute{
+				{Key: "rid", Value: "q send"},
+				{Key: "rid", Value: "f send pt=96"},
+				{Key: "rid", Value: "r recv"},
+			},
+			[]string{"q", "f"},
+		},
+		{
+			"none",
+			[]sdp.Attribute{
+				{Key: "sendonly"},
+			},
+			nil,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.rids, simulcastRIDs(&sdp.MediaDescription{Attributes: ca.attrs}))
+		})
+	}
+}
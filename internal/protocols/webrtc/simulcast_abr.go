@@ -0,0 +1,260 @@
+package webrtc
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+const (
+	// below this loss ratio, the estimate is increased.
+	abrLowLoss = 0.02
+
+	// above this loss ratio, the estimate is decreased.
+	abrHighLoss = 0.10
+
+	// increase of the estimate at each update, when losses are low.
+	abrIncreaseFactor = 1.05
+
+	// switching to an upper layer requires an estimate that exceeds
+	// the bitrate of the layer by this factor.
+	abrUpHysteresis = 1.25
+
+	// the estimate can't exceed the bitrate of the highest layer by more than this factor.
+	abrMaxEstimateFactor = 1.5
+)
+
+// SimulcastABR moves a reader between simulcast layers, depending on the bandwidth
+// estimated from the congestion feedback (receiver reports, TWCC and REMB)
+// sent by the reader.
+//
+// The estimate follows the loss-based controller of Google Congestion Control:
+// it's increased when losses are low and decreased when losses are high.
+// Switching to a lower layer happens as soon as the estimate falls below the bitrate
+// of the current layer, while switching to an upper layer requires a margin (hysteresis).
+// Two consecutive switches are separated by at least Cooldown.
+//
+// When the bitrate of the current layer is unknown, the estimate is seeded with the send rate,
+// and switching is delayed until the bitrates of all layers are known.
+type SimulcastABR struct {
+	Inputs   []conf.SimulcastInput
+	Cooldown time.Duration
+
+	mutex      sync.Mutex
+	layers     []conf.SimulcastInput
+	limit      float64
+	estimate   float64
+	layer      string
+	lastSwitch time.Time
+	prev       *FeedbackStats
+	prevTime   time.Time
+}
+
+// Initialize initializes SimulcastABR.
+func (a *SimulcastABR) Initialize() {
+	a.setInputs(a.Inputs)
+}
+
+// SetInputs updates the inputs, in case their bitrate changes.
+func (a *SimulcastABR) SetInputs(inputs []conf.SimulcastInput) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.setInputs(inputs)
+}
+
+func (a *SimulcastABR) setInputs(inputs []conf.SimulcastInput) {
+	a.layers = nil
+
+	for _, input := range inputs {
+		if input.Type == "video" {
+			a.layers = append(a.layers, input)
+		}
+	}
+
+	slices.SortFunc(a.layers, func(x, y conf.SimulcastInput) int {
+		return cmp.Compare(x.Bitrate, y.Bitrate)
+	})
+
+	if a.estimate == 0 {
+		a.seedEstimate()
+	}
+}
+
+// SetLayer sets the current layer and resets the estimate to its bitrate.
+// When the bitrate is unknown, the estimate is reset to zero.
+func (a *SimulcastABR) SetLayer(layer string, now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.layer = layer
+	a.lastSwitch = now
+	a.estimate = 0
+	a.seedEstimate()
+}
+
+// seedEstimate sets the estimate to the bitrate of the current layer, if known.
+func (a *SimulcastABR) seedEstimate() {
+	if l := a.find(a.layer); l != nil && l.Bitrate != 0 {
+		a.estimate = float64(l.Bitrate)
+		a.applyLimits()
+	}
+}
+
+// SetLimit sets the bandwidth limit requested by the reader, in kbps.
+// A limit of zero means that there's no limit.
+func (a *SimulcastABR) SetLimit(limit int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.limit = float64(limit) * 1000
+	a.applyLimits()
+}
+
+// Estimate returns the estimated bandwidth, in bit/s.
+// It returns zero when the estimate is not available yet.
+func (a *SimulcastABR) Estimate() uint64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return uint64(a.estimate)
+}
+
+// Update updates the estimate with new feedback statistics.
+// It returns the layer to switch to, if a switch is needed.
+func (a *SimulcastABR) Update(stats *FeedbackStats, now time.Time) (string, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.prev == nil {
+		a.prev = stats
+		a.prevTime = now
+		return "", false
+	}
+
+	elapsed := now.Sub(a.prevTime).Seconds()
+	if elapsed <= 0 {
+		return "", false
+	}
+
+	sendRate := float64(stats.RTPBytesSent-a.prev.RTPBytesSent) * 8 / elapsed
+
+	// bitrate of the current layer is unknown: start from the send rate
+	if a.estimate == 0 {
+		a.estimate = sendRate
+		a.applyLimits()
+		a.prev = stats
+		a.prevTime = now
+		return "", false
+	}
+
+	loss := float64(0)
+
+	if stats.ReceiverReports != a.prev.ReceiverReports {
+		loss = stats.FractionLost
+	}
+
+	twccReceived := stats.TWCCPacketsReceived - a.prev.TWCCPacketsReceived
+	twccLost := stats.TWCCPacketsLost - a.prev.TWCCPacketsLost
+	if (twccReceived + twccLost) != 0 {
+		loss = max(loss, float64(twccLost)/float64(twccReceived+twccLost))
+	}
+
+	switch {
+	case loss > abrHighLoss:
+		if sendRate != 0 {
+			a.estimate = min(a.estimate, sendRate)
+		}
+		a.estimate *= 1 - 0.5*loss
+
+	case loss < abrLowLoss:
+		a.estimate *= abrIncreaseFactor
+	}
+
+	if stats.REMBBitrate != 0 {
+		a.estimate = min(a.estimate, float64(stats.REMBBitrate))
+	}
+
+	a.applyLimits()
+
+	a.prev = stats
+	a.prevTime = now
+
+	if now.Sub(a.lastSwitch) < a.Cooldown {
+		return "", false
+	}
+
+	target := a.targetLayer()
+	if target == "" || target == a.layer {
+		return "", false
+	}
+
+	a.layer = target
+	a.lastSwitch = now
+
+	return target, true
+}
+
+func (a *SimulcastABR) find(layer string) *conf.SimulcastInput {
+	for i := range a.layers {
+		if a.layers[i].Layer == layer {
+			return &a.layers[i]
+		}
+	}
+	return nil
+}
+
+// bitratesKnown checks whether the bitrates of all layers are known.
+func (a *SimulcastABR) bitratesKnown() bool {
+	for _, l := range a.layers {
+		if l.Bitrate == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *SimulcastABR) applyLimits() {
+	if len(a.layers) != 0 && a.bitratesKnown() {
+		a.estimate = min(a.estimate, float64(a.layers[len(a.layers)-1].Bitrate)*abrMaxEstimateFactor)
+	}
+
+	if a.limit != 0 {
+		a.estimate = min(a.estimate, a.limit)
+	}
+}
+
+func (a *SimulcastABR) targetLayer() string {
+	cur := a.find(a.layer)
+	if cur == nil {
+		return ""
+	}
+
+	// layers can't be compared until their bitrates are known
+	if !a.bitratesKnown() {
+		return ""
+	}
+
+	// switch down
+	if a.estimate < float64(cur.Bitrate) {
+		for i := len(a.layers) - 1; i >= 0; i-- {
+			if float64(a.layers[i].Bitrate) <= a.estimate {
+				return a.layers[i].Layer
+			}
+		}
+		return a.layers[0].Layer
+	}
+
+	// switch up
+	for i := len(a.layers) - 1; i >= 0; i-- {
+		if a.layers[i].Bitrate <= cur.Bitrate {
+			break
+		}
+		if float64(a.layers[i].Bitrate)*abrUpHysteresis <= a.estimate {
+			return a.layers[i].Layer
+		}
+	}
+
+	return cur.Layer
+}
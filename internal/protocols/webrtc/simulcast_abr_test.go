@@ -0,0 +1,146 @@
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulcastABR(t *testing.T) {
+	a := &SimulcastABR{
+		Inputs: []conf.SimulcastInput{
+			{Path: "live/high", Layer: "high", Bitrate: 2000000, Type: "video"},
+			{Path: "live/medium", Layer: "medium", Bitrate: 800000, Type: "video"},
+			{Path: "live/low", Layer: "low", Bitrate: 300000, Type: "video"},
+			{Path: "live/audio", Bitrate: 64000, Type: "audio"},
+		},
+		Cooldown: 5 * time.Second,
+	}
+	a.Initialize()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.SetLayer("high", now)
+
+	stats := &FeedbackStats{}
+
+	update := func(rate uint64, fractionLost float64) (string, bool) {
+		now = now.Add(1 * time.Second)
+		stats = &FeedbackStats{
+			RTPBytesSent:    stats.RTPBytesSent + rate/8,
+			ReceiverReports: stats.ReceiverReports + 1,
+			FractionLost:    fractionLost,
+		}
+		return a.Update(stats, now)
+	}
+
+	_, ok := update(0, 0)
+	require.False(t, ok)
+
+	// losses during cooldown decrease the estimate without switching
+	_, ok = update(2000000, 0.5)
+	require.False(t, ok)
+	require.Equal(t, uint64(1500000), a.Estimate())
+
+	for range 2 {
+		_, ok = update(2000000, 0.5)
+		require.False(t, ok)
+	}
+
+	// switch down after the cooldown
+	layer, ok := update(2000000, 0.5)
+	require.True(t, ok)
+	require.Equal(t, "low", layer)
+
+	// estimate grows, but switching up requires a margin
+	for range 9 {
+		_, ok = update(300000, 0)
+		require.False(t, ok)
+	}
+
+	layer, ok = update(300000, 0)
+	require.True(t, ok)
+	require.Equal(t, "medium", layer)
+
+	// the limit requested by the reader is an upper bound
+	a.SetLimit(1000)
+
+	for range 30 {
+		_, ok = update(800000, 0)
+		require.False(t, ok)
+	}
+	require.Equal(t, uint64(1000000), a.Estimate())
+}
+
+func TestSimulcastABRUnknownBitrates(t *testing.T) {
+	inputs := []conf.SimulcastInput{
+		{Path: "live~h", Layer: "h", Type: "video"},
+		{Path: "live~l", Layer: "l", Type: "video"},
+	}
+
+	a := &SimulcastABR{
+		Inputs:   inputs,
+		Cooldown: 5 * time.Second,
+	}
+	a.Initialize()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.SetLayer("h", now)
+	require.Equal(t, uint64(0), a.Estimate())
+
+	stats := &FeedbackStats{}
+
+	update := func(rate uint64) (string, bool) {
+		now = now.Add(1 * time.Second)
+		stats = &FeedbackStats{
+			RTPBytesSent:    stats.RTPBytesSent + rate/8,
+			ReceiverReports: stats.ReceiverReports + 1,
+		}
+		return a.Update(stats, now)
+	}
+
+	// no data has been sent yet
+	for range 10 {
+		_, ok := update(0)
+		require.False(t, ok)
+		require.Equal(t, uint64(0), a.Estimate())
+	}
+
+	// estimate starts from the send rate, without switching
+	// until bitrates of all layers are known
+	for range 10 {
+		_, ok := update(1000000)
+		require.False(t, ok)
+	}
+	require.NotZero(t, a.Estimate())
+
+	inputs[0].Bitrate = 1000000
+	inputs[1].Bitrate = 300000
+	a.SetInputs(inputs)
+
+	for range 10 {
+		_, ok := update(1000000)
+		require.False(t, ok)
+	}
+}
+
+func TestSimulcastABRSeedFromMeasuredBitrate(t *testing.T) {
+	inputs := []conf.SimulcastInput{
+		{Path: "live~h", Layer: "h", Type: "video"},
+		{Path: "live~l", Layer: "l", Type: "video"},
+	}
+
+	a := &SimulcastABR{
+		Inputs:   inputs,
+		Cooldown: 5 * time.Second,
+	}
+	a.Initialize()
+	a.SetLayer("h", time.Now())
+	require.Equal(t, uint64(0), a.Estimate())
+
+	inputs[0].Bitrate = 1000000
+	inputs[1].Bitrate = 300000
+	a.SetInputs(inputs)
+	require.Equal(t, uint64(1000000), a.Estimate())
+}
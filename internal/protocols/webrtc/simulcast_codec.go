@@ -0,0 +1,170 @@
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpav1"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtph265"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpvp8"
+	"github.com/bluenviron/gortsplib/v5/pkg/format/rtpvp9"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/av1"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/vp9"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// simulcastCodec contains the codec-specific parts of a simulcast video track.
+type simulcastCodec struct {
+	caps           webrtc.RTPCodecCapability
+	encode         func(u unit.Payload) ([]*rtp.Packet, error)
+	isRandomAccess func(u unit.Payload) bool
+}
+
+// simulcastVideoFormat returns the first video format of a simulcast layer.
+func simulcastVideoFormat(desc *description.Session) (*description.Media, format.Format) {
+	for _, media := range desc.Medias {
+		if media.Type == description.MediaTypeVideo && len(media.Formats) != 0 {
+			return media, media.Formats[0]
+		}
+	}
+	return nil, nil
+}
+
+// newSimulcastCodec allocates a simulcastCodec.
+// Since a single encoder is shared by all layers, sequence numbers are continuous.
+func newSimulcastCodec(forma format.Format) (*simulcastCodec, error) {
+	switch forma.(type) {
+	case *format.AV1:
+		encoder := &rtpav1.Encoder{
+			PayloadType:    105,
+			PayloadMaxSize: webrtcPayloadMaxSize,
+		}
+		err := encoder.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		return &simulcastCodec{
+			caps: webrtc.RTPCodecCapability{
+				MimeType:  webrtc.MimeTypeAV1,
+				ClockRate: 90000,
+			},
+			encode: func(p unit.Payload) ([]*rtp.Packet, error) {
+				return encoder.Encode(p.(unit.PayloadAV1))
+			},
+			isRandomAccess: func(p unit.Payload) bool {
+				return av1.IsRandomAccess2(p.(unit.PayloadAV1))
+			},
+		}, nil
+
+	case *format.VP9:
+		encoder := &rtpvp9.Encoder{
+			PayloadType:      96,
+			PayloadMaxSize:   webrtcPayloadMaxSize,
+			InitialPictureID: ptrOf(uint16(8445)),
+		}
+		err := encoder.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		return &simulcastCodec{
+			caps: webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeVP9,
+				ClockRate:   90000,
+				SDPFmtpLine: "profile-id=0",
+			},
+			encode: func(p unit.Payload) ([]*rtp.Packet, error) {
+				return encoder.Encode(p.(unit.PayloadVP9))
+			},
+			isRandomAccess: func(p unit.Payload) bool {
+				var h vp9.Header
+				err := h.Unmarshal(p.(unit.PayloadVP9))
+				return err == nil && !h.NonKeyFrame
+			},
+		}, nil
+
+	case *format.VP8:
+		encoder := &rtpvp8.Encoder{
+			PayloadType:    96,
+			PayloadMaxSize: webrtcPayloadMaxSize,
+		}
+		err := encoder.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		return &simulcastCodec{
+			caps: webrtc.RTPCodecCapability{
+				MimeType:  webrtc.MimeTypeVP8,
+				ClockRate: 90000,
+			},
+			encode: func(p unit.Payload) ([]*rtp.Packet, error) {
+				return encoder.Encode(p.(unit.PayloadVP8))
+			},
+			isRandomAccess: func(p unit.Payload) bool {
+				// the first bit of the frame tag is zero in key frames
+				frame := p.(unit.PayloadVP8)
+				return len(frame) != 0 && (frame[0]&0x01) == 0
+			},
+		}, nil
+
+	case *format.H265:
+		encoder := &rtph265.Encoder{
+			PayloadType:    96,
+			PayloadMaxSize: webrtcPayloadMaxSize,
+		}
+		err := encoder.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		return &simulcastCodec{
+			caps: webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeH265,
+				ClockRate:   90000,
+				SDPFmtpLine: "level-id=93;profile-id=1;tier-flag=0;tx-mode=SRST",
+			},
+			encode: func(p unit.Payload) ([]*rtp.Packet, error) {
+				return encoder.Encode(p.(unit.PayloadH265))
+			},
+			isRandomAccess: func(p unit.Payload) bool {
+				return h265.IsRandomAccess(p.(unit.PayloadH265))
+			},
+		}, nil
+
+	case *format.H264:
+		encoder := &rtph264.Encoder{
+			PayloadType:    96,
+			PayloadMaxSize: webrtcPayloadMaxSize,
+		}
+		err := encoder.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		return &simulcastCodec{
+			caps: webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeH264,
+				ClockRate:   90000,
+				SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+			},
+			encode: func(p unit.Payload) ([]*rtp.Packet, error) {
+				return encoder.Encode(p.(unit.PayloadH264))
+			},
+			isRandomAccess: func(p unit.Payload) bool {
+				return h264.IsRandomAccess(p.(unit.PayloadH264))
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("codec %s is not supported by simulcast, supported codecs are "+
+		"AV1, VP9, VP8, H265, H264", forma.Codec())
+}
@@ -0,0 +1,188 @@
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	simulcastBitrateWindow = 1 * time.Second
+)
+
+// SimulcastLayerForBandwidth returns the video layer with the highest bitrate
+// that fits into the given bandwidth limit, in kbps.
+// If no layer fits, the layer with the lowest bitrate is returned.
+// A limit of zero means that there's no limit.
+func SimulcastLayerForBandwidth(inputs []conf.SimulcastInput, limit int) string {
+	var best *conf.SimulcastInput
+	var lowest *conf.SimulcastInput
+
+	for i := range inputs {
+		input := &inputs[i]
+		if input.Type != "video" {
+			continue
+		}
+
+		if lowest == nil || input.Bitrate < lowest.Bitrate {
+			lowest = input
+		}
+
+		if limit != 0 && uint64(input.Bitrate) > uint64(limit)*1000 {
+			continue
+		}
+
+		if best == nil || input.Bitrate > best.Bitrate {
+			best = input
+		}
+	}
+
+	if best != nil {
+		return best.Layer
+	}
+	if lowest != nil {
+		return lowest.Layer
+	}
+	return ""
+}
+
+// SimulcastSwitcher selects the simulcast layer that feeds a video track.
+//
+// Switches are performed at the first random access unit of the new layer.
+// Units of all layers are packetized by a single encoder and written with the SSRC
+// of the track, therefore SSRC and sequence numbers don't change across switches,
+// while timestamps are rewritten in order to continue from the last written one.
+type SimulcastSwitcher struct {
+	ClockRate int
+
+	mutex     sync.Mutex
+	current   string
+	pending   string
+	tsBase    uint32
+	ptsOffset int64
+	started   bool
+	lastPTS   int64
+	lastNTP   time.Time
+	bitrates  map[string]*simulcastBitrate
+}
+
+// simulcastBitrate measures the bitrate of a layer.
+type simulcastBitrate struct {
+	bytes   uint64
+	start   time.Time
+	bitrate uint
+}
+
+func (b *simulcastBitrate) add(u *unit.Unit) {
+	for _, pkt := range u.RTPPackets {
+		b.bytes += uint64(pkt.MarshalSize())
+	}
+
+	if b.start.IsZero() {
+		b.start = u.NTP
+		return
+	}
+
+	if elapsed := u.NTP.Sub(b.start); elapsed >= simulcastBitrateWindow {
+		b.bitrate = uint(float64(b.bytes*8) / elapsed.Seconds())
+		b.bytes = 0
+		b.start = u.NTP
+	}
+}
+
+// Initialize initializes SimulcastSwitcher.
+func (s *SimulcastSwitcher) Initialize() error {
+	var err error
+	s.tsBase, err = randUint32()
+	if err != nil {
+		return err
+	}
+
+	s.bitrates = make(map[string]*simulcastBitrate)
+
+	return nil
+}
+
+// SetLayer sets the layer that is used starting from its next random access unit.
+func (s *SimulcastSwitcher) SetLayer(layer string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if layer == s.current {
+		s.pending = ""
+	} else {
+		s.pending = layer
+	}
+}
+
+// Layer returns the layer that is currently feeding the track.
+func (s *SimulcastSwitcher) Layer() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.current
+}
+
+// Bitrate returns the measured bitrate of a layer, in bit/s.
+func (s *SimulcastSwitcher) Bitrate(layer string) uint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if b, ok := s.bitrates[layer]; ok {
+		return b.bitrate
+	}
+	return 0
+}
+
+// WriteUnit processes a unit of the given layer.
+// If the layer is active, cb is called with the rewritten RTP timestamp,
+// otherwise the unit is discarded.
+func (s *SimulcastSwitcher) WriteUnit(
+	layer string,
+	randomAccess bool,
+	u *unit.Unit,
+	cb func(ts uint32) error,
+) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, ok := s.bitrates[layer]
+	if !ok {
+		b = &simulcastBitrate{}
+		s.bitrates[layer] = b
+	}
+	b.add(u)
+
+	if layer != s.current {
+		if layer != s.pending || !randomAccess {
+			return nil
+		}
+
+		s.current = layer
+		s.pending = ""
+
+		if s.started {
+			// continue from the last written timestamp, by adding the time elapsed
+			// between the last written unit and the current one.
+			delta := durationToTimestamp(u.NTP.Sub(s.lastNTP), s.ClockRate)
+			if delta <= 0 {
+				delta = 1
+			}
+			s.ptsOffset = s.lastPTS + delta - u.PTS
+		} else {
+			s.ptsOffset = -u.PTS
+			s.started = true
+		}
+	}
+
+	pts := u.PTS + s.ptsOffset
+	s.lastPTS = pts
+	s.lastNTP = u.NTP
+
+	return cb(s.tsBase + uint32(pts))
+}
+
+func durationToTimestamp(d time.Duration, clockRate int) int64 {
+	return int64(multiplyAndDivide2(d, time.Duration(clockRate), time.Second))
+}
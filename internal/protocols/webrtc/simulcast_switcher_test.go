@@ -0,0 +1,80 @@
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/unit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulcastLayerForBandwidth(t *testing.T) {
+	inputs := []conf.SimulcastInput{
+		{Path: "live/high", Layer: "high", Bitrate: 2000000, Type: "video"},
+		{Path: "live/low", Layer: "low", Bitrate: 300000, Type: "video"},
+		{Path: "live/medium", Layer: "medium", Bitrate: 800000, Type: "video"},
+		{Path: "live/audio", Bitrate: 64000, Type: "audio"},
+	}
+
+	for _, ca := range []struct {
+		name  string
+		limit int
+		layer string
+	}{
+		{"unlimited", 0, "high"},
+		{"high", 2500, "high"},
+		{"medium", 1000, "medium"},
+		{"exact", 300, "low"},
+		{"below lowest", 100, "low"},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.layer, SimulcastLayerForBandwidth(inputs, ca.limit))
+		})
+	}
+}
+
+func TestSimulcastSwitcher(t *testing.T) {
+	s := &SimulcastSwitcher{ClockRate: 90000}
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	var written []uint32
+
+	write := func(layer string, randomAccess bool, pts int64, ntp time.Time) {
+		err2 := s.WriteUnit(layer, randomAccess, &unit.Unit{PTS: pts, NTP: ntp}, func(ts uint32) error {
+			written = append(written, ts-s.tsBase)
+			return nil
+		})
+		require.NoError(t, err2)
+	}
+
+	ntp := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.SetLayer("high")
+
+	// wait for the first random access unit
+	write("high", false, 1000, ntp)
+	write("low", true, 5000, ntp)
+	require.Empty(t, written)
+
+	write("high", true, 4000, ntp.Add(100*time.Millisecond))
+	write("high", false, 7000, ntp.Add(133*time.Millisecond))
+	require.Equal(t, []uint32{0, 3000}, written)
+	require.Equal(t, "high", s.Layer())
+
+	s.SetLayer("low")
+
+	// the current layer is kept until the new layer sends a random access unit
+	write("low", false, 90000, ntp.Add(166*time.Millisecond))
+	write("high", false, 10000, ntp.Add(166*time.Millisecond))
+	require.Equal(t, []uint32{0, 3000, 6000}, written)
+	require.Equal(t, "high", s.Layer())
+
+	// timestamps continue from the last written one
+	write("low", true, 93000, ntp.Add(200*time.Millisecond))
+	write("high", true, 13000, ntp.Add(200*time.Millisecond))
+	write("low", false, 96000, ntp.Add(233*time.Millisecond))
+	require.Equal(t, []uint32{0, 3000, 6000, 9060, 12060}, written)
+	require.Equal(t, "low", s.Layer())
+}
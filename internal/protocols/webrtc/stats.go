@@ -11,3 +11,13 @@ type Stats struct {
 	RTCPPacketsReceived uint64
 	RTCPPacketsSent     uint64
 }
+
+// FeedbackStats are statistics about the congestion feedback sent by the remote peer.
+type FeedbackStats struct {
+	RTPBytesSent        uint64
+	ReceiverReports     uint64
+	FractionLost        float64 // fraction lost reported by the last receiver report
+	TWCCPacketsReceived uint64
+	TWCCPacketsLost     uint64
+	REMBBitrate         uint64 // last estimate received through REMB, in bit/s
+}
@@ -1,15 +1,26 @@
 package webrtc
 
 import (
+	"sync"
 	"sync/atomic"
 
 	"github.com/pion/interceptor"
 	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 )
 
 type statsInterceptor struct {
 	rtcpPacketsSent     *uint64
 	rtcpPacketsReceived *uint64
+	rtpBytesSent        *uint64
+
+	// congestion feedback
+	feedbackMutex   sync.Mutex
+	receiverReports uint64
+	fractionLost    uint8
+	twccReceived    uint64
+	twccLost        uint64
+	rembBitrate     float32
 }
 
 func (*statsInterceptor) Close() error {
@@ -22,9 +33,10 @@ func (s *statsInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interce
 	) (int, interceptor.Attributes, error) {
 		n, attrs, err := reader.Read(bytes, attributes)
 
-		pkts, err2 := attrs.GetRTCPPackets(bytes)
+		pkts, err2 := attrs.GetRTCPPackets(bytes[:n])
 		if err2 == nil {
 			atomic.AddUint64(s.rtcpPacketsReceived, uint64(len(pkts)))
+			s.processFeedback(pkts)
 		}
 
 		return n, attrs, err
@@ -38,10 +50,58 @@ func (s *statsInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interce
 	})
 }
 
+func (s *statsInterceptor) processFeedback(pkts []rtcp.Packet) {
+	s.feedbackMutex.Lock()
+	defer s.feedbackMutex.Unlock()
+
+	for _, pkt := range pkts {
+		switch pkt := pkt.(type) {
+		case *rtcp.ReceiverReport:
+			for _, report := range pkt.Reports {
+				s.receiverReports++
+				s.fractionLost = report.FractionLost
+			}
+
+		case *rtcp.TransportLayerCC:
+			// a receive delta is present for each received packet
+			received := uint64(len(pkt.RecvDeltas))
+			if uint64(pkt.PacketStatusCount) >= received {
+				s.twccReceived += received
+				s.twccLost += uint64(pkt.PacketStatusCount) - received
+			}
+
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			s.rembBitrate = pkt.Bitrate
+		}
+	}
+}
+
+func (s *statsInterceptor) feedbackStats() *FeedbackStats {
+	s.feedbackMutex.Lock()
+	defer s.feedbackMutex.Unlock()
+
+	return &FeedbackStats{
+		RTPBytesSent:        atomic.LoadUint64(s.rtpBytesSent),
+		ReceiverReports:     s.receiverReports,
+		FractionLost:        float64(s.fractionLost) / 256,
+		TWCCPacketsReceived: s.twccReceived,
+		TWCCPacketsLost:     s.twccLost,
+		REMBBitrate:         uint64(s.rembBitrate),
+	}
+}
+
 func (s *statsInterceptor) BindLocalStream(_ *interceptor.StreamInfo,
 	writer interceptor.RTPWriter,
 ) interceptor.RTPWriter {
-	return writer
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte,
+		attributes interceptor.Attributes,
+	) (int, error) {
+		n, err := writer.Write(header, payload, attributes)
+		if err == nil {
+			atomic.AddUint64(s.rtpBytesSent, uint64(header.MarshalSize()+len(payload)))
+		}
+		return n, err
+	})
 }
 
 func (*statsInterceptor) UnbindLocalStream(_ *interceptor.StreamInfo) {}
@@ -62,6 +122,7 @@ func (f *statsInterceptorFactory) NewInterceptor(_ string) (interceptor.Intercep
 	s := &statsInterceptor{
 		rtcpPacketsSent:     new(uint64),
 		rtcpPacketsReceived: new(uint64),
+		rtpBytesSent:        new(uint64),
 	}
 
 	f.onCreate(s)
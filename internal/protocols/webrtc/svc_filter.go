@@ -0,0 +1,199 @@
+package webrtc
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/av1"
+)
+
+// AV1 OBU types that don't contain frame data.
+// Specification: AV1 Bitstream & Decoding Process, section 6.2.2
+const (
+	av1OBUTypeMetadata = 5
+	av1OBUTypePadding  = 15
+)
+
+// SVCFilter drops spatial and temporal layers of SVC streams,
+// in order to send a single stream with a quality that depends on the reader.
+//
+// AV1 layers are identified through the extension header of OBUs.
+// VP9 doesn't carry temporal layers in the bitstream, therefore only spatial layers
+// are dropped, assuming that each frame of a superframe belongs to a different spatial layer,
+// as produced by SVC encoders.
+type SVCFilter struct {
+	// maximum spatial layer ID. A negative value means no limit.
+	MaxSpatialLayer int
+
+	// maximum temporal layer ID. A negative value means no limit.
+	MaxTemporalLayer int
+}
+
+// SVCFilterFromQuery creates a SVCFilter from the "svcSpatialLayer" and "svcTemporalLayer"
+// query parameters. It returns nil if none of them is present.
+func SVCFilterFromQuery(query url.Values) (*SVCFilter, error) {
+	f := &SVCFilter{
+		MaxSpatialLayer:  -1,
+		MaxTemporalLayer: -1,
+	}
+	found := false
+
+	for _, param := range []struct {
+		key  string
+		dest *int
+	}{
+		{"svcSpatialLayer", &f.MaxSpatialLayer},
+		{"svcTemporalLayer", &f.MaxTemporalLayer},
+	} {
+		if v := query.Get(param.key); v != "" {
+			tmp, err := strconv.ParseUint(v, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid '%s': %w", param.key, err)
+			}
+			*param.dest = int(tmp)
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return f, nil
+}
+
+func (f *SVCFilter) keep(spatialID int, temporalID int) bool {
+	return (f.MaxSpatialLayer < 0 || spatialID <= f.MaxSpatialLayer) &&
+		(f.MaxTemporalLayer < 0 || temporalID <= f.MaxTemporalLayer)
+}
+
+// FilterAV1 removes OBUs of dropped layers from a temporal unit.
+// It returns nil when the whole temporal unit must be dropped.
+func (f *SVCFilter) FilterAV1(tu [][]byte) [][]byte {
+	var ret [][]byte
+	hasFrame := false
+
+	for _, obu := range tu {
+		if len(obu) == 0 {
+			continue
+		}
+
+		// OBUs without extension header belong to all layers
+		extensionFlag := (obu[0]>>2)&0x01 != 0
+		if extensionFlag && len(obu) >= 2 {
+			temporalID := int(obu[1] >> 5)
+			spatialID := int((obu[1] >> 3) & 0x03)
+
+			if !f.keep(spatialID, temporalID) {
+				continue
+			}
+		}
+
+		// temporal delimiters, sequence headers and metadata are not enough to send the unit
+		switch av1.OBUType((obu[0] >> 3) & 0x0F) {
+		case av1.OBUTypeSequenceHeader, av1.OBUTypeTemporalDelimiter, av1OBUTypeMetadata, av1OBUTypePadding:
+		default:
+			hasFrame = true
+		}
+
+		ret = append(ret, obu)
+	}
+
+	if !hasFrame {
+		return nil
+	}
+
+	return ret
+}
+
+// FilterVP9 removes frames of dropped spatial layers from a VP9 superframe.
+func (f *SVCFilter) FilterVP9(frame []byte) []byte {
+	if f.MaxSpatialLayer < 0 {
+		return frame
+	}
+
+	frames := splitVP9Superframe(frame)
+	if frames == nil {
+		// not a superframe, the frame belongs to the base layer
+		return frame
+	}
+
+	if len(frames) <= f.MaxSpatialLayer+1 {
+		return frame
+	}
+
+	return joinVP9Superframe(frames[:f.MaxSpatialLayer+1])
+}
+
+// splitVP9Superframe returns the frames contained into a superframe.
+// Specification: VP9 Bitstream & Decoding Process, Annex B
+func splitVP9Superframe(buf []byte) [][]byte {
+	if len(buf) == 0 {
+		return nil
+	}
+
+	marker := buf[len(buf)-1]
+	if (marker & 0xE0) != 0xC0 {
+		return nil
+	}
+
+	frameCount := int(marker&0x07) + 1
+	sizeLen := int((marker>>3)&0x03) + 1
+	indexLen := 2 + sizeLen*frameCount
+
+	if len(buf) < indexLen || buf[len(buf)-indexLen] != marker {
+		return nil
+	}
+
+	index := buf[len(buf)-indexLen+1 : len(buf)-1]
+	data := buf[:len(buf)-indexLen]
+	frames := make([][]byte, frameCount)
+
+	for i := range frameCount {
+		size := 0
+		for j := range sizeLen {
+			size |= int(index[i*sizeLen+j]) << (8 * j)
+		}
+
+		if size > len(data) {
+			return nil
+		}
+
+		frames[i] = data[:size]
+		data = data[size:]
+	}
+
+	return frames
+}
+
+// joinVP9Superframe builds a superframe from frames.
+func joinVP9Superframe(frames [][]byte) []byte {
+	if len(frames) == 1 {
+		return frames[0]
+	}
+
+	// always use 4-byte sizes
+	const sizeLen = 4
+	marker := byte(0xC0 | (sizeLen-1)<<3 | (len(frames) - 1))
+
+	n := 0
+	for _, frame := range frames {
+		n += len(frame)
+	}
+
+	buf := make([]byte, 0, n+2+sizeLen*len(frames))
+
+	for _, frame := range frames {
+		buf = append(buf, frame...)
+	}
+
+	buf = append(buf, marker)
+	for _, frame := range frames {
+		size := len(frame)
+		buf = append(buf, byte(size), byte(size>>8), byte(size>>16), byte(size>>24))
+	}
+	buf = append(buf, marker)
+
+	return buf
+}
@@ -0,0 +1,65 @@
+package webrtc
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVCFilterFromQuery(t *testing.T) {
+	f, err := SVCFilterFromQuery(url.Values{})
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	f, err = SVCFilterFromQuery(url.Values{"svcSpatialLayer": []string{"1"}})
+	require.NoError(t, err)
+	require.Equal(t, &SVCFilter{MaxSpatialLayer: 1, MaxTemporalLayer: -1}, f)
+
+	_, err = SVCFilterFromQuery(url.Values{"svcTemporalLayer": []string{"x"}})
+	require.Error(t, err)
+}
+
+func TestSVCFilterAV1(t *testing.T) {
+	temporalDelimiter := []byte{0x12, 0x00}
+	// frame OBUs with extension header
+	frameS0T0 := []byte{0x36, 0x00, 0x01, 0xAA}
+	frameS1T0 := []byte{0x36, 0x08, 0x01, 0xBB}
+	frameS0T1 := []byte{0x36, 0x20, 0x01, 0xCC}
+
+	f := &SVCFilter{MaxSpatialLayer: 0, MaxTemporalLayer: -1}
+
+	require.Equal(t, [][]byte{temporalDelimiter, frameS0T0},
+		f.FilterAV1([][]byte{temporalDelimiter, frameS0T0, frameS1T0}))
+
+	f = &SVCFilter{MaxSpatialLayer: -1, MaxTemporalLayer: 0}
+
+	require.Nil(t, f.FilterAV1([][]byte{temporalDelimiter, frameS0T1}))
+	require.Equal(t, [][]byte{temporalDelimiter, frameS0T0, frameS1T0},
+		f.FilterAV1([][]byte{temporalDelimiter, frameS0T0, frameS1T0}))
+}
+
+func TestSVCFilterVP9(t *testing.T) {
+	superframe := []byte{
+		0x01, 0x02, // spatial layer 0
+		0x03, 0x04, 0x05, // spatial layer 1
+		0x06, // spatial layer 2
+		0xc2, 0x02, 0x03, 0x01, 0xc2,
+	}
+
+	f := &SVCFilter{MaxSpatialLayer: 0, MaxTemporalLayer: -1}
+	require.Equal(t, []byte{0x01, 0x02}, f.FilterVP9(superframe))
+
+	f = &SVCFilter{MaxSpatialLayer: 1, MaxTemporalLayer: -1}
+	require.Equal(t, []byte{
+		0x01, 0x02,
+		0x03, 0x04, 0x05,
+		0xd9, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0xd9,
+	}, f.FilterVP9(superframe))
+
+	f = &SVCFilter{MaxSpatialLayer: 2, MaxTemporalLayer: -1}
+	require.Equal(t, superframe, f.FilterVP9(superframe))
+
+	// plain frames are not modified
+	require.Equal(t, []byte{0x01, 0x02}, f.FilterVP9([]byte{0x01, 0x02}))
+}
@@ -29,17 +29,58 @@ var errNoSupportedCodecsTo = errors.New(
 		"AV1, VP9, VP8, H265, H264, Opus, G722, G711, LPCM")
 
 // ToStream maps a WebRTC connection to a MediaMTX stream.
+// In case of simulcast, the first layer is mapped.
 func ToStream(
 	pc *PeerConnection,
 	pathConf *conf.Path,
 	strm **stream.Stream,
 	log logger.Writer,
+) ([]*description.Media, error) {
+	var firstRID string
+	if rids := pc.IncomingRIDs(); len(rids) != 0 {
+		firstRID = rids[0]
+	}
+
+	var tracks []*IncomingTrack
+	for _, track := range pc.incomingTracks {
+		if track.RID() == "" || track.RID() == firstRID {
+			tracks = append(tracks, track)
+		}
+	}
+
+	return toStream(tracks, pathConf, strm, log)
+}
+
+// ToStreamLayer maps a simulcast layer of a WebRTC connection to a MediaMTX stream.
+// The stream contains the video of the layer and the tracks that are not simulcast.
+func ToStreamLayer(
+	pc *PeerConnection,
+	rid string,
+	pathConf *conf.Path,
+	strm **stream.Stream,
+	log logger.Writer,
+) ([]*description.Media, error) {
+	var tracks []*IncomingTrack
+	for _, track := range pc.incomingTracks {
+		if track.RID() == "" || track.RID() == rid {
+			tracks = append(tracks, track)
+		}
+	}
+
+	return toStream(tracks, pathConf, strm, log)
+}
+
+func toStream(
+	tracks []*IncomingTrack,
+	pathConf *conf.Path,
+	strm **stream.Stream,
+	log logger.Writer,
 ) ([]*description.Media, error) {
 	var medias []*description.Media //nolint:prealloc
 	timeDecoder := &rtptime.GlobalDecoder{}
 	timeDecoder.Initialize()
 
-	for _, track := range pc.incomingTracks {
+	for _, track := range tracks {
 		var typ description.MediaType
 		var forma format.Format
 
@@ -185,7 +226,12 @@ func ToStream(
 			}
 		}
 
+		// a track can be mapped to multiple streams (i.e. the path and a simulcast layer)
+		prevOnPacketRTP := track.OnPacketRTP
+
 		track.OnPacketRTP = func(pkt *rtp.Packet) {
+			prevOnPacketRTP(pkt)
+
 			pts, ok := timeDecoder.Decode(track, pkt)
 			if !ok {
 				return
@@ -196,7 +242,10 @@ func ToStream(
 				return
 			}
 
-			(*strm).WriteRTPPacket(medi, forma, pkt, ntp, pts)
+			// packets are processed by each stream, do not share them
+			pkt2 := *pkt
+
+			(*strm).WriteRTPPacket(medi, forma, &pkt2, ntp, pts)
 		}
 
 		medias = append(medias, medi)
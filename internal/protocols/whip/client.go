@@ -26,12 +26,16 @@ const (
 
 // Client is a WHIP client.
 type Client struct {
-	URL               *url.URL
-	Publish           bool
-	OutgoingTracks    []*webrtc.OutgoingTrack
-	HTTPClient        *http.Client
-	UDPReadBufferSize uint
-	Log               logger.Writer
+	URL                *url.URL
+	Publish            bool
+	OutgoingTracks     []*webrtc.OutgoingTrack
+	HTTPClient         *http.Client
+	UDPReadBufferSize  uint
+	BearerToken        string
+	Header             http.Header
+	ICEServers         []pwebrtc.ICEServer
+	ICETransportPolicy pwebrtc.ICETransportPolicy
+	Log                logger.Writer
 
 	pc               *webrtc.PeerConnection
 	patchIsSupported bool
@@ -44,10 +48,14 @@ func (c *Client) Initialize(ctx context.Context) error {
 		return err
 	}
 
+	// servers provided by the user come before the ones advertised by the server
+	iceServers = append(append([]pwebrtc.ICEServer(nil), c.ICEServers...), iceServers...)
+
 	c.pc = &webrtc.PeerConnection{
 		UDPReadBufferSize:  c.UDPReadBufferSize,
 		LocalRandomUDP:     true,
 		ICEServers:         iceServers,
+		ICETransportPolicy: c.ICETransportPolicy,
 		IPsFromInterfaces:  true,
 		HandshakeTimeout:   conf.Duration(10 * time.Second),
 		TrackGatherTimeout: conf.Duration(2 * time.Second),
@@ -183,10 +191,29 @@ func (c *Client) Wait() error {
 	return fmt.Errorf("peer connection closed")
 }
 
+func (c *Client) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range c.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	}
+
+	return req, nil
+}
+
 func (c *Client) optionsICEServers(
 	ctx context.Context,
 ) ([]pwebrtc.ICEServer, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, c.URL.String(), nil)
+	req, err := c.newRequest(ctx, http.MethodOptions, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +241,7 @@ func (c *Client) postOffer(
 	ctx context.Context,
 	offer *pwebrtc.SessionDescription,
 ) (*whipPostOfferResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL.String(), bytes.NewReader([]byte(offer.SDP)))
+	req, err := c.newRequest(ctx, http.MethodPost, bytes.NewReader([]byte(offer.SDP)))
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +304,7 @@ func (c *Client) patchCandidate(
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, c.URL.String(), bytes.NewReader(frag))
+	req, err := c.newRequest(ctx, http.MethodPatch, bytes.NewReader(frag))
 	if err != nil {
 		return err
 	}
@@ -301,7 +328,7 @@ func (c *Client) patchCandidate(
 func (c *Client) deleteSession(
 	ctx context.Context,
 ) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.URL.String(), nil)
+	req, err := c.newRequest(ctx, http.MethodDelete, nil)
 	if err != nil {
 		return err
 	}
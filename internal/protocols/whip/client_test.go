@@ -442,3 +442,56 @@ func TestClientPublish(t *testing.T) {
 		})
 	}
 }
+
+func TestClientHeaders(t *testing.T) {
+	var methods []string
+
+	httpServ := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			require.Equal(t, "Bearer mytoken", r.Header.Get("Authorization"))
+			require.Equal(t, "myvalue", r.Header.Get("X-Custom"))
+
+			switch r.Method {
+			case http.MethodOptions:
+				w.WriteHeader(http.StatusNoContent)
+
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}),
+	}
+
+	ln, err := net.Listen("tcp", "localhost:9005")
+	require.NoError(t, err)
+
+	go httpServ.Serve(ln)
+	defer httpServ.Shutdown(context.Background())
+
+	u, err := url.Parse("http://localhost:9005/my/resource")
+	require.NoError(t, err)
+
+	cl := &Client{
+		URL:         u,
+		Publish:     true,
+		HTTPClient:  &http.Client{},
+		BearerToken: "mytoken",
+		Header:      http.Header{"X-Custom": []string{"myvalue"}},
+		OutgoingTracks: []*webrtc.OutgoingTrack{{
+			Caps: pwebrtc.RTPCodecCapability{
+				MimeType:  "audio/opus",
+				ClockRate: 48000,
+				Channels:  2,
+			},
+		}},
+		ICEServers: []pwebrtc.ICEServer{{
+			URLs: []string{"stun:localhost:3478"},
+		}},
+		ICETransportPolicy: pwebrtc.ICETransportPolicyRelay,
+		Log:                test.NilLogger,
+	}
+	err = cl.Initialize(context.Background())
+	require.EqualError(t, err, "bad status code: 401")
+
+	require.Equal(t, []string{http.MethodOptions, http.MethodPost}, methods)
+}
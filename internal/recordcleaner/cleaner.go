@@ -3,6 +3,7 @@ package recordcleaner
 
 import (
 	"context"
+	"errors"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -12,13 +13,20 @@ import (
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/bluenviron/mediamtx/internal/recorduploader"
 )
 
+// segments that have been modified recently may still be written.
+const uploadMinIdleTime = 5 * time.Minute
+
 var timeNow = time.Now
 
-// Cleaner removes expired recording segments from disk.
+// Cleaner removes expired recording segments from disk and from object storage,
+// removes local copies of segments that have been uploaded,
+// and uploads segments that are missing from object storage.
 type Cleaner struct {
 	PathConfs map[string]*conf.Path
+	Uploader  *recorduploader.Uploader
 	Parent    logger.Writer
 
 	ctx       context.Context
@@ -83,6 +91,11 @@ func (c *Cleaner) cleanInterval() time.Duration {
 			interval > (time.Duration(e.RecordDeleteAfter)/2) {
 			interval = time.Duration(e.RecordDeleteAfter) / 2
 		}
+
+		if e.RecordUploadKeepLocal != 0 &&
+			interval > (time.Duration(e.RecordUploadKeepLocal)/2) {
+			interval = time.Duration(e.RecordUploadKeepLocal) / 2
+		}
 	}
 
 	return interval
@@ -104,13 +117,20 @@ func (c *Cleaner) processPath(now time.Time, pathName string) error {
 		return err
 	}
 
-	if pathConf.RecordDeleteAfter == 0 {
+	if pathConf.RecordDeleteAfter == 0 && pathConf.RecordUploadURL == "" {
 		return nil
 	}
 
-	err = c.deleteExpiredSegments(now, pathName, pathConf)
-	if err != nil {
-		return err
+	if pathConf.RecordDeleteAfter != 0 {
+		c.deleteExpiredSegments(now, pathName, pathConf)
+	}
+
+	if pathConf.RecordUploadURL != "" && c.Uploader != nil {
+		c.uploadMissingSegments(pathName, pathConf)
+	}
+
+	if pathConf.RecordUploadKeepLocal != 0 {
+		c.deleteUploadedSegments(now, pathName, pathConf)
 	}
 
 	c.deleteEmptyDirs(pathConf)
@@ -118,19 +138,93 @@ func (c *Cleaner) processPath(now time.Time, pathName string) error {
 	return nil
 }
 
-func (c *Cleaner) deleteExpiredSegments(now time.Time, pathName string, pathConf *conf.Path) error {
+func (c *Cleaner) deleteExpiredSegments(now time.Time, pathName string, pathConf *conf.Path) {
 	end := now.Add(-time.Duration(pathConf.RecordDeleteAfter))
-	segments, err := recordstore.FindSegments(pathConf, pathName, nil, &end)
+
+	segments, err := recordstore.FindLocalSegments(pathConf, pathName, nil, &end)
+	if err == nil {
+		for _, seg := range segments {
+			c.Log(logger.Debug, "removing %s", seg.Fpath)
+			os.Remove(seg.Fpath)
+		}
+	}
+
+	segments, err = recordstore.FindRemoteSegments(pathConf, pathName, nil, &end)
 	if err != nil {
-		return err
+		if !errors.Is(err, recordstore.ErrNoSegmentsFound) {
+			c.Log(logger.Error, "unable to list segments in object storage: %v", err)
+		}
+		return
 	}
 
 	for _, seg := range segments {
-		c.Log(logger.Debug, "removing %s", seg.Fpath)
-		os.Remove(seg.Fpath)
+		c.Log(logger.Debug, "removing %s from object storage", seg.RemoteKey)
+		err = seg.DeleteRemote()
+		if err != nil {
+			c.Log(logger.Error, "unable to remove %s from object storage: %v", seg.RemoteKey, err)
+		}
 	}
+}
 
-	return nil
+// uploadMissingSegments adds to the upload queue the segments that have not been uploaded,
+// like the ones that were in queue when the server was closed.
+func (c *Cleaner) uploadMissingSegments(pathName string, pathConf *conf.Path) {
+	local, err := recordstore.FindLocalSegments(pathConf, pathName, nil, nil)
+	if err != nil {
+		return
+	}
+
+	remote, err := recordstore.FindRemoteSegments(pathConf, pathName, nil, nil)
+	if err != nil && !errors.Is(err, recordstore.ErrNoSegmentsFound) {
+		c.Log(logger.Error, "unable to list segments in object storage: %v", err)
+		return
+	}
+
+	uploadedSizes := make(map[int64]int64, len(remote))
+	for _, seg := range remote {
+		uploadedSizes[seg.Start.UnixNano()] = seg.RemoteSize
+	}
+
+	for _, seg := range local {
+		fi, err := os.Stat(seg.Fpath)
+		if err != nil || time.Since(fi.ModTime()) < uploadMinIdleTime {
+			continue
+		}
+
+		if size, ok := uploadedSizes[seg.Start.UnixNano()]; ok && size == fi.Size() {
+			continue
+		}
+
+		c.Log(logger.Debug, "uploading %s, since it is missing from object storage", seg.Fpath)
+		c.Uploader.Upload(pathConf, pathName, seg.Fpath)
+	}
+}
+
+func (c *Cleaner) deleteUploadedSegments(now time.Time, pathName string, pathConf *conf.Path) {
+	end := now.Add(-time.Duration(pathConf.RecordUploadKeepLocal))
+
+	segments, err := recordstore.FindSegments(pathConf, pathName, nil, &end, c)
+	if err != nil {
+		if !errors.Is(err, recordstore.ErrNoSegmentsFound) {
+			c.Log(logger.Error, "unable to list segments: %v", err)
+		}
+		return
+	}
+
+	for _, seg := range segments {
+		if seg.Fpath == "" || seg.RemoteKey == "" {
+			continue
+		}
+
+		// make sure that the uploaded copy is complete
+		fi, err := os.Stat(seg.Fpath)
+		if err != nil || fi.Size() != seg.RemoteSize {
+			continue
+		}
+
+		c.Log(logger.Debug, "removing %s, since it has been uploaded", seg.Fpath)
+		os.Remove(seg.Fpath)
+	}
 }
 
 func (c *Cleaner) deleteEmptyDirs(pathConf *conf.Path) {
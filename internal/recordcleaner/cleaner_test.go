@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/recorduploader"
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/stretchr/testify/require"
 )
@@ -108,3 +109,147 @@ func TestCleanerMultipleEntriesSamePath(t *testing.T) {
 	_, err = os.Stat(filepath.Join(dir, "path2", "2009-05-19_22-15-25-000427.mp4"))
 	require.NoError(t, err)
 }
+
+func TestCleanerUpload(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2009, 5, 20, 22, 15, 25, 427000, time.Local)
+	}
+
+	dir, err := os.MkdirTemp("", "mediamtx-cleaner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	// uploaded
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2009-05-19_22-15-25-000427.mp4"), []byte{1, 2}, 0o644)
+	require.NoError(t, err)
+
+	// upload is not complete
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2009-05-19_23-15-25-000427.mp4"), []byte{1, 2}, 0o644)
+	require.NoError(t, err)
+
+	// not uploaded
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2009-05-20_00-15-25-000427.mp4"), []byte{1, 2}, 0o644)
+	require.NoError(t, err)
+
+	s := &test.S3Server{Bucket: "mybucket"}
+	s.Initialize()
+	defer s.Close()
+
+	s.SetObject("mypath/2008-05-19_22-15-25-000427.mp4", []byte{1, 2})
+	s.SetObject("mypath/2009-05-19_22-15-25-000427.mp4", []byte{1, 2})
+	s.SetObject("mypath/2009-05-19_23-15-25-000427.mp4", []byte{1})
+
+	c := &Cleaner{
+		PathConfs: map[string]*conf.Path{
+			"mypath": {
+				Name:                  "mypath",
+				RecordPath:            filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+				RecordFormat:          conf.RecordFormatFMP4,
+				RecordDeleteAfter:     conf.Duration(100 * 24 * time.Hour),
+				RecordUploadURL:       s.URL(),
+				RecordUploadKeepLocal: conf.Duration(10 * time.Second),
+			},
+		},
+		Parent: test.NilLogger,
+	}
+	c.Initialize()
+	defer c.Close()
+
+	time.Sleep(500 * time.Millisecond)
+
+	_, err = os.Stat(filepath.Join(dir, "mypath", "2009-05-19_22-15-25-000427.mp4"))
+	require.Error(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "mypath", "2009-05-19_23-15-25-000427.mp4"))
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "mypath", "2009-05-20_00-15-25-000427.mp4"))
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][]byte{
+		"mypath/2009-05-19_22-15-25-000427.mp4": {1, 2},
+		"mypath/2009-05-19_23-15-25-000427.mp4": {1},
+	}, s.Objects())
+}
+
+func TestCleanerUploadMissing(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2009, 5, 20, 22, 15, 25, 427000, time.Local)
+	}
+
+	dir, err := os.MkdirTemp("", "mediamtx-cleaner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	// not uploaded
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2009-05-19_22-15-25-000427.mp4"), []byte{1, 2}, 0o644)
+	require.NoError(t, err)
+
+	// upload is not complete
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2009-05-19_23-15-25-000427.mp4"), []byte{3, 4}, 0o644)
+	require.NoError(t, err)
+
+	// uploaded
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2009-05-20_00-15-25-000427.mp4"), []byte{5, 6}, 0o644)
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	for _, name := range []string{
+		"2009-05-19_22-15-25-000427.mp4",
+		"2009-05-19_23-15-25-000427.mp4",
+		"2009-05-20_00-15-25-000427.mp4",
+	} {
+		err = os.Chtimes(filepath.Join(dir, "mypath", name), past, past)
+		require.NoError(t, err)
+	}
+
+	// may still be written
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2009-05-20_01-15-25-000427.mp4"), []byte{7, 8}, 0o644)
+	require.NoError(t, err)
+
+	s := &test.S3Server{Bucket: "mybucket"}
+	s.Initialize()
+	defer s.Close()
+
+	s.SetObject("mypath/2009-05-19_23-15-25-000427.mp4", []byte{3})
+	s.SetObject("mypath/2009-05-20_00-15-25-000427.mp4", []byte{5, 6})
+
+	u := &recorduploader.Uploader{Parent: test.NilLogger}
+	u.Initialize()
+	defer u.Close()
+
+	c := &Cleaner{
+		PathConfs: map[string]*conf.Path{
+			"mypath": {
+				Name:            "mypath",
+				RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+				RecordFormat:    conf.RecordFormatFMP4,
+				RecordUploadURL: s.URL(),
+			},
+		},
+		Uploader: u,
+		Parent:   test.NilLogger,
+	}
+	c.Initialize()
+	defer c.Close()
+
+	require.Eventually(t, func() bool {
+		return len(s.Objects()["mypath/2009-05-19_23-15-25-000427.mp4"]) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return len(s.Objects()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, map[string][]byte{
+		"mypath/2009-05-19_22-15-25-000427.mp4": {1, 2},
+		"mypath/2009-05-19_23-15-25-000427.mp4": {3, 4},
+		"mypath/2009-05-20_00-15-25-000427.mp4": {5, 6},
+	}, s.Objects())
+}
@@ -111,6 +111,9 @@ type formatFMP4 struct {
 	hasVideo          bool
 	currentSegment    *formatFMP4Segment
 	nextSegmentNumber uint64
+	buffer            []*formatFMP4BufferedSample
+	recording         bool
+	recordUntil       time.Time
 }
 
 func (f *formatFMP4) initialize() bool {
@@ -96,6 +96,14 @@ func (t *formatFMP4Track) write(sample *formatFMP4Sample) error {
 		}
 	}
 
+	if t.f.ri.trigger != nil {
+		return t.f.writeOnTrigger(t, sample, dts)
+	}
+
+	return t.writeToSegment(sample, dts, true)
+}
+
+func (t *formatFMP4Track) writeToSegment(sample *formatFMP4Sample, dts time.Duration, canSwitch bool) error {
 	if t.f.currentSegment == nil {
 		t.f.currentSegment = &formatFMP4Segment{
 			f:        t.f,
@@ -117,7 +125,8 @@ func (t *formatFMP4Track) write(sample *formatFMP4Sample) error {
 
 	nextDTS := timestampToDuration(t.nextSample.dts, int(t.initTrack.TimeScale))
 
-	if (!t.f.hasVideo || t.initTrack.Codec.IsVideo()) &&
+	if canSwitch &&
+		(!t.f.hasVideo || t.initTrack.Codec.IsVideo()) &&
 		!t.nextSample.IsNonSyncSample &&
 		(nextDTS-t.f.currentSegment.startDTS) >= t.f.ri.segmentDuration {
 		err = t.f.currentSegment.close()
@@ -0,0 +1,126 @@
+package recorder
+
+import (
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+type formatFMP4BufferedSample struct {
+	track  *formatFMP4Track
+	sample *formatFMP4Sample
+	dts    time.Duration
+}
+
+func (s *formatFMP4BufferedSample) isRandomAccess(hasVideo bool) bool {
+	if !hasVideo {
+		return true
+	}
+	return s.track.initTrack.Codec.IsVideo() && !s.sample.IsNonSyncSample
+}
+
+// writeOnTrigger writes a sample when recording on trigger.
+// Until a trigger is received, samples are kept in a buffer that starts from a random access point
+// and covers at least the pre-roll. When a trigger is received, the buffer is written into a new segment.
+func (f *formatFMP4) writeOnTrigger(track *formatFMP4Track, sample *formatFMP4Sample, dts time.Duration) error {
+	triggered, before, after := f.ri.trigger.pop()
+
+	if !f.recording {
+		f.buffer = append(f.buffer, &formatFMP4BufferedSample{
+			track:  track,
+			sample: sample,
+			dts:    dts,
+		})
+		f.trimBuffer()
+
+		if !triggered {
+			return nil
+		}
+
+		f.ri.Log(logger.Debug, "trigger received, writing %v of buffered stream", before)
+
+		f.recording = true
+		f.recordUntil = sample.ntp.Add(after)
+
+		return f.flushBuffer(before)
+	}
+
+	if triggered {
+		until := sample.ntp.Add(after)
+		if until.After(f.recordUntil) {
+			f.recordUntil = until
+		}
+	}
+
+	err := track.writeToSegment(sample, dts, true)
+	if err != nil {
+		return err
+	}
+
+	// stop recording at the first random access point after the end of triggers
+	if (!f.hasVideo || track.initTrack.Codec.IsVideo()) &&
+		!track.nextSample.IsNonSyncSample &&
+		track.nextSample.ntp.After(f.recordUntil) {
+		f.recording = false
+
+		err = f.currentSegment.close()
+		f.currentSegment = nil
+		return err
+	}
+
+	return nil
+}
+
+// trimBuffer removes samples that are not needed to cover the pre-roll.
+func (f *formatFMP4) trimBuffer() {
+	newest := f.buffer[len(f.buffer)-1].sample.ntp
+	start := 0
+
+	for i, s := range f.buffer {
+		if newest.Sub(s.sample.ntp) < f.ri.preRoll {
+			break
+		}
+		if s.isRandomAccess(f.hasVideo) {
+			start = i
+		}
+	}
+
+	if start != 0 {
+		f.buffer = f.buffer[start:]
+	}
+}
+
+// flushBuffer writes buffered samples into a segment,
+// starting from the last random access point that precedes the newest sample by at least before.
+func (f *formatFMP4) flushBuffer(before time.Duration) error {
+	buffer := f.buffer
+	f.buffer = nil
+
+	newest := buffer[len(buffer)-1].sample.ntp
+	start := 0
+
+	for i, s := range buffer {
+		if newest.Sub(s.sample.ntp) < before {
+			break
+		}
+		if s.isRandomAccess(f.hasVideo) {
+			start = i
+		}
+	}
+
+	startDTS := buffer[start].dts
+
+	for _, s := range buffer[start:] {
+		// samples of other tracks that precede the starting point would have a negative BaseTime
+		if s.dts < startDTS {
+			continue
+		}
+
+		err := s.track.writeToSegment(s.sample, s.dts, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@
 package recorder
 
 import (
+	"sync"
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
@@ -19,6 +20,38 @@ type OnSegmentCreateFunc = func(path string)
 // OnSegmentCompleteFunc is the prototype of the function passed as OnSegmentComplete
 type OnSegmentCompleteFunc = func(path string, duration time.Duration)
 
+// recorderTrigger is a trigger that has not been processed yet.
+type recorderTrigger struct {
+	mutex   sync.Mutex
+	pending bool
+	before  time.Duration
+	after   time.Duration
+}
+
+func (t *recorderTrigger) set(before time.Duration, after time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// merge with the pending trigger
+	if t.pending {
+		before = max(before, t.before)
+		after = max(after, t.after)
+	}
+
+	t.pending = true
+	t.before = before
+	t.after = after
+}
+
+func (t *recorderTrigger) pop() (bool, time.Duration, time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	pending := t.pending
+	t.pending = false
+	return pending, t.before, t.after
+}
+
 // Recorder writes recordings to disk.
 type Recorder struct {
 	PathFormat        string
@@ -32,8 +65,14 @@ type Recorder struct {
 	OnSegmentComplete OnSegmentCompleteFunc
 	Parent            logger.Writer
 
+	// when enabled, segments are written only around triggers,
+	// and the last PreRoll of the stream is kept in memory.
+	OnTrigger bool
+	PreRoll   time.Duration
+
 	restartPause time.Duration
 
+	trigger         *recorderTrigger
 	currentInstance *recorderInstance
 
 	terminate chan struct{}
@@ -54,6 +93,10 @@ func (r *Recorder) Initialize() {
 		r.restartPause = 2 * time.Second
 	}
 
+	if r.OnTrigger {
+		r.trigger = &recorderTrigger{}
+	}
+
 	r.terminate = make(chan struct{})
 	r.done = make(chan struct{})
 
@@ -67,6 +110,8 @@ func (r *Recorder) Initialize() {
 		stream:            r.Stream,
 		onSegmentCreate:   r.OnSegmentCreate,
 		onSegmentComplete: r.OnSegmentComplete,
+		trigger:           r.trigger,
+		preRoll:           r.PreRoll,
 		parent:            r,
 	}
 	r.currentInstance.initialize()
@@ -86,6 +131,17 @@ func (r *Recorder) Close() {
 	<-r.done
 }
 
+// Trigger starts writing a segment that includes the stream received in the last
+// before, and that lasts until after has passed.
+// If a segment is already being written, it is extended.
+func (r *Recorder) Trigger(before time.Duration, after time.Duration) {
+	if r.trigger == nil {
+		return
+	}
+
+	r.trigger.set(min(before, r.PreRoll), after)
+}
+
 func (r *Recorder) run() {
 	defer close(r.done)
 
@@ -114,6 +170,8 @@ func (r *Recorder) run() {
 			stream:            r.Stream,
 			onSegmentCreate:   r.OnSegmentCreate,
 			onSegmentComplete: r.OnSegmentComplete,
+			trigger:           r.trigger,
+			preRoll:           r.PreRoll,
 			parent:            r,
 		}
 		r.currentInstance.initialize()
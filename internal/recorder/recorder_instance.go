@@ -22,6 +22,8 @@ type recorderInstance struct {
 	stream            *stream.Stream
 	onSegmentCreate   OnSegmentCreateFunc
 	onSegmentComplete OnSegmentCompleteFunc
+	trigger           *recorderTrigger
+	preRoll           time.Duration
 	parent            logger.Writer
 
 	streamID    uuid.UUID
@@ -59,8 +61,14 @@ func (ri *recorderInstance) initialize() {
 		ri.format2 = &formatMPEGTS{
 			ri: ri,
 		}
-		ok := ri.format2.initialize()
-		ri.skip = !ok
+
+		if ri.trigger != nil {
+			ri.Log(logger.Warn, "recording on trigger is supported with the fMP4 format only, skipping recording")
+			ri.skip = true
+		} else {
+			ok := ri.format2.initialize()
+			ri.skip = !ok
+		}
 
 	default:
 		ri.format2 = &formatFMP4{
@@ -1042,3 +1042,134 @@ func TestRecorderTimeDriftDetector(t *testing.T) {
 		})
 	}
 }
+
+func TestRecorderFMP4OnTrigger(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []rtspformat.Format{test.FormatH264},
+		},
+		{
+			Type:    description.MediaTypeAudio,
+			Formats: []rtspformat.Format{test.FormatMPEG4Audio},
+		},
+	}}
+
+	strm := &stream.Stream{
+		WriteQueueSize:     512,
+		RTPMaxPayloadSize:  1450,
+		Desc:               desc,
+		GenerateRTPPackets: true,
+		Parent:             test.NilLogger,
+	}
+	err := strm.Initialize()
+	require.NoError(t, err)
+	defer strm.Close()
+
+	dir, err := os.MkdirTemp("", "mediamtx-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	type segment struct {
+		path     string
+		duration time.Duration
+	}
+
+	segDone := make(chan segment, 10)
+
+	w := &Recorder{
+		PathFormat:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		Format:          conf.RecordFormatFMP4,
+		PartDuration:    100 * time.Millisecond,
+		MaxPartSize:     50 * 1024 * 1024,
+		SegmentDuration: 1 * time.Hour,
+		PathName:        "mypath",
+		Stream:          strm,
+		Parent:          test.NilLogger,
+		OnTrigger:       true,
+		PreRoll:         4 * time.Second,
+		OnSegmentComplete: func(segPath string, duration time.Duration) {
+			segDone <- segment{path: segPath, duration: duration}
+		},
+	}
+	w.Initialize()
+
+	ntp := time.Date(2008, 5, 20, 22, 15, 25, 0, time.UTC)
+
+	writeSecond := func(i int) {
+		pts := 10*time.Second + time.Duration(i)*time.Second
+
+		// IDR every 3 seconds
+		payload := unit.PayloadH264{{1}}
+		if i%3 == 0 {
+			payload = unit.PayloadH264{{5}}
+		}
+
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.Unit{
+			PTS:     int64(pts) * 90000 / int64(time.Second),
+			NTP:     ntp.Add(time.Duration(i) * time.Second),
+			Payload: payload,
+		})
+
+		// audio precedes video by 200ms
+		strm.WriteUnit(desc.Medias[1], desc.Medias[1].Formats[0], &unit.Unit{
+			PTS:     int64(pts-200*time.Millisecond) * 44100 / int64(time.Second),
+			NTP:     ntp.Add(time.Duration(i)*time.Second - 200*time.Millisecond),
+			Payload: unit.PayloadMPEG4Audio{{1, 2}},
+		})
+	}
+
+	for i := range 10 {
+		writeSecond(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	w.Trigger(4*time.Second, 3*time.Second)
+
+	for i := 10; i < 20; i++ {
+		writeSecond(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	w.Close()
+	close(segDone)
+
+	var completed []segment
+	for seg := range segDone {
+		completed = append(completed, seg)
+	}
+
+	require.Equal(t, []segment{{
+		path:     filepath.Join(dir, "mypath", "2008-05-20_22-15-28-000000.mp4"),
+		duration: 12 * time.Second,
+	}}, completed)
+
+	entries, err := os.ReadDir(filepath.Join(dir, "mypath"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	byts, err := os.ReadFile(completed[0].path)
+	require.NoError(t, err)
+
+	var parts fmp4.Parts
+	err = parts.Unmarshal(byts)
+	require.NoError(t, err)
+
+	sampleCount := make(map[int]int)
+
+	for _, part := range parts {
+		for _, track := range part.Tracks {
+			if sampleCount[track.ID] == 0 && track.ID == 1 {
+				require.False(t, track.Samples[0].IsNonSyncSample)
+			}
+			sampleCount[track.ID] += len(track.Samples)
+		}
+	}
+
+	require.Equal(t, map[int]int{
+		1: 12,
+		2: 10,
+	}, sampleCount)
+}
@@ -0,0 +1,176 @@
+package recordstore
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/protocols/s3"
+)
+
+const (
+	remoteRequestTimeout = 30 * time.Second
+)
+
+// RemoteClient returns a client for the bucket where segments of a path are uploaded,
+// and the prefix of keys inside the bucket.
+// It returns a nil client when uploads are disabled.
+func RemoteClient(pathConf *conf.Path) (*s3.Client, string, error) {
+	if pathConf.RecordUploadURL == "" {
+		return nil, "", nil
+	}
+
+	u, err := url.Parse(pathConf.RecordUploadURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid 'recordUploadURL': %w", err)
+	}
+
+	// first path element is the bucket, remaining ones are the key prefix
+	bucket, prefix, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	u.Path = "/" + bucket
+	u.RawPath = ""
+	u.RawQuery = ""
+
+	return &s3.Client{
+		URL:             u,
+		Region:          pathConf.RecordUploadRegion,
+		AccessKeyID:     pathConf.RecordUploadAccessKeyID,
+		SecretAccessKey: pathConf.RecordUploadSecretAccessKey,
+	}, prefix, nil
+}
+
+func joinKey(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
+
+// RemoteKey returns the key of a segment inside the bucket.
+// Keys mirror the position of segments relative to the fixed part of the record path.
+func RemoteKey(pathConf *conf.Path, fpath string) (string, error) {
+	_, prefix, err := RemoteClient(pathConf)
+	if err != nil {
+		return "", err
+	}
+
+	commonPath, _ := filepath.Abs(CommonPath(pathConf.RecordPath))
+	fpath, _ = filepath.Abs(fpath)
+
+	rel, err := filepath.Rel(commonPath, fpath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("segment '%s' is outside of the record path", fpath)
+	}
+
+	return joinKey(prefix, filepath.ToSlash(rel)), nil
+}
+
+// remoteRecordPath returns the record path, as seen inside the bucket.
+func remoteRecordPath(pathConf *conf.Path, prefix string) string {
+	recordPath := filepath.ToSlash(pathConf.RecordPath)
+	rest := strings.TrimLeft(recordPath[len(filepath.ToSlash(CommonPath(pathConf.RecordPath))):], "/")
+	return joinKey(prefix, PathAddExtension(rest, pathConf.RecordFormat))
+}
+
+func listRemote(client *s3.Client, recordPath string) ([]*s3.Object, error) {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), remoteRequestTimeout)
+	defer ctxCancel()
+
+	listPrefix := CommonPath(recordPath)
+	if listPrefix != "" {
+		listPrefix += "/"
+	}
+
+	return client.ListObjects(ctx, listPrefix)
+}
+
+func remotePathsWithSegments(pathConf *conf.Path) (map[string]struct{}, error) {
+	client, prefix, err := RemoteClient(pathConf)
+	if err != nil || client == nil {
+		return nil, err
+	}
+
+	recordPath := remoteRecordPath(pathConf, prefix)
+	if pathConf.Regexp == nil {
+		recordPath = strings.ReplaceAll(recordPath, "%path", pathConf.Name)
+	}
+
+	objects, err := listRemote(client, recordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]struct{})
+
+	for _, obj := range objects {
+		var pa Path
+		if ok := pa.Decode(recordPath, obj.Key); ok {
+			if pathConf.Regexp == nil {
+				ret[pathConf.Name] = struct{}{}
+			} else if err = conf.IsValidPathName(pa.Path); err == nil {
+				if pathConf.Regexp.FindStringSubmatch(pa.Path) != nil {
+					ret[pa.Path] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// FindRemoteSegments returns segments of a path that have been uploaded.
+// Segments can be filtered by start date and end date.
+func FindRemoteSegments(
+	pathConf *conf.Path,
+	pathName string,
+	start *time.Time,
+	end *time.Time,
+) ([]*Segment, error) {
+	segments, err := findRemoteSegments(pathConf, pathName, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterSegments(segments, start)
+}
+
+func findRemoteSegments(
+	pathConf *conf.Path,
+	pathName string,
+	end *time.Time,
+) ([]*Segment, error) {
+	client, prefix, err := RemoteClient(pathConf)
+	if err != nil || client == nil {
+		return nil, err
+	}
+
+	recordPath := strings.ReplaceAll(remoteRecordPath(pathConf, prefix), "%path", pathName)
+
+	objects, err := listRemote(client, recordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []*Segment
+
+	for _, obj := range objects {
+		var pa Path
+		ok := pa.Decode(recordPath, obj.Key)
+
+		// gather all segments that start before the end of the playback
+		if ok && (end == nil || !end.Before(pa.Start)) {
+			segments = append(segments, &Segment{
+				Start:        pa.Start,
+				RemoteKey:    obj.Key,
+				RemoteSize:   obj.Size,
+				remoteClient: client,
+			})
+		}
+	}
+
+	return segments, nil
+}
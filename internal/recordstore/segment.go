@@ -1,14 +1,19 @@
 package recordstore
 
 import (
+	"context"
 	"errors"
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/s3"
 )
 
 // ErrNoSegmentsFound is returned when no recording segments have been found.
@@ -18,8 +23,45 @@ var errFound = errors.New("found")
 
 // Segment is a recording segment.
 type Segment struct {
+	// path of the segment on disk.
+	// It is empty when the segment is available in object storage only.
 	Fpath string
 	Start time.Time
+	// key of the segment in object storage.
+	// It is empty when the segment has not been uploaded.
+	RemoteKey  string
+	RemoteSize int64
+
+	remoteClient *s3.Client
+}
+
+// SegmentReader allows to read a segment.
+type SegmentReader interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+}
+
+// Open opens the segment.
+// The copy on disk is preferred to the one in object storage.
+func (s *Segment) Open() (SegmentReader, error) {
+	if s.Fpath != "" {
+		return os.Open(s.Fpath)
+	}
+
+	return &s3.ObjectReader{
+		Context: context.Background(),
+		Client:  s.remoteClient,
+		Key:     s.RemoteKey,
+		Size:    s.RemoteSize,
+	}, nil
+}
+
+// DeleteRemote deletes the copy of the segment in object storage.
+func (s *Segment) DeleteRemote() error {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), remoteRequestTimeout)
+	defer ctxCancel()
+
+	return s.remoteClient.DeleteObject(ctx, s.RemoteKey)
 }
 
 func fixedPathHasSegments(pathConf *conf.Path) bool {
@@ -106,6 +148,13 @@ func FindAllPathsWithSegments(pathConfs map[string]*conf.Path) []string {
 				pathNames[name] = struct{}{}
 			}
 		}
+
+		// paths whose segments are available in object storage only.
+		// listing errors are ignored, in order not to hide local segments.
+		remoteNames, _ := remotePathsWithSegments(pathConf)
+		for name := range remoteNames {
+			pathNames[name] = struct{}{}
+		}
 	}
 
 	out := make([]string, len(pathNames))
@@ -119,13 +168,53 @@ func FindAllPathsWithSegments(pathConfs map[string]*conf.Path) []string {
 	return out
 }
 
-// FindSegments returns all segments of a path.
+// FindSegments returns all segments of a path,
+// including the ones that are available in object storage only.
 // Segments can be filtered by start date and end date.
+// When object storage cannot be listed, the error is logged and local segments are returned.
 func FindSegments(
 	pathConf *conf.Path,
 	pathName string,
 	start *time.Time,
 	end *time.Time,
+	l logger.Writer,
+) ([]*Segment, error) {
+	segments, err := findLocalSegments(pathConf, pathName, end)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteSegments, err := findRemoteSegments(pathConf, pathName, end)
+	if err != nil {
+		l.Log(logger.Warn, "unable to list segments of path '%s' in object storage: %v", pathName, err)
+		remoteSegments = nil
+	}
+
+	segments = mergeSegments(segments, remoteSegments)
+
+	return filterSegments(segments, start)
+}
+
+// FindLocalSegments returns segments of a path that are stored on disk.
+// Segments can be filtered by start date and end date.
+func FindLocalSegments(
+	pathConf *conf.Path,
+	pathName string,
+	start *time.Time,
+	end *time.Time,
+) ([]*Segment, error) {
+	segments, err := findLocalSegments(pathConf, pathName, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterSegments(segments, start)
+}
+
+func findLocalSegments(
+	pathConf *conf.Path,
+	pathName string,
+	end *time.Time,
 ) ([]*Segment, error) {
 	recordPath := PathAddExtension(
 		strings.ReplaceAll(pathConf.RecordPath, "%path", pathName),
@@ -160,9 +249,42 @@ func FindSegments(
 		return nil
 	})
 	if err != nil {
+		// local segments may have been deleted after being uploaded
+		if errors.Is(err, fs.ErrNotExist) && pathConf.RecordUploadURL != "" {
+			return nil, nil
+		}
 		return nil, err
 	}
 
+	return segments, nil
+}
+
+// mergeSegments merges local and remote segments.
+// Segments that are available in both locations are returned once.
+func mergeSegments(local []*Segment, remote []*Segment) []*Segment {
+	if len(remote) == 0 {
+		return local
+	}
+
+	byStart := make(map[int64]*Segment, len(local))
+	for _, seg := range local {
+		byStart[seg.Start.UnixNano()] = seg
+	}
+
+	for _, seg := range remote {
+		if localSeg, ok := byStart[seg.Start.UnixNano()]; ok {
+			localSeg.RemoteKey = seg.RemoteKey
+			localSeg.RemoteSize = seg.RemoteSize
+			localSeg.remoteClient = seg.remoteClient
+		} else {
+			local = append(local, seg)
+		}
+	}
+
+	return local
+}
+
+func filterSegments(segments []*Segment, start *time.Time) ([]*Segment, error) {
 	if segments == nil {
 		return nil, ErrNoSegmentsFound
 	}
@@ -1,6 +1,7 @@
 package recordstore
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -114,6 +116,7 @@ func TestFindSegments(t *testing.T) {
 				"path1",
 				start,
 				end,
+				test.NilLogger,
 			)
 			require.NoError(t, err)
 
@@ -141,3 +144,88 @@ func TestFindSegments(t *testing.T) {
 		})
 	}
 }
+
+func TestFindSegmentsRemote(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recordstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "path1"), 0o755)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "path1", "2016-05-19_22-15-25-000427.mp4"), []byte{1}, 0o644)
+	require.NoError(t, err)
+
+	s := &test.S3Server{Bucket: "mybucket"}
+	s.Initialize()
+	defer s.Close()
+
+	s.SetObject("recordings/path1/2015-05-19_22-15-25-000427.mp4", []byte{1, 2})
+	s.SetObject("recordings/path1/2016-05-19_22-15-25-000427.mp4", []byte{1})
+	s.SetObject("recordings/path2/2015-05-19_22-15-25-000427.mp4", []byte{1})
+
+	pathConf := &conf.Path{
+		Name:            "~^.*$",
+		Regexp:          regexp.MustCompile("^.*$"),
+		RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s.URL() + "/recordings",
+	}
+
+	paths := FindAllPathsWithSegments(map[string]*conf.Path{"~^.*$": pathConf})
+	require.Equal(t, []string{"path1", "path2"}, paths)
+
+	segments, err := FindSegments(pathConf, "path1", nil, nil, test.NilLogger)
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+
+	require.Equal(t, "", segments[0].Fpath)
+	require.Equal(t, time.Date(2015, 5, 19, 22, 15, 25, 427000, time.Local), segments[0].Start)
+	require.Equal(t, "recordings/path1/2015-05-19_22-15-25-000427.mp4", segments[0].RemoteKey)
+	require.Equal(t, int64(2), segments[0].RemoteSize)
+
+	require.Equal(t, filepath.Join(dir, "path1", "2016-05-19_22-15-25-000427.mp4"), segments[1].Fpath)
+	require.Equal(t, time.Date(2016, 5, 19, 22, 15, 25, 427000, time.Local), segments[1].Start)
+	require.Equal(t, "recordings/path1/2016-05-19_22-15-25-000427.mp4", segments[1].RemoteKey)
+
+	r, err := segments[0].Open()
+	require.NoError(t, err)
+	defer r.Close()
+
+	buf, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2}, buf)
+}
+
+func TestFindSegmentsRemoteError(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recordstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "path1"), 0o755)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "path1", "2016-05-19_22-15-25-000427.mp4"), []byte{1}, 0o644)
+	require.NoError(t, err)
+
+	s := &test.S3Server{Bucket: "mybucket"}
+	s.Initialize()
+	s.Close()
+
+	pathConf := &conf.Path{
+		Name:            "path1",
+		RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s.URL() + "/recordings",
+	}
+
+	// local segments are returned when object storage is unreachable
+	segments, err := FindSegments(pathConf, "path1", nil, nil, test.NilLogger)
+	require.NoError(t, err)
+	require.Equal(t, []*Segment{
+		{
+			Fpath: filepath.Join(dir, "path1", "2016-05-19_22-15-25-000427.mp4"),
+			Start: time.Date(2016, 5, 19, 22, 15, 25, 427000, time.Local),
+		},
+	}, segments)
+}
@@ -0,0 +1,243 @@
+// Package recorduploader contains the recording uploader.
+package recorduploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/s3"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+)
+
+const (
+	queueSize         = 1024
+	retryPauseMin     = 5 * time.Second
+	retryPauseMax     = 5 * time.Minute
+	maxAttempts       = 10
+	uploadIdleTimeout = 30 * time.Second
+)
+
+// isTemporaryError checks whether an upload error may disappear by itself,
+// like network errors and server errors.
+func isTemporaryError(err error) bool {
+	if errors.Is(err, s3.ErrObjectNotFound) {
+		return false
+	}
+
+	var statusErr *s3.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 ||
+			statusErr.StatusCode == http.StatusRequestTimeout ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+// idleTimeoutReader postpones a timer every time it is read.
+type idleTimeoutReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	r.timer.Reset(r.timeout)
+	return r.r.Read(p)
+}
+
+type uploadReq struct {
+	pathConf *conf.Path
+	fpath    string
+}
+
+// Uploader uploads completed recording segments to object storage.
+// Each path has its own queue, in order to prevent paths whose uploads fail from blocking other paths.
+// Segments of a path are uploaded one at a time, in the same order in which they are completed.
+type Uploader struct {
+	Parent logger.Writer
+
+	idleTimeout time.Duration
+	ctx         context.Context
+	ctxCancel   func()
+	wg          sync.WaitGroup
+
+	mutex  sync.Mutex
+	queues map[string][]uploadReq
+	queued map[string]struct{}
+}
+
+// Initialize initializes an Uploader.
+func (u *Uploader) Initialize() {
+	if u.idleTimeout == 0 {
+		u.idleTimeout = uploadIdleTimeout
+	}
+
+	u.ctx, u.ctxCancel = context.WithCancel(context.Background())
+	u.queues = make(map[string][]uploadReq)
+	u.queued = make(map[string]struct{})
+}
+
+// Close closes the Uploader.
+// Segments that are still in queue are not uploaded;
+// they are uploaded later by the record cleaner.
+func (u *Uploader) Close() {
+	u.ctxCancel()
+	u.wg.Wait()
+}
+
+// Log implements logger.Writer.
+func (u *Uploader) Log(level logger.Level, format string, args ...any) {
+	u.Parent.Log(level, "[record uploader] "+format, args...)
+}
+
+// Upload adds a segment to the upload queue of a path.
+// Segments that are already in queue are ignored.
+func (u *Uploader) Upload(pathConf *conf.Path, pathName string, fpath string) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if u.ctx.Err() != nil {
+		return
+	}
+
+	if _, ok := u.queued[fpath]; ok {
+		return
+	}
+
+	queue, running := u.queues[pathName]
+	if len(queue) >= queueSize {
+		u.Log(logger.Error, "upload queue of path '%s' is full, segment %s will be uploaded later", pathName, fpath)
+		return
+	}
+
+	u.queued[fpath] = struct{}{}
+	u.queues[pathName] = append(queue, uploadReq{pathConf: pathConf, fpath: fpath})
+
+	if !running {
+		u.wg.Add(1)
+		go u.runPath(pathName)
+	}
+}
+
+// runPath uploads the segments of a path until its queue is empty.
+func (u *Uploader) runPath(pathName string) {
+	defer u.wg.Done()
+
+	for {
+		u.mutex.Lock()
+		queue := u.queues[pathName]
+		if len(queue) == 0 || u.ctx.Err() != nil {
+			delete(u.queues, pathName)
+			for _, req := range queue {
+				delete(u.queued, req.fpath)
+			}
+			u.mutex.Unlock()
+			return
+		}
+		req := queue[0]
+		u.mutex.Unlock()
+
+		u.process(req)
+
+		u.mutex.Lock()
+		u.queues[pathName] = u.queues[pathName][1:]
+		delete(u.queued, req.fpath)
+		u.mutex.Unlock()
+	}
+}
+
+func (u *Uploader) process(req uploadReq) {
+	client, _, err := recordstore.RemoteClient(req.pathConf)
+	if err != nil {
+		u.Log(logger.Error, "unable to upload %s: %v", req.fpath, err)
+		return
+	}
+
+	if client == nil {
+		return
+	}
+
+	key, err := recordstore.RemoteKey(req.pathConf, req.fpath)
+	if err != nil {
+		u.Log(logger.Error, "unable to upload %s: %v", req.fpath, err)
+		return
+	}
+
+	retryPause := retryPauseMin
+
+	for attempt := 1; ; attempt++ {
+		err = u.upload(client, key, req.fpath)
+		if err == nil {
+			u.Log(logger.Debug, "uploaded %s", req.fpath)
+			return
+		}
+
+		switch {
+		// segment has been deleted in the meanwhile
+		case errors.Is(err, fs.ErrNotExist):
+			u.Log(logger.Warn, "unable to upload %s: %v", req.fpath, err)
+			return
+
+		case u.ctx.Err() != nil:
+			return
+
+		case !isTemporaryError(err):
+			u.Log(logger.Error, "unable to upload %s: %v", req.fpath, err)
+			return
+
+		case attempt >= maxAttempts:
+			u.Log(logger.Error, "unable to upload %s: %v, giving up after %d attempts", req.fpath, err, attempt)
+			return
+		}
+
+		u.Log(logger.Error, "unable to upload %s: %v, retrying in %v", req.fpath, err, retryPause)
+
+		select {
+		case <-time.After(retryPause):
+		case <-u.ctx.Done():
+			return
+		}
+
+		retryPause = min(retryPause*2, retryPauseMax)
+	}
+}
+
+func (u *Uploader) upload(client *s3.Client, key string, fpath string) error {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// abort uploads that stop progressing, in order not to block the queue.
+	ctx, ctxCancel := context.WithCancel(u.ctx)
+	defer ctxCancel()
+
+	timer := time.AfterFunc(u.idleTimeout, ctxCancel)
+	defer timer.Stop()
+
+	err = client.PutObject(ctx, key, &idleTimeoutReader{
+		r:       f,
+		timer:   timer,
+		timeout: u.idleTimeout,
+	}, fi.Size())
+	if err != nil && u.ctx.Err() == nil && ctx.Err() != nil {
+		return fmt.Errorf("upload did not progress for %v", u.idleTimeout)
+	}
+	return err
+}
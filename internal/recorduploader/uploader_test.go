@@ -0,0 +1,222 @@
+package recorduploader
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploader(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recorduploader")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	fpath := filepath.Join(dir, "mypath", "2009-05-19_22-15-25-000427.mp4")
+	err = os.WriteFile(fpath, []byte{1, 2, 3}, 0o644)
+	require.NoError(t, err)
+
+	s := &test.S3Server{Bucket: "mybucket"}
+	s.Initialize()
+	defer s.Close()
+
+	u := &Uploader{Parent: test.NilLogger}
+	u.Initialize()
+	defer u.Close()
+
+	u.Upload(&conf.Path{
+		Name:            "mypath",
+		RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s.URL() + "/recordings",
+	}, "mypath", fpath)
+
+	require.Eventually(t, func() bool {
+		return len(s.Objects()) != 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, map[string][]byte{
+		"recordings/mypath/2009-05-19_22-15-25-000427.mp4": {1, 2, 3},
+	}, s.Objects())
+}
+
+func TestUploaderIdleTimeout(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recorduploader")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	fpath := filepath.Join(dir, "mypath", "2009-05-19_22-15-25-000427.mp4")
+	err = os.WriteFile(fpath, []byte{1, 2, 3}, 0o644)
+	require.NoError(t, err)
+
+	// server that never replies
+	s := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) //nolint:errcheck
+		<-r.Context().Done()
+	}))
+	defer s.Close()
+
+	logged := make(chan string, 10)
+
+	u := &Uploader{
+		Parent: test.Logger(func(level logger.Level, format string, args ...any) {
+			if level == logger.Error {
+				logged <- fmt.Sprintf(format, args...)
+			}
+		}),
+		idleTimeout: 100 * time.Millisecond,
+	}
+	u.Initialize()
+	defer u.Close()
+
+	u.Upload(&conf.Path{
+		Name:            "mypath",
+		RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s.URL + "/recordings",
+	}, "mypath", fpath)
+
+	select {
+	case msg := <-logged:
+		require.Contains(t, msg, "upload did not progress for 100ms")
+	case <-time.After(5 * time.Second):
+		t.Errorf("upload did not time out")
+	}
+}
+
+func TestUploaderPermanentError(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recorduploader")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	fpath := filepath.Join(dir, "mypath", "2009-05-19_22-15-25-000427.mp4")
+	err = os.WriteFile(fpath, []byte{1, 2, 3}, 0o644)
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	requests := 0
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) //nolint:errcheck
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer s.Close()
+
+	logged := make(chan string, 10)
+
+	u := &Uploader{
+		Parent: test.Logger(func(level logger.Level, format string, args ...any) {
+			if level == logger.Error {
+				logged <- fmt.Sprintf(format, args...)
+			}
+		}),
+	}
+	u.Initialize()
+	defer u.Close()
+
+	u.Upload(&conf.Path{
+		Name:            "mypath",
+		RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s.URL + "/recordings",
+	}, "mypath", fpath)
+
+	select {
+	case msg := <-logged:
+		require.Equal(t, "[record uploader] unable to upload "+fpath+": bad status code: 403", msg)
+	case <-time.After(5 * time.Second):
+		t.Errorf("upload did not fail")
+	}
+
+	// segment is not in queue anymore
+	u.Upload(&conf.Path{
+		Name:            "mypath",
+		RecordPath:      filepath.Join(dir, "other/%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s.URL + "/recordings",
+	}, "mypath", fpath)
+
+	select {
+	case msg := <-logged:
+		require.Contains(t, msg, "is outside of the record path")
+	case <-time.After(5 * time.Second):
+		t.Errorf("upload did not fail")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, 1, requests)
+}
+
+func TestUploaderPathQueues(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recorduploader")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, pathName := range []string{"path1", "path2"} {
+		err = os.Mkdir(filepath.Join(dir, pathName), 0o755)
+		require.NoError(t, err)
+
+		err = os.WriteFile(filepath.Join(dir, pathName, "2009-05-19_22-15-25-000427.mp4"), []byte{1, 2, 3}, 0o644)
+		require.NoError(t, err)
+	}
+
+	// server that is not available
+	s1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) //nolint:errcheck
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s1.Close()
+
+	s2 := &test.S3Server{Bucket: "mybucket"}
+	s2.Initialize()
+	defer s2.Close()
+
+	u := &Uploader{Parent: test.NilLogger}
+	u.Initialize()
+	defer u.Close()
+
+	u.Upload(&conf.Path{
+		Name:            "path1",
+		RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s1.URL + "/recordings",
+	}, "path1", filepath.Join(dir, "path1", "2009-05-19_22-15-25-000427.mp4"))
+
+	u.Upload(&conf.Path{
+		Name:            "path2",
+		RecordPath:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		RecordFormat:    conf.RecordFormatFMP4,
+		RecordUploadURL: s2.URL() + "/recordings",
+	}, "path2", filepath.Join(dir, "path2", "2009-05-19_22-15-25-000427.mp4"))
+
+	require.Eventually(t, func() bool {
+		return len(s2.Objects()) != 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, map[string][]byte{
+		"recordings/path2/2009-05-19_22-15-25-000427.mp4": {1, 2, 3},
+	}, s2.Objects())
+}
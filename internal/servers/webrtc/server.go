@@ -183,12 +183,19 @@ type webRTCRenegotiateSessionReq struct {
 	res      chan webRTCRenegotiateSessionRes
 }
 
+type webRTCSimulcastLayersReq struct {
+	pathName string
+	res      chan []conf.SimulcastInput
+}
+
 type serverMetrics interface {
 	SetWebRTCServer(defs.APIWebRTCServer)
 }
 
 type serverPathManager interface {
 	FindPathConf(req defs.PathFindPathConfReq) (*conf.Path, error)
+	AddSimulcastLayer(req defs.PathAddSimulcastLayerReq) (*conf.Path, error)
+	RemoveSimulcastLayer(req defs.PathRemoveSimulcastLayerReq)
 	AddPublisher(req defs.PathAddPublisherReq) (defs.Path, *stream.Stream, error)
 	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
 }
@@ -238,6 +245,7 @@ type Server struct {
 	chAddSessionCandidates chan webRTCAddSessionCandidatesReq
 	chDeleteSession        chan webRTCDeleteSessionReq
 	chRenegotiateSession   chan webRTCRenegotiateSessionReq
+	chSimulcastLayers      chan webRTCSimulcastLayersReq
 	chAPISessionsList      chan serverAPISessionsListReq
 	chAPISessionsGet       chan serverAPISessionsGetReq
 	chAPIConnsKick         chan serverAPISessionsKickReq
@@ -259,6 +267,7 @@ func (s *Server) Initialize() error {
 	s.chAddSessionCandidates = make(chan webRTCAddSessionCandidatesReq)
 	s.chDeleteSession = make(chan webRTCDeleteSessionReq)
 	s.chRenegotiateSession = make(chan webRTCRenegotiateSessionReq)
+	s.chSimulcastLayers = make(chan webRTCSimulcastLayersReq)
 	s.chAPISessionsList = make(chan serverAPISessionsListReq)
 	s.chAPISessionsGet = make(chan serverAPISessionsGetReq)
 	s.chAPIConnsKick = make(chan serverAPISessionsKickReq)
@@ -437,6 +446,18 @@ outer:
 			s.Log(logger.Info, "Session found successfully: UUID=%s", sx.uuid.String())
 			req.res <- webRTCRenegotiateSessionRes{sx: sx}
 
+		case req := <-s.chSimulcastLayers:
+			var inputs []conf.SimulcastInput
+
+			for sx := range s.sessions {
+				if sx.req.publish && sx.req.pathName == req.pathName {
+					inputs = sx.simulcastInputs()
+					break
+				}
+			}
+
+			req.res <- inputs
+
 		case req := <-s.chAPISessionsList:
 			data := &defs.APIWebRTCSessionList{
 				Items: []*defs.APIWebRTCSession{},
@@ -615,6 +636,23 @@ func (s *Server) renegotiateSession(req webRTCRenegotiateSessionReq) webRTCReneg
 	}
 }
 
+// simulcastLayers is called by session.
+// It returns the simulcast layers published with WHIP on a path.
+func (s *Server) simulcastLayers(pathName string) []conf.SimulcastInput {
+	req := webRTCSimulcastLayersReq{
+		pathName: pathName,
+		res:      make(chan []conf.SimulcastInput),
+	}
+
+	select {
+	case s.chSimulcastLayers <- req:
+		return <-req.res
+
+	case <-s.ctx.Done():
+		return nil
+	}
+}
+
 // APISessionsList is called by api.
 func (s *Server) APISessionsList() (*defs.APIWebRTCSessionList, error) {
 	req := serverAPISessionsListReq{
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +30,14 @@ import (
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
+const (
+	abrUpdateInterval  = 1 * time.Second
+	defaultABRCooldown = 5 * time.Second
+
+	// frame rate below which frames are not thinned anymore by ABR
+	minThinnedFPS = 1
+)
+
 func whipOffer(body []byte) *pwebrtc.SessionDescription {
 	return &pwebrtc.SessionDescription{
 		Type: pwebrtc.SDPTypeOffer,
@@ -39,6 +48,7 @@ func whipOffer(body []byte) *pwebrtc.SessionDescription {
 type sessionParent interface {
 	closeSession(sx *session)
 	generateICEServers(clientConfig bool) ([]pwebrtc.ICEServer, error)
+	simulcastLayers(pathName string) []conf.SimulcastInput
 	logger.Writer
 }
 
@@ -67,12 +77,21 @@ type session struct {
 	mutex     sync.RWMutex
 	pc        *webrtc.PeerConnection
 
-	chNew            chan webRTCNewSessionReq
-	chAddCandidates  chan webRTCAddSessionCandidatesReq
+	chNew           chan webRTCNewSessionReq
+	chAddCandidates chan webRTCAddSessionCandidatesReq
 	chRenegotiate   chan webRTCRenegotiateSessionReq
 
 	// Simulcast state
 	currentBandwidthLimit int // Current bandwidth limit in kbps (0 = unlimited)
+	simulcastInputsConf   []conf.SimulcastInput
+	simulcastSwitcher     *webrtc.SimulcastSwitcher
+	simulcastABR          *webrtc.SimulcastABR
+	simulcastLayers       []*simulcastLayer // layers received from a WHIP publisher
+
+	// Frame thinning state
+	frameThinner              *webrtc.FrameThinner
+	queryMaxFPS               float64 // maximum frame rate requested with the "maxfps" query parameter
+	thinningUnavailableLogged bool
 }
 
 func (s *session) initialize() {
@@ -275,6 +294,69 @@ func (s *session) runPublish() (int, error) {
 
 	defer path.RemovePublisher(defs.PathRemovePublisherReq{Author: s})
 
+	// each simulcast encoding is published on a dedicated sub-path
+	var layers []*simulcastLayer
+
+	for _, rid := range pc.IncomingRIDs() {
+		layer := &simulcastLayer{
+			sx:       s,
+			rid:      rid,
+			pathName: conf.SimulcastLayerPath(s.req.pathName, rid),
+		}
+
+		for _, track := range pc.IncomingTracks() {
+			if track.RID() == rid {
+				layer.track = track
+				break
+			}
+		}
+
+		var layerStrm *stream.Stream
+
+		layerMedias, err2 := webrtc.ToStreamLayer(pc, rid, pathConf, &layerStrm, layer)
+		if err2 != nil {
+			return 0, err2
+		}
+
+		layerConf, err2 := s.pathManager.AddSimulcastLayer(defs.PathAddSimulcastLayerReq{
+			PathName:  s.req.pathName,
+			LayerPath: layer.pathName,
+		})
+		if err2 != nil {
+			return 0, fmt.Errorf("simulcast layer '%s': %w", rid, err2)
+		}
+
+		defer s.pathManager.RemoveSimulcastLayer(defs.PathRemoveSimulcastLayerReq{LayerPath: layer.pathName})
+
+		var layerPath defs.Path
+		layerPath, layerStrm, err2 = s.pathManager.AddPublisher(defs.PathAddPublisherReq{
+			Author:             layer,
+			Desc:               &description.Session{Medias: layerMedias},
+			GenerateRTPPackets: false,
+			FillNTP:            !pathConf.UseAbsoluteTimestamp,
+			ConfToCompare:      layerConf,
+			AccessRequest: defs.PathAccessRequest{
+				Name:     layer.pathName,
+				Publish:  true,
+				SkipAuth: true,
+			},
+		})
+		if err2 != nil {
+			return 0, fmt.Errorf("simulcast layer '%s': %w", rid, err2)
+		}
+
+		defer layerPath.RemovePublisher(defs.PathRemovePublisherReq{Author: layer})
+
+		layer.stream = layerStrm
+		layers = append(layers, layer)
+
+		s.Log(logger.Info, "is publishing simulcast layer '%s' to path '%s'", rid, layer.pathName)
+	}
+
+	s.mutex.Lock()
+	s.simulcastLayers = layers
+	s.mutex.Unlock()
+
 	pc.StartReading()
 
 	select {
@@ -335,7 +417,101 @@ func (s *session) runRead() (int, error) {
 
 	r := &stream.Reader{Parent: s}
 
-	err = webrtc.FromStreamWithConfig(strm.Desc, r, pc, path)
+	s.frameThinner, err = webrtc.FrameThinnerFromQuery(s.req.httpRequest.URL.Query())
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if s.frameThinner != nil {
+		s.frameThinner.Log = s
+		s.queryMaxFPS = s.frameThinner.MaxFPS()
+	}
+
+	var layers []webrtc.SimulcastLayer
+	var layerStreams []*stream.Stream
+
+	simulcastConf := path.SafeConf().SimulcastConfig
+
+	if simulcastConf != nil && simulcastConf.Enable {
+		s.simulcastInputsConf = simulcastConf.Inputs
+	} else {
+		// layers published by a WHIP simulcast publisher
+		s.simulcastInputsConf = s.parent.simulcastLayers(path.Name())
+	}
+
+	if s.simulcastInputsConf != nil {
+		for _, input := range s.simulcastInputsConf {
+			if input.Type != "video" {
+				continue
+			}
+
+			// video is read from the layer paths, in order to switch between them
+			layerPath, layerStrm, err2 := s.pathManager.AddReader(defs.PathAddReaderReq{
+				Author: s,
+				AccessRequest: defs.PathAccessRequest{
+					Name:     input.Path,
+					SkipAuth: true,
+				},
+			})
+			if err2 != nil {
+				return http.StatusBadRequest, fmt.Errorf("simulcast layer '%s': %w", input.Layer, err2)
+			}
+
+			defer layerPath.RemoveReader(defs.PathRemoveReaderReq{Author: s})
+
+			layers = append(layers, webrtc.SimulcastLayer{
+				Name:   input.Layer,
+				Desc:   layerStrm.Desc,
+				Reader: &stream.Reader{Parent: s},
+			})
+			layerStreams = append(layerStreams, layerStrm)
+		}
+	}
+
+	if layers != nil {
+		s.simulcastSwitcher = &webrtc.SimulcastSwitcher{ClockRate: 90000}
+		err = s.simulcastSwitcher.Initialize()
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		layer := webrtc.SimulcastLayerForBandwidth(s.simulcastInputs(), s.currentBandwidthLimit)
+		s.simulcastSwitcher.SetLayer(layer)
+
+		if simulcastConf != nil && simulcastConf.ABR {
+			cooldown := time.Duration(simulcastConf.ABRCooldown)
+			if cooldown == 0 {
+				cooldown = defaultABRCooldown
+			}
+
+			s.simulcastABR = &webrtc.SimulcastABR{
+				Inputs:   s.simulcastInputs(),
+				Cooldown: cooldown,
+			}
+			s.simulcastABR.Initialize()
+			s.simulcastABR.SetLimit(s.currentBandwidthLimit)
+			s.simulcastABR.SetLayer(layer, time.Now())
+
+			// frames are thinned when the estimate is below the bitrate of the lowest layer
+			if s.frameThinner == nil {
+				s.frameThinner = &webrtc.FrameThinner{Log: s}
+			}
+		}
+
+		err = webrtc.FromSimulcastStream(strm.Desc, r, layers, s.simulcastSwitcher, s.frameThinner, pc)
+	} else {
+		var svcFilter *webrtc.SVCFilter
+		svcFilter, err = webrtc.SVCFilterFromQuery(s.req.httpRequest.URL.Query())
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		err = webrtc.FromStreamWithOptions(strm.Desc, r, pc, webrtc.FromStreamOptions{
+			PathConf:     path,
+			SVCFilter:    svcFilter,
+			FrameThinner: s.frameThinner,
+		})
+	}
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
@@ -396,15 +572,123 @@ func (s *session) runRead() (int, error) {
 	strm.AddReader(r)
 	defer strm.RemoveReader(r)
 
-	select {
-	case <-pc.Failed():
-		return 0, fmt.Errorf("peer connection closed")
+	if s.simulcastABR != nil {
+		go s.runSimulcastABR(pc, terminatorRun)
+	}
 
-	case err = <-r.Error():
-		return 0, err
+	layerErr := make(chan error)
 
-	case <-s.ctx.Done():
-		return 0, fmt.Errorf("terminated")
+	for i, layer := range layers {
+		layerStreams[i].AddReader(layer.Reader)
+		defer layerStreams[i].RemoveReader(layer.Reader)
+
+		go func() {
+			select {
+			case err2 := <-layer.Reader.Error():
+				select {
+				case layerErr <- err2:
+				case <-terminatorRun:
+				}
+			case <-terminatorRun:
+			}
+		}()
+	}
+
+	for {
+		select {
+		case <-pc.Failed():
+			return 0, fmt.Errorf("peer connection closed")
+
+		case err = <-r.Error():
+			return 0, err
+
+		case err = <-layerErr:
+			return 0, err
+
+		case req := <-s.chRenegotiate:
+			_, err = s.handleRenegotiation(req)
+			if err != nil {
+				req.res <- webRTCRenegotiateSessionRes{err: err}
+			}
+
+		case <-s.ctx.Done():
+			return 0, fmt.Errorf("terminated")
+		}
+	}
+}
+
+// runSimulcastABR periodically moves the reader to the simulcast layer
+// that fits the bandwidth estimated from its RTCP feedback.
+func (s *session) runSimulcastABR(pc *webrtc.PeerConnection, terminate chan struct{}) {
+	t := time.NewTicker(abrUpdateInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case now := <-t.C:
+			s.simulcastABR.SetInputs(s.simulcastInputs())
+
+			layer, ok := s.simulcastABR.Update(pc.FeedbackStats(), now)
+			if ok {
+				s.Log(logger.Info, "estimated bandwidth is %d kbps, switching to simulcast layer '%s' at next keyframe",
+					s.simulcastABR.Estimate()/1000, layer)
+				s.simulcastSwitcher.SetLayer(layer)
+			}
+
+			s.updateFrameThinner()
+
+		case <-terminate:
+			return
+		}
+	}
+}
+
+// updateFrameThinner reduces the frame rate when the reader is receiving the lowest
+// simulcast layer and the estimated bandwidth is below its bitrate,
+// providing an additional step below the lowest layer.
+// This is not possible when the stream doesn't contain frames that can be dropped.
+func (s *session) updateFrameThinner() {
+	maxFPS := s.queryMaxFPS
+
+	var lowest *conf.SimulcastInput
+	inputs := s.simulcastInputs()
+
+	for i := range inputs {
+		if inputs[i].Type == "video" && inputs[i].Bitrate != 0 &&
+			(lowest == nil || inputs[i].Bitrate < lowest.Bitrate) {
+			lowest = &inputs[i]
+		}
+	}
+
+	if lowest != nil && s.simulcastSwitcher.Layer() == lowest.Layer {
+		estimate := float64(s.simulcastABR.Estimate())
+		inputFPS := s.frameThinner.InputFPS()
+
+		if estimate != 0 && estimate < float64(lowest.Bitrate) && inputFPS > 0 {
+			if s.frameThinner.Thinnable() {
+				fps := max(inputFPS*estimate/float64(lowest.Bitrate), minThinnedFPS)
+				if maxFPS == 0 || fps < maxFPS {
+					maxFPS = fps
+				}
+			} else if !s.thinningUnavailableLogged {
+				s.thinningUnavailableLogged = true
+				s.Log(logger.Warn, "estimated bandwidth is %d kbps, but frame rate cannot be reduced, since the stream "+
+					"does not contain frames that can be dropped (non-reference frames or temporal layers)",
+					s.simulcastABR.Estimate()/1000)
+			}
+		}
+	}
+
+	if maxFPS != s.frameThinner.MaxFPS() {
+		if maxFPS == 0 {
+			s.Log(logger.Info, "estimated bandwidth is %d kbps, frame rate is not limited anymore",
+				s.simulcastABR.Estimate()/1000)
+		} else {
+			s.Log(logger.Info, "estimated bandwidth is %d kbps, limiting frame rate to %.1f fps",
+				s.simulcastABR.Estimate()/1000, maxFPS)
+		}
+
+		s.frameThinner.SetMaxFPS(maxFPS)
 	}
 }
 
@@ -447,14 +731,14 @@ func (s *session) handleRenegotiation(req webRTCRenegotiateSessionReq) (int, err
 
 	// Extract bandwidth limit from b=AS attribute (for Simulcast)
 	bandwidthLimit := s.extractBandwidthLimit(&sdp)
+
+	s.mutex.Lock()
 	s.currentBandwidthLimit = bandwidthLimit
+	s.mutex.Unlock()
 
 	s.Log(logger.Info, "Extracted bandwidth limit: %d kbps", bandwidthLimit)
 	s.Log(logger.Info, "SDP renegotiation with bandwidth limit: %d kbps", bandwidthLimit)
 
-	// TODO: Implement RTP source switching based on bandwidth limit
-	// For now, we perform the renegotiation handshake but don't actually change streams
-
 	// Perform proper WebRTC renegotiation:
 	// 1. Set the new remote offer
 	// 2. Create a new answer
@@ -518,6 +802,22 @@ func (s *session) handleRenegotiation(req webRTCRenegotiateSessionReq) (int, err
 
 	s.Log(logger.Info, "=== SDP RENEGOTIATION COMPLETED ===")
 
+	// Switch the simulcast layer that feeds the video track.
+	// The switch takes place at the next keyframe of the new layer.
+	if s.simulcastSwitcher != nil {
+		layer := webrtc.SimulcastLayerForBandwidth(s.simulcastInputs(), bandwidthLimit)
+		if layer != s.simulcastSwitcher.Layer() {
+			s.Log(logger.Info, "switching to simulcast layer '%s' at next keyframe", layer)
+		}
+		s.simulcastSwitcher.SetLayer(layer)
+
+		// the requested bandwidth becomes an upper bound for ABR
+		if s.simulcastABR != nil {
+			s.simulcastABR.SetLimit(bandwidthLimit)
+			s.simulcastABR.SetLayer(layer, time.Now())
+		}
+	}
+
 	req.res <- webRTCRenegotiateSessionRes{
 		sx:     s,
 		answer: []byte(finalAnswer.SDP),
@@ -593,7 +893,49 @@ func (s *session) APIReaderDescribe() defs.APIPathSourceOrReader {
 
 // APISourceDescribe implements source.
 func (s *session) APISourceDescribe() defs.APIPathSourceOrReader {
-	return s.APIReaderDescribe()
+	ret := s.APIReaderDescribe()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, layer := range s.simulcastLayers {
+		ret.Layers = append(ret.Layers, layer.apiItem())
+	}
+
+	return ret
+}
+
+// simulcastInputs returns the simulcast layers.
+// When called on a reader, layers without a configured bitrate
+// are filled with the measured one.
+// When called on a publisher, layers received from the publisher are returned.
+func (s *session) simulcastInputs() []conf.SimulcastInput {
+	if s.req.publish {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		var inputs []conf.SimulcastInput
+		for _, layer := range s.simulcastLayers {
+			inputs = append(inputs, conf.SimulcastInput{
+				Path:  layer.pathName,
+				Layer: layer.rid,
+				Type:  "video",
+			})
+		}
+		return inputs
+	}
+
+	inputs := slices.Clone(s.simulcastInputsConf)
+
+	if s.simulcastSwitcher != nil {
+		for i := range inputs {
+			if inputs[i].Type == "video" && inputs[i].Bitrate == 0 {
+				inputs[i].Bitrate = s.simulcastSwitcher.Bitrate(inputs[i].Layer)
+			}
+		}
+	}
+
+	return inputs
 }
 
 func (s *session) apiItem() *defs.APIWebRTCSession {
@@ -641,16 +983,16 @@ func (s *session) apiItem() *defs.APIWebRTCSession {
 			}
 			return defs.APIWebRTCSessionStateRead
 		}(),
-		Path:                s.req.pathName,
-		Query:               s.req.httpRequest.URL.RawQuery,
-		BytesReceived:       bytesReceived,
-		BytesSent:           bytesSent,
-		RTPPacketsReceived:  rtpPacketsReceived,
-		RTPPacketsSent:      rtpPacketsSent,
-		RTPPacketsLost:      rtpPacketsLost,
-		RTPPacketsJitter:       rtpPacketsJitter,
-		RTCPPacketsReceived:    rtcpPacketsReceived,
-		RTCPPacketsSent:        rtcpPacketsSent,
+		Path:                    s.req.pathName,
+		Query:                   s.req.httpRequest.URL.RawQuery,
+		BytesReceived:           bytesReceived,
+		BytesSent:               bytesSent,
+		RTPPacketsReceived:      rtpPacketsReceived,
+		RTPPacketsSent:          rtpPacketsSent,
+		RTPPacketsLost:          rtpPacketsLost,
+		RTPPacketsJitter:        rtpPacketsJitter,
+		RTCPPacketsReceived:     rtcpPacketsReceived,
+		RTCPPacketsSent:         rtcpPacketsSent,
 		SimulcastBandwidthLimit: simulcastBandwidthLimit,
 	}
 }
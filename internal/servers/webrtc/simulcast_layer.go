@@ -0,0 +1,51 @@
+package webrtc
+
+import (
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/webrtc"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+// simulcastLayer is a simulcast encoding received from a WHIP publisher,
+// published on a dedicated sub-path.
+type simulcastLayer struct {
+	sx       *session
+	rid      string
+	pathName string
+	track    *webrtc.IncomingTrack
+	stream   *stream.Stream
+}
+
+// Log implements logger.Writer.
+func (l *simulcastLayer) Log(level logger.Level, format string, args ...any) {
+	l.sx.Log(level, "[layer %s] "+format, append([]any{l.rid}, args...)...)
+}
+
+// Close implements defs.Publisher.
+func (l *simulcastLayer) Close() {
+	l.sx.Close()
+}
+
+// APISourceDescribe implements defs.Source.
+func (l *simulcastLayer) APISourceDescribe() defs.APIPathSourceOrReader {
+	return l.sx.APIReaderDescribe()
+}
+
+func (l *simulcastLayer) apiItem() *defs.APISimulcastLayer {
+	item := &defs.APISimulcastLayer{
+		Layer:  l.rid,
+		Path:   l.pathName,
+		Online: true,
+	}
+
+	if l.track != nil {
+		item.RTPPacketsReceived = l.track.RTPPacketsReceived()
+	}
+
+	if l.stream != nil {
+		item.BytesReceived = l.stream.BytesReceived()
+	}
+
+	return item
+}
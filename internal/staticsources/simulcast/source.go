@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
@@ -25,7 +26,19 @@ type staticSource interface {
 	APISourceDescribe() defs.APIPathSourceOrReader
 }
 
+const (
+	// how long Run waits for all inputs before going ready with the available ones
+	inputsStartTimeout = 10 * time.Second
+
+	// pause between attempts to attach an input
+	inputRetryPause = 2 * time.Second
+)
+
 // Source is a Simulcast static source.
+//
+// Each input is attached independently: when an input path is not available,
+// the source keeps running with the remaining inputs and attaches the input again
+// as soon as its path becomes ready.
 type Source struct {
 	Conf              *conf.Path
 	LogLevel          conf.LogLevel
@@ -45,27 +58,22 @@ type Source struct {
 		SetNotReady(req defs.PathSourceStaticSetNotReadyReq)
 	}
 
-	config      *conf.SimulcastConfig
-	logger      logger.Writer
+	config *conf.SimulcastConfig
+	logger logger.Writer
 
 	// Output stream (the path's stream that clients read from)
 	outputStream *stream.Stream
-
-	// Input streams and readers
-	inputStreams map[string]*stream.Stream // path -> stream
-	readers      map[string]*stream.Reader  // path -> reader
+	outputReady  chan struct{}
 
 	// Layer mapping
 	layerMapping map[string]*layerInfo // path -> layer info
 
-	// Context
+	// Context of the current run
 	ctx       context.Context
 	ctxCancel context.CancelFunc
 	wg        sync.WaitGroup
 
-	// State
-	active bool
-	mutex   sync.RWMutex
+	mutex sync.RWMutex
 }
 
 // layerInfo stores layer-related information
@@ -75,6 +83,16 @@ type layerInfo struct {
 	RID        string // RTP Stream Identifier
 	Resolution string // Resolution
 	Bitrate    uint   // Bitrate
+
+	// input stream, when the input is attached. Protected by Source.mutex
+	stream *stream.Stream
+
+	// whether data is being forwarded. Protected by Source.mutex
+	online bool
+
+	// statistics
+	rtpPacketsReceived uint64
+	bytesReceived      uint64
 }
 
 // New allocates a Source.
@@ -97,8 +115,6 @@ func New(
 		SetNotReady(req defs.PathSourceStaticSetNotReadyReq)
 	},
 ) staticSource {
-	ctx, ctxCancel := context.WithCancel(context.Background())
-
 	s := &Source{
 		Conf:              conf,
 		LogLevel:          logLevel,
@@ -113,12 +129,7 @@ func New(
 
 		config:       conf.SimulcastConfig,
 		logger:       parent,
-		inputStreams: make(map[string]*stream.Stream),
-		readers:      make(map[string]*stream.Reader),
 		layerMapping: make(map[string]*layerInfo),
-
-		ctx:       ctx,
-		ctxCancel: ctxCancel,
 	}
 
 	s.logger.Log(logger.Info, "initialized simulcast source with %d inputs", len(s.config.Inputs))
@@ -134,9 +145,38 @@ func (s *Source) Log(level logger.Level, format string, args ...any) {
 // APISourceDescribe implements defs.Source.
 func (s *Source) APISourceDescribe() defs.APIPathSourceOrReader {
 	return defs.APIPathSourceOrReader{
-		Type: "simulcast",
-		ID:   fmt.Sprintf("simulcast:%d_inputs", len(s.config.Inputs)),
+		Type:   "simulcast",
+		ID:     fmt.Sprintf("simulcast:%d_inputs", len(s.config.Inputs)),
+		Layers: s.apiLayers(),
+	}
+}
+
+func (s *Source) apiLayers() []*defs.APISimulcastLayer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var layers []*defs.APISimulcastLayer
+
+	for _, input := range s.config.Inputs {
+		if input.Type != "video" {
+			continue
+		}
+
+		layer := &defs.APISimulcastLayer{
+			Layer: input.Layer,
+			Path:  input.Path,
+		}
+
+		if li, ok := s.layerMapping[input.Path]; ok {
+			layer.Online = li.online
+			layer.RTPPacketsReceived = atomic.LoadUint64(&li.rtpPacketsReceived)
+			layer.BytesReceived = atomic.LoadUint64(&li.bytesReceived)
+		}
+
+		layers = append(layers, layer)
 	}
+
+	return layers
 }
 
 // randUint32 generates a random uint32 for SSRC
@@ -151,212 +191,315 @@ func randUint32() (uint32, error) {
 
 // Run implements defs.Source.
 func (s *Source) Run(params defs.StaticSourceRunParams) error {
-	s.Log(logger.Info, "simulcast source Run called")
+	s.ctx, s.ctxCancel = context.WithCancel(params.Context)
+	s.outputReady = make(chan struct{})
+
+	// Step 1: Attach to all input paths
+	for i := range s.config.Inputs {
+		input := &s.config.Inputs[i]
+
+		ssrc, err := randUint32()
+		if err != nil {
+			s.stopInputs()
+			return fmt.Errorf("failed to generate SSRC for path '%s': %w", input.Path, err)
+		}
+
+		li := &layerInfo{
+			Layer:      input.Layer,
+			SSRC:       ssrc,
+			RID:        input.Layer,
+			Resolution: input.Resolution,
+			Bitrate:    input.Bitrate,
+		}
+
+		s.mutex.Lock()
+		s.layerMapping[input.Path] = li
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go s.runInput(input, li)
+	}
 
-	// Step 1: Connect to all input paths
-	if err := s.connectInputs(); err != nil {
-		return fmt.Errorf("failed to connect inputs: %w", err)
+	// Step 2: Wait for inputs and notify path system that stream is ready
+	desc, err := s.waitInputs(params.ReloadConf)
+	if err != nil {
+		s.stopInputs()
+		return err
 	}
-	defer s.disconnectInputs()
 
-	// Step 2: Notify path system that stream is ready
-	desc := s.createStreamDescription()
 	res := s.Parent.SetReady(defs.PathSourceStaticSetReadyReq{
 		Desc:               desc,
 		GenerateRTPPackets: true,
 		FillNTP:            true,
 	})
 	if res.Err != nil {
+		s.stopInputs()
 		return fmt.Errorf("failed to set ready: %w", res.Err)
 	}
-	defer s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
-
-	// Store the output stream
-	s.outputStream = res.Stream
 
 	// Step 3: Start data forwarding (write to output stream)
-	if err := s.startDataForwarding(); err != nil {
-		return fmt.Errorf("failed to start data forwarding: %w", err)
-	}
+	s.outputStream = res.Stream
+	close(s.outputReady)
 
 	s.Log(logger.Info, "simulcast source ready")
 
-	// Step 6: Wait for termination
-	select {
-	case <-s.ctx.Done():
-		s.Log(logger.Info, "stopped by context cancellation")
-	case <-params.Context.Done():
-		s.Log(logger.Info, "stopped by params context cancellation")
+	// Step 4: Wait for termination
+	for {
+		select {
+		case <-params.ReloadConf:
+
+		case <-s.ctx.Done():
+			s.stopInputs()
+			s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
+			return nil
+		}
 	}
+}
 
-	return nil
+// stopInputs stops all inputs and waits for them.
+func (s *Source) stopInputs() {
+	s.ctxCancel()
+	s.wg.Wait()
 }
 
-// connectInputs connects to all input paths
-func (s *Source) connectInputs() error {
-	s.Log(logger.Info, "connecting to %d input paths", len(s.config.Inputs))
+// waitInputs waits until all inputs are attached, or until inputsStartTimeout,
+// then creates the stream description from the attached inputs.
+func (s *Source) waitInputs(reloadConf chan *conf.Path) (*description.Session, error) {
+	timeout := time.NewTimer(inputsStartTimeout)
+	defer timeout.Stop()
 
-	for _, input := range s.config.Inputs {
-		s.Log(logger.Debug, "connecting to input path: %s", input.Path)
-
-		// Create reader wrapper
-		readerWrapper := &simulcastReader{source: s, path: input.Path}
-		req := defs.PathAddReaderReq{
-			Author: readerWrapper,
-			AccessRequest: defs.PathAccessRequest{
-				Name:    input.Path,
-				Query:   "",
-				Publish: false,
-			},
-			Res: make(chan defs.PathAddReaderRes, 1),
-		}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
 
-		// Call AddReader
-		path, strm, err := s.PathManager.AddReader(req)
-		if err != nil {
-			return fmt.Errorf("failed to add reader to path '%s': %w", input.Path, err)
-		}
+	for {
+		select {
+		case <-ticker.C:
+			if s.attachedInputs() == len(s.config.Inputs) {
+				return s.createStreamDescription()
+			}
+
+		case <-timeout.C:
+			if s.attachedInputs() == 0 {
+				return nil, fmt.Errorf("no input became ready within %v", inputsStartTimeout)
+			}
+			return s.createStreamDescription()
 
-		if path == nil || strm == nil {
-			return fmt.Errorf("path '%s' returned nil", input.Path)
+		case <-reloadConf:
+
+		case <-s.ctx.Done():
+			return nil, fmt.Errorf("terminated")
 		}
+	}
+}
 
-		// Wait for stream to be ready
-		maxWaitTime := 10 * time.Second
-		waitStart := time.Now()
-		for strm.Desc == nil || len(strm.Desc.Medias) == 0 {
-			select {
-			case <-s.ctx.Done():
-				return fmt.Errorf("context cancelled while waiting for stream")
-			case <-time.After(100 * time.Millisecond):
-			}
-			if time.Since(waitStart) > maxWaitTime {
-				return fmt.Errorf("path '%s' did not become ready within %v", input.Path, maxWaitTime)
-			}
+func (s *Source) attachedInputs() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	n := 0
+	for _, li := range s.layerMapping {
+		if li.stream != nil {
+			n++
 		}
+	}
+	return n
+}
 
-		// Create reader
-		reader := &stream.Reader{Parent: s.logger}
-		strm.AddReader(reader)
+// runInput attaches an input until the source is stopped.
+func (s *Source) runInput(input *conf.SimulcastInput, li *layerInfo) {
+	defer s.wg.Done()
 
-		s.inputStreams[input.Path] = strm
-		s.readers[input.Path] = reader
+	var prevErr string
 
-		// Create layer info
-		layerInfo := &layerInfo{
-			Layer:      input.Layer,
-			Resolution: input.Resolution,
-			Bitrate:    input.Bitrate,
-			RID:        input.Layer,
+	for {
+		err := s.attachInput(input, li)
+
+		select {
+		case <-s.ctx.Done():
+			return
+		default:
 		}
 
-		// Generate SSRC for this layer
-		ssrc, err := randUint32()
-		if err != nil {
-			return fmt.Errorf("failed to generate SSRC for path '%s': %w", input.Path, err)
+		// avoid flooding the log when an input is offline for a long time
+		level := logger.Warn
+		if err.Error() == prevErr {
+			level = logger.Debug
 		}
-		layerInfo.SSRC = ssrc
+		prevErr = err.Error()
 
-		s.layerMapping[input.Path] = layerInfo
+		s.Log(level, "input '%s' is offline: %v, retrying in %v", input.Path, err, inputRetryPause)
 
-		s.Log(logger.Info, "connected to input path: %s, medias: %s, SSRC: %d",
-			input.Path, defs.MediasInfo(strm.Desc.Medias), ssrc)
+		select {
+		case <-time.After(inputRetryPause):
+		case <-s.ctx.Done():
+			return
+		}
 	}
-
-	return nil
 }
 
-// disconnectInputs disconnects from all input paths
-func (s *Source) disconnectInputs() {
-	for path, reader := range s.readers {
-		if strm, ok := s.inputStreams[path]; ok && reader != nil {
-			strm.RemoveReader(reader)
-			s.Log(logger.Debug, "disconnected from input path: %s", path)
-		}
+// attachInput reads an input path and forwards its data, until the input path
+// is not ready anymore or the source is stopped.
+func (s *Source) attachInput(input *conf.SimulcastInput, li *layerInfo) error {
+	author := &simulcastReader{
+		source: s,
+		path:   input.Path,
+		closed: make(chan struct{}),
 	}
 
-	// Remove readers via PathManager
-	for path := range s.readers {
-		removeReq := defs.PathRemoveReaderReq{
-			Author: &simulcastReader{source: s, path: path},
-			Res:    make(chan struct{}, 1),
-		}
-		s.PathManager.RemoveReader(removeReq)
-		select {
-		case <-removeReq.Res:
-		case <-time.After(1 * time.Second):
-			s.Log(logger.Warn, "timeout waiting for RemoveReader response for path: %s", path)
-		}
+	path, strm, err := s.PathManager.AddReader(defs.PathAddReaderReq{
+		Author: author,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     input.Path,
+			SkipAuth: true,
+		},
+	})
+	if err != nil {
+		return err
 	}
 
-	s.inputStreams = make(map[string]*stream.Stream)
-	s.readers = make(map[string]*stream.Reader)
+	defer path.RemoveReader(defs.PathRemoveReaderReq{Author: author})
+
+	s.mutex.Lock()
+	li.stream = strm
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		li.stream = nil
+		li.online = false
+		s.mutex.Unlock()
+	}()
+
+	// wait for the output stream
+	select {
+	case <-s.outputReady:
+	case <-author.closed:
+		return fmt.Errorf("path is not ready anymore")
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+
+	reader := &stream.Reader{Parent: s}
+
+	if input.Type == "video" {
+		err = s.setupVideo(strm, reader, input, li)
+	} else {
+		err = s.setupAudio(strm, reader, input)
+	}
+	if err != nil {
+		return err
+	}
+
+	strm.AddReader(reader)
+	defer strm.RemoveReader(reader)
+
+	s.mutex.Lock()
+	li.online = true
+	s.mutex.Unlock()
+
+	s.Log(logger.Info, "input '%s' is online, medias: %s", input.Path, defs.MediasInfo(strm.Desc.Medias))
+
+	select {
+	case err = <-reader.Error():
+		return err
+
+	case <-author.closed:
+		return fmt.Errorf("path is not ready anymore")
+
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
+
+// firstFormat returns the first format of the given media type.
+func firstFormat(desc *description.Session, typ description.MediaType) (*description.Media, format.Format) {
+	for _, media := range desc.Medias {
+		if media.Type == typ && len(media.Formats) != 0 {
+			return media, media.Formats[0]
+		}
+	}
+	return nil, nil
 }
 
+// createStreamDescription creates a stream description for the Simulcast output,
+// from the attached inputs. All video layers must use the same codec.
+func (s *Source) createStreamDescription() (*description.Session, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 
-// createStreamDescription creates a stream description for the Simulcast output
-func (s *Source) createStreamDescription() *description.Session {
 	desc := &description.Session{}
 
-	// Find first video input to get video format
+	var videoFormat format.Format
+	var videoLayer string
+
 	for _, input := range s.config.Inputs {
-		if input.Type == "video" {
-			if strm, ok := s.inputStreams[input.Path]; ok && strm.Desc != nil {
-				// Find H.264 format
-				for _, media := range strm.Desc.Medias {
-					if media.Type == description.MediaTypeVideo {
-						for _, fmt := range media.Formats {
-							if h264, ok := fmt.(*format.H264); ok {
-								videoMedia := &description.Media{
-									Type: description.MediaTypeVideo,
-									Formats: []format.Format{h264},
-								}
-								desc.Medias = append(desc.Medias, videoMedia)
-								break
-							}
-						}
-					}
-				}
-			}
-			break
+		strm := s.layerMapping[input.Path].stream
+		if input.Type != "video" || strm == nil {
+			continue
+		}
+
+		_, forma := firstFormat(strm.Desc, description.MediaTypeVideo)
+		if forma == nil {
+			return nil, fmt.Errorf("input path '%s' doesn't contain a video track", input.Path)
 		}
+
+		if videoFormat == nil {
+			videoFormat = forma
+			videoLayer = input.Layer
+			continue
+		}
+
+		if forma.Codec() != videoFormat.Codec() {
+			return nil, fmt.Errorf("simulcast layers must use the same codec, but layer '%s' uses %s "+
+				"and layer '%s' uses %s", videoLayer, videoFormat.Codec(), input.Layer, forma.Codec())
+		}
+	}
+
+	if videoFormat == nil {
+		return nil, fmt.Errorf("no video layer is available")
 	}
 
-	// Find audio input to get audio format
+	desc.Medias = append(desc.Medias, &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{videoFormat},
+	})
+
+	// audio is taken from the first audio input
 	for _, input := range s.config.Inputs {
-		if input.Type == "audio" {
-			if strm, ok := s.inputStreams[input.Path]; ok && strm.Desc != nil {
-				// Find Opus format
-				for _, media := range strm.Desc.Medias {
-					if media.Type == description.MediaTypeAudio {
-						for _, fmt := range media.Formats {
-							if opus, ok := fmt.(*format.Opus); ok {
-								audioMedia := &description.Media{
-									Type: description.MediaTypeAudio,
-									Formats: []format.Format{opus},
-								}
-								desc.Medias = append(desc.Medias, audioMedia)
-								break
-							}
-						}
-					}
-				}
-			}
-			break
+		strm := s.layerMapping[input.Path].stream
+		if input.Type != "audio" || strm == nil {
+			continue
 		}
+
+		_, forma := firstFormat(strm.Desc, description.MediaTypeAudio)
+		if forma == nil {
+			return nil, fmt.Errorf("input path '%s' doesn't contain an audio track", input.Path)
+		}
+
+		desc.Medias = append(desc.Medias, &description.Media{
+			Type:    description.MediaTypeAudio,
+			Formats: []format.Format{forma},
+		})
+		break
 	}
 
-	return desc
+	return desc, nil
 }
 
 // simulcastReader is a wrapper that implements defs.Reader
 type simulcastReader struct {
 	source *Source
 	path   string
+
+	closeOnce sync.Once
+	closed    chan struct{}
 }
 
+// Close is called by the input path when it is not ready anymore.
 func (r *simulcastReader) Close() {
-	// Nothing to close
+	r.closeOnce.Do(func() {
+		close(r.closed)
+	})
 }
 
 func (r *simulcastReader) APIReaderDescribe() defs.APIPathSourceOrReader {
@@ -366,90 +509,35 @@ func (r *simulcastReader) APIReaderDescribe() defs.APIPathSourceOrReader {
 	}
 }
 
-
-// startDataForwarding starts forwarding data from input streams
-func (s *Source) startDataForwarding() error {
-	s.Log(logger.Info, "starting data forwarding")
-
-	// Start forwarding goroutine for each input stream
-	for i := range s.config.Inputs {
-		input := &s.config.Inputs[i]
-		s.wg.Add(1)
-		go s.forwardInput(input)
-	}
-
-	return nil
-}
-
-// forwardInput forwards data from a single input
-func (s *Source) forwardInput(input *conf.SimulcastInput) {
-	defer s.wg.Done()
-
-	strm := s.inputStreams[input.Path]
-	reader := s.readers[input.Path]
-	layerInfo := s.layerMapping[input.Path]
-
-	if strm == nil || reader == nil {
-		s.Log(logger.Error, "input stream or reader not found for path: %s", input.Path)
-		return
-	}
-
-	s.Log(logger.Info, "starting forward for input: %s, layer: %s", input.Path, layerInfo.Layer)
-
-	// Forward based on type
-	if input.Type == "video" {
-		s.forwardVideo(strm, reader, input, layerInfo)
-	} else {
-		s.forwardAudio(strm, reader, input)
-	}
-}
-
-// forwardVideo forwards video RTP packets
-func (s *Source) forwardVideo(
+// setupVideo sets up the forwarding of video RTP packets.
+// The codec of the input must match the one of the output stream.
+func (s *Source) setupVideo(
 	strm *stream.Stream,
 	reader *stream.Reader,
 	input *conf.SimulcastInput,
-	layerInfo *layerInfo,
-) {
-	// Find video media
-	var videoMedia *description.Media
-	var h264Format *format.H264
-
-	for _, media := range strm.Desc.Medias {
-		if media.Type == description.MediaTypeVideo {
-			videoMedia = media
-			for _, fmt := range media.Formats {
-				if h264, ok := fmt.(*format.H264); ok {
-					h264Format = h264
-					break
-				}
-			}
-			break
-		}
-	}
-
-	if videoMedia == nil || h264Format == nil {
-		s.Log(logger.Error, "video media or H.264 format not found")
-		return
+	li *layerInfo,
+) error {
+	videoMedia, videoFormat := firstFormat(strm.Desc, description.MediaTypeVideo)
+	if videoMedia == nil {
+		return fmt.Errorf("input path doesn't contain a video track")
 	}
 
-	s.Log(logger.Info, "setting up video forward for path: %s, layer: %s, SSRC: %d",
-		input.Path, layerInfo.Layer, layerInfo.SSRC)
+	outputMedia, outputFormat := firstFormat(s.outputStream.Desc, description.MediaTypeVideo)
 
-	// Set up data callback
-	reader.OnData(videoMedia, h264Format, func(u *unit.Unit) error {
-		select {
-		case <-s.ctx.Done():
-			return fmt.Errorf("context cancelled")
-		default:
-		}
+	if videoFormat.Codec() != outputFormat.Codec() {
+		return fmt.Errorf("layer '%s' uses %s, while the simulcast stream uses %s",
+			input.Layer, videoFormat.Codec(), outputFormat.Codec())
+	}
 
+	reader.OnData(videoMedia, videoFormat, func(u *unit.Unit) error {
 		if u.NilPayload() {
 			return nil
 		}
 
-		// Process RTP packets
 		for _, originalPkt := range u.RTPPackets {
+			atomic.AddUint64(&li.rtpPacketsReceived, 1)
+			atomic.AddUint64(&li.bytesReceived, uint64(originalPkt.MarshalSize()))
+
 			// Clone RTP packet (avoid modifying original)
 			pkt := &rtp.Packet{
 				Header:  originalPkt.Header,
@@ -458,88 +546,46 @@ func (s *Source) forwardVideo(
 			copy(pkt.Payload, originalPkt.Payload)
 
 			// Modify SSRC for Simulcast layer
-			pkt.SSRC = layerInfo.SSRC
-
-			// Write to output stream (path's stream that clients read from)
-			// Find the video media in output stream
-			var outputVideoMedia *description.Media
-			var outputH264Format *format.H264
-			for _, media := range s.outputStream.Desc.Medias {
-				if media.Type == description.MediaTypeVideo {
-					outputVideoMedia = media
-					for _, fmt := range media.Formats {
-						if h264, ok := fmt.(*format.H264); ok {
-							outputH264Format = h264
-							break
-						}
-					}
-					break
-				}
-			}
+			pkt.SSRC = li.SSRC
 
-			if outputVideoMedia != nil && outputH264Format != nil {
-				// Calculate PTS from RTP timestamp
-				pts := int64(pkt.Timestamp)
-				s.outputStream.WriteRTPPacket(outputVideoMedia, outputH264Format, pkt, u.NTP, pts)
-			} else {
-				s.Log(logger.Warn, "output stream video media not found")
-			}
+			// Calculate PTS from RTP timestamp
+			pts := int64(pkt.Timestamp)
+			s.outputStream.WriteRTPPacket(outputMedia, outputFormat, pkt, u.NTP, pts)
 		}
 
 		return nil
 	})
 
-	s.Log(logger.Info, "video forward started for path: %s", input.Path)
-
-	// Wait for context cancellation
-	<-s.ctx.Done()
-
-	s.Log(logger.Info, "video forward stopped for path: %s", input.Path)
+	return nil
 }
 
-// forwardAudio forwards audio RTP packets
-func (s *Source) forwardAudio(
+// setupAudio sets up the forwarding of audio RTP packets.
+func (s *Source) setupAudio(
 	strm *stream.Stream,
 	reader *stream.Reader,
 	input *conf.SimulcastInput,
-) {
-	// Find audio media
-	var audioMedia *description.Media
-	var opusFormat *format.Opus
-
-	for _, media := range strm.Desc.Medias {
-		if media.Type == description.MediaTypeAudio {
-			audioMedia = media
-			for _, fmt := range media.Formats {
-				if opus, ok := fmt.(*format.Opus); ok {
-					opusFormat = opus
-					break
-				}
-			}
-			break
-		}
+) error {
+	audioMedia, audioFormat := firstFormat(strm.Desc, description.MediaTypeAudio)
+	if audioMedia == nil {
+		return fmt.Errorf("input path doesn't contain an audio track")
 	}
 
-	if audioMedia == nil || opusFormat == nil {
-		s.Log(logger.Error, "audio media or Opus format not found")
-		return
+	outputMedia, outputFormat := firstFormat(s.outputStream.Desc, description.MediaTypeAudio)
+	if outputMedia == nil {
+		return fmt.Errorf("audio of input '%s' is not part of the simulcast stream, "+
+			"since it was not available at startup", input.Path)
 	}
 
-	s.Log(logger.Info, "setting up audio forward for path: %s", input.Path)
-
-	// Set up data callback
-	reader.OnData(audioMedia, opusFormat, func(u *unit.Unit) error {
-		select {
-		case <-s.ctx.Done():
-			return fmt.Errorf("context cancelled")
-		default:
-		}
+	if audioFormat.Codec() != outputFormat.Codec() {
+		return fmt.Errorf("input '%s' uses %s, while the simulcast stream uses %s",
+			input.Path, audioFormat.Codec(), outputFormat.Codec())
+	}
 
+	reader.OnData(audioMedia, audioFormat, func(u *unit.Unit) error {
 		if u.NilPayload() {
 			return nil
 		}
 
-		// Process RTP packets
 		for _, originalPkt := range u.RTPPackets {
 			// Clone RTP packet
 			pkt := &rtp.Packet{
@@ -548,40 +594,13 @@ func (s *Source) forwardAudio(
 			}
 			copy(pkt.Payload, originalPkt.Payload)
 
-			// Write to output stream (path's stream that clients read from)
-			// Find the audio media in output stream
-			var outputAudioMedia *description.Media
-			var outputOpusFormat *format.Opus
-			for _, media := range s.outputStream.Desc.Medias {
-				if media.Type == description.MediaTypeAudio {
-					outputAudioMedia = media
-					for _, fmt := range media.Formats {
-						if opus, ok := fmt.(*format.Opus); ok {
-							outputOpusFormat = opus
-							break
-						}
-					}
-					break
-				}
-			}
-
-			if outputAudioMedia != nil && outputOpusFormat != nil {
-				// Calculate PTS from RTP timestamp
-				pts := int64(pkt.Timestamp)
-				s.outputStream.WriteRTPPacket(outputAudioMedia, outputOpusFormat, pkt, u.NTP, pts)
-			} else {
-				s.Log(logger.Warn, "output stream audio media not found")
-			}
+			// Calculate PTS from RTP timestamp
+			pts := int64(pkt.Timestamp)
+			s.outputStream.WriteRTPPacket(outputMedia, outputFormat, pkt, u.NTP, pts)
 		}
 
 		return nil
 	})
 
-	s.Log(logger.Info, "audio forward started for path: %s", input.Path)
-
-	// Wait for context cancellation
-	<-s.ctx.Done()
-
-	s.Log(logger.Info, "audio forward stopped for path: %s", input.Path)
+	return nil
 }
-
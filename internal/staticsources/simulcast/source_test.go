@@ -0,0 +1,211 @@
+package simulcast
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type dummyPath struct{}
+
+func (dummyPath) Name() string                                  { return "" }
+func (dummyPath) SafeConf() *conf.Path                          { return &conf.Path{} }
+func (dummyPath) ExternalCmdEnv() externalcmd.Environment       { return nil }
+func (dummyPath) RemovePublisher(_ defs.PathRemovePublisherReq) {}
+func (dummyPath) RemoveReader(_ defs.PathRemoveReaderReq)       {}
+
+type dummyPathManager struct {
+	mutex   sync.Mutex
+	streams map[string]*stream.Stream
+	authors map[string]defs.Reader
+}
+
+func (pm *dummyPathManager) AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	strm, ok := pm.streams[req.AccessRequest.Name]
+	if !ok {
+		return nil, nil, defs.PathNoStreamAvailableError{PathName: req.AccessRequest.Name}
+	}
+
+	pm.authors[req.AccessRequest.Name] = req.Author
+	return &dummyPath{}, strm, nil
+}
+
+func (pm *dummyPathManager) RemoveReader(_ defs.PathRemoveReaderReq) {}
+
+// setNotReady simulates an input path whose publisher went away.
+func (pm *dummyPathManager) setNotReady(name string) *stream.Stream {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	strm := pm.streams[name]
+	delete(pm.streams, name)
+	pm.authors[name].Close()
+	return strm
+}
+
+func (pm *dummyPathManager) setReady(name string, strm *stream.Stream) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.streams[name] = strm
+}
+
+func newInputStream(t *testing.T) *stream.Stream {
+	strm := &stream.Stream{
+		WriteQueueSize:     512,
+		RTPMaxPayloadSize:  1450,
+		Desc:               &description.Session{Medias: []*description.Media{test.MediaH264}},
+		GenerateRTPPackets: true,
+		Parent:             test.NilLogger,
+	}
+	err := strm.Initialize()
+	require.NoError(t, err)
+	return strm
+}
+
+func layersOnline(so *Source) map[string]bool {
+	ret := make(map[string]bool)
+	for _, l := range so.APISourceDescribe().Layers {
+		ret[l.Layer] = l.Online
+	}
+	return ret
+}
+
+func TestSourceLayersComeAndGo(t *testing.T) {
+	high := newInputStream(t)
+	defer high.Close()
+
+	low := newInputStream(t)
+	defer low.Close()
+
+	pm := &dummyPathManager{
+		streams: map[string]*stream.Stream{
+			"cam~high": high,
+			"cam~low":  low,
+		},
+		authors: make(map[string]defs.Reader),
+	}
+
+	p := &test.StaticSourceParent{}
+	p.Initialize()
+	defer p.Close()
+
+	so := New(
+		&conf.Path{
+			SimulcastConfig: &conf.SimulcastConfig{
+				Enable: true,
+				Inputs: []conf.SimulcastInput{
+					{Path: "cam~high", Layer: "high", Bitrate: 2000000, Type: "video"},
+					{Path: "cam~low", Layer: "low", Bitrate: 500000, Type: "video"},
+				},
+			},
+		},
+		conf.LogLevel(0), 0, 0, 512, 0, 1450, nil, pm, p).(*Source)
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	defer func() {
+		ctxCancel()
+		<-done
+	}()
+
+	go func() {
+		defer close(done)
+		err := so.Run(defs.StaticSourceRunParams{
+			Context:    ctx,
+			ReloadConf: make(chan *conf.Path),
+		})
+		require.NoError(t, err)
+	}()
+
+	require.Eventually(t, func() bool {
+		return layersOnline(so)["high"] && layersOnline(so)["low"]
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// the low layer goes away, while the high one keeps working
+	pm.setNotReady("cam~low")
+
+	require.Eventually(t, func() bool {
+		return !layersOnline(so)["low"]
+	}, 5*time.Second, 50*time.Millisecond)
+	require.True(t, layersOnline(so)["high"])
+
+	high.WriteUnit(test.MediaH264, test.FormatH264, &unit.Unit{
+		PTS:     0,
+		NTP:     time.Time{},
+		Payload: unit.PayloadH264{{5, 1}},
+	})
+
+	u := <-p.Unit
+	require.Equal(t, unit.PayloadH264{test.FormatH264.SPS, test.FormatH264.PPS, {5, 1}}, u.Payload)
+
+	// the low layer comes back and is attached again
+	pm.setReady("cam~low", low)
+
+	require.Eventually(t, func() bool {
+		return layersOnline(so)["low"]
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestSourceMixedCodecs(t *testing.T) {
+	high := newInputStream(t)
+	defer high.Close()
+
+	low := &stream.Stream{
+		WriteQueueSize:    512,
+		RTPMaxPayloadSize: 1450,
+		Desc: &description.Session{Medias: []*description.Media{{
+			Type:    description.MediaTypeVideo,
+			Formats: []format.Format{&format.VP9{PayloadTyp: 96}},
+		}}},
+		Parent: test.NilLogger,
+	}
+	err := low.Initialize()
+	require.NoError(t, err)
+	defer low.Close()
+
+	pm := &dummyPathManager{
+		streams: map[string]*stream.Stream{
+			"cam~high": high,
+			"cam~low":  low,
+		},
+		authors: make(map[string]defs.Reader),
+	}
+
+	p := &test.StaticSourceParent{}
+	p.Initialize()
+
+	so := New(
+		&conf.Path{
+			SimulcastConfig: &conf.SimulcastConfig{
+				Enable: true,
+				Inputs: []conf.SimulcastInput{
+					{Path: "cam~high", Layer: "high", Type: "video"},
+					{Path: "cam~low", Layer: "low", Type: "video"},
+				},
+			},
+		},
+		conf.LogLevel(0), 0, 0, 512, 0, 1450, nil, pm, p).(*Source)
+
+	err = so.Run(defs.StaticSourceRunParams{
+		Context:    context.Background(),
+		ReloadConf: make(chan *conf.Path),
+	})
+	require.EqualError(t, err, "simulcast layers must use the same codec, "+
+		"but layer 'high' uses H264 and layer 'low' uses VP9")
+}
@@ -10,6 +10,11 @@ import (
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	outputCheckInterval = 1 * time.Second
 )
 
 // staticSource is the interface that Source implements
@@ -252,24 +257,55 @@ func (s *Source) Run(params defs.StaticSourceRunParams) error {
 	defer s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
 
 	// The transcoder source acts as a reader for the transcoded output stream
-	// Create a simple reader that just keeps the connection alive
+	// and forwards its data to the path
 	reader := &stream.Reader{Parent: s.logger}
+
+	for _, medi := range outputStream.Desc.Medias {
+		for _, forma := range medi.Formats {
+			reader.OnData(medi, forma, func(u *unit.Unit) error {
+				// units are shared with other readers, RTP packets are generated again
+				setReadyRes.Stream.WriteUnit(medi, forma, &unit.Unit{
+					PTS:     u.PTS,
+					NTP:     u.NTP,
+					Payload: u.Payload,
+				})
+				return nil
+			})
+		}
+	}
+
 	outputStream.AddReader(reader)
 	defer outputStream.RemoveReader(reader)
 
 	s.Log(logger.Info, "transcoder source ready, stream description: %s", defs.MediasInfo(outputStream.Desc.Medias))
 
-	// Keep the source running until terminated
-	// Listen to both terminate channel and context cancellation
-	select {
-	case <-s.terminate:
-		s.Log(logger.Info, "stopped by terminate signal")
-	case <-params.Context.Done():
-		s.Log(logger.Info, "stopped by context cancellation")
-	}
+	// The output stream is replaced when the encoder is restarted by its supervisor.
+	// In this case, the source is restarted too, and the path is not ready in the meanwhile.
+	checkTicker := time.NewTicker(outputCheckInterval)
+	defer checkTicker.Stop()
 
-	close(s.done)
-	return nil
+	for {
+		select {
+		case <-checkTicker.C:
+			results = getTranscoderMethod.Call([]reflect.Value{reflect.ValueOf(s.outputPath)})
+			if results[0].IsNil() || results[0].Interface().(*stream.Stream) != outputStream {
+				return fmt.Errorf("transcoder output stream '%s' has been restarted", s.outputPath)
+			}
+
+		case err = <-reader.Error():
+			return err
+
+		case <-s.terminate:
+			s.Log(logger.Info, "stopped by terminate signal")
+			close(s.done)
+			return nil
+
+		case <-params.Context.Done():
+			s.Log(logger.Info, "stopped by context cancellation")
+			close(s.done)
+			return nil
+		}
+	}
 }
 
 // Stop implements defs.Source.
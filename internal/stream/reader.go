@@ -39,6 +39,11 @@ func (r *Reader) OnData(medi *description.Media, forma format.Format, cb OnDataF
 	r.onDatas[medi][forma] = cb
 }
 
+// Callback returns the callback registered for given format, or nil.
+func (r *Reader) Callback(medi *description.Media, forma format.Format) OnDataFunc {
+	return r.onDatas[medi][forma]
+}
+
 // Formats returns all formats for which the reader has registered a OnData callback.
 func (r *Reader) Formats() []format.Format {
 	var out []format.Format
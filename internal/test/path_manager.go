@@ -8,10 +8,12 @@ import (
 
 // PathManager is a dummy path manager.
 type PathManager struct {
-	FindPathConfImpl func(req defs.PathFindPathConfReq) (*conf.Path, error)
-	DescribeImpl     func(req defs.PathDescribeReq) defs.PathDescribeRes
-	AddPublisherImpl func(req defs.PathAddPublisherReq) (defs.Path, *stream.Stream, error)
-	AddReaderImpl    func(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+	FindPathConfImpl         func(req defs.PathFindPathConfReq) (*conf.Path, error)
+	AddSimulcastLayerImpl    func(req defs.PathAddSimulcastLayerReq) (*conf.Path, error)
+	RemoveSimulcastLayerImpl func(req defs.PathRemoveSimulcastLayerReq)
+	DescribeImpl             func(req defs.PathDescribeReq) defs.PathDescribeRes
+	AddPublisherImpl         func(req defs.PathAddPublisherReq) (defs.Path, *stream.Stream, error)
+	AddReaderImpl            func(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
 }
 
 // FindPathConf implements PathManager.
@@ -19,6 +21,16 @@ func (pm *PathManager) FindPathConf(req defs.PathFindPathConfReq) (*conf.Path, e
 	return pm.FindPathConfImpl(req)
 }
 
+// AddSimulcastLayer implements PathManager.
+func (pm *PathManager) AddSimulcastLayer(req defs.PathAddSimulcastLayerReq) (*conf.Path, error) {
+	return pm.AddSimulcastLayerImpl(req)
+}
+
+// RemoveSimulcastLayer implements PathManager.
+func (pm *PathManager) RemoveSimulcastLayer(req defs.PathRemoveSimulcastLayerReq) {
+	pm.RemoveSimulcastLayerImpl(req)
+}
+
 // Describe implements PathManager.
 func (pm *PathManager) Describe(req defs.PathDescribeReq) defs.PathDescribeRes {
 	return pm.DescribeImpl(req)
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// S3Server is a in-memory server that implements the subset of the S3 API
+// that is used by the recorder and by the playback server.
+type S3Server struct {
+	Bucket string
+
+	mutex   sync.Mutex
+	objects map[string][]byte
+	server  *httptest.Server
+}
+
+// Initialize initializes S3Server.
+func (s *S3Server) Initialize() {
+	s.objects = make(map[string][]byte)
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+}
+
+// Close closes S3Server.
+func (s *S3Server) Close() {
+	s.server.Close()
+}
+
+// URL returns the URL of the bucket.
+func (s *S3Server) URL() string {
+	return s.server.URL + "/" + s.Bucket
+}
+
+// Objects returns a copy of the stored objects.
+func (s *S3Server) Objects() map[string][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := make(map[string][]byte, len(s.objects))
+	for k, v := range s.objects {
+		ret[k] = v
+	}
+	return ret
+}
+
+// SetObject stores an object.
+func (s *S3Server) SetObject(key string, buf []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.objects[key] = buf
+}
+
+func (s *S3Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !strings.HasPrefix(r.URL.Path, "/"+s.Bucket) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+s.Bucket), "/")
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		s.handleList(w, r)
+
+	case r.Method == http.MethodGet:
+		buf, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if rang := r.Header.Get("Range"); rang != "" {
+			start, end, _ := strings.Cut(strings.TrimPrefix(rang, "bytes="), "-")
+			startPos, _ := strconv.ParseInt(start, 10, 64)
+			endPos := int64(len(buf)) - 1
+			if end != "" {
+				endPos, _ = strconv.ParseInt(end, 10, 64)
+			}
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(buf[startPos : endPos+1]) //nolint:errcheck
+			return
+		}
+
+		w.Write(buf) //nolint:errcheck
+
+	case r.Method == http.MethodPut:
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = buf
+
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *S3Server) handleList(w http.ResponseWriter, r *http.Request) {
+	type content struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	}
+
+	type listBucketResult struct {
+		XMLName  xml.Name  `xml:"ListBucketResult"`
+		Contents []content `xml:"Contents"`
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+
+	var res listBucketResult
+
+	for k, v := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			res.Contents = append(res.Contents, content{Key: k, Size: int64(len(v))})
+		}
+	}
+
+	sort.Slice(res.Contents, func(i, j int) bool {
+		return res.Contents[i].Key < res.Contents[j].Key
+	})
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(res) //nolint:errcheck
+}
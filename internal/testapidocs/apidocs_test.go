@@ -84,6 +84,23 @@ func TestAPIDocs(t *testing.T) {
 			"PathReader",
 			defs.APIPathSourceOrReader{},
 		},
+		{
+			"Forwarder",
+			defs.APIForwarder{},
+		},
+
+		{
+			"TranscoderOutput",
+			defs.APITranscoderOutput{},
+		},
+		{
+			"ForwarderList",
+			defs.APIForwarderList{},
+		},
+		{
+			"ForwardRequest",
+			defs.APIForwardReq{},
+		},
 		{
 			"HLSMuxer",
 			defs.APIHLSMuxer{},
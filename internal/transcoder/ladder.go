@@ -0,0 +1,302 @@
+package transcoder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+// Ladder produces all outputs with a single FFmpeg process.
+//
+// The input is decoded once and scaled into multiple renditions through a filter graph.
+// Audio is encoded once and shared between renditions.
+// Each output is written into a dedicated program of a multi-program MPEG-TS stream,
+// that is demuxed back into a stream per output.
+type Ladder struct {
+	outputs []*Output
+	pool    *ProcessPool
+	logger  logger.Writer
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	done      chan struct{}
+
+	// outputs produced by the current process
+	active []*Output
+}
+
+// NewLadder creates a new Ladder.
+func NewLadder(
+	outputs []*Output,
+	pool *ProcessPool,
+	parent logger.Writer,
+) *Ladder {
+	return &Ladder{
+		outputs: outputs,
+		pool:    pool,
+		logger:  parent,
+	}
+}
+
+// Log implements logger.Writer.
+func (l *Ladder) Log(level logger.Level, format string, args ...any) {
+	l.logger.Log(level, "[transcoder ladder] "+format, args...)
+}
+
+// Start starts the ladder.
+func (l *Ladder) Start(inputStream *stream.Stream) error {
+	if l.done != nil {
+		return fmt.Errorf("ladder already active")
+	}
+
+	l.Log(logger.Info, "starting with %d renditions", len(l.outputs))
+
+	l.ctx, l.ctxCancel = context.WithCancel(context.Background())
+	l.done = make(chan struct{})
+
+	go l.run(inputStream)
+
+	return nil
+}
+
+// Stop stops the ladder.
+func (l *Ladder) Stop() {
+	if l.done == nil {
+		return
+	}
+
+	l.ctxCancel()
+	<-l.done
+	l.done = nil
+
+	l.Log(logger.Info, "stopped")
+}
+
+func (l *Ladder) run(inputStream *stream.Stream) {
+	defer close(l.done)
+
+	statsDone := make(chan struct{}, len(l.outputs))
+	for _, o := range l.outputs {
+		go o.runStats(l.ctx, statsDone)
+	}
+	defer func() {
+		for range l.outputs {
+			<-statsDone
+		}
+	}()
+
+	supervise(l.ctx, l.pool, l, func() *process {
+		var args []string
+		args, l.active = l.buildFFmpegArgs(inputStream.Desc)
+
+		for _, o := range l.active {
+			o.resetStall()
+		}
+
+		return &process{
+			args:        args,
+			inputStream: inputStream,
+			logger:      l,
+			readOutput:  l.readOutput,
+			checkStall:  l.checkStall,
+		}
+	}, func() {
+		for _, o := range l.outputs {
+			o.restarted()
+		}
+	})
+}
+
+// checkStall returns an error when any rendition stopped producing output.
+func (l *Ladder) checkStall() error {
+	for _, o := range l.active {
+		err := o.checkStall()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readOutput demuxes the programs of the FFmpeg output and passes each of them
+// to the related output.
+func (l *Ladder) readOutput(r io.Reader) error {
+	readers := make([]*io.PipeReader, len(l.active))
+	writers := make([]io.Writer, len(l.active))
+	pipeWriters := make([]*io.PipeWriter, len(l.active))
+
+	for i := range l.active {
+		readers[i], pipeWriters[i] = io.Pipe()
+		writers[i] = pipeWriters[i]
+	}
+
+	errs := make(chan error, len(l.active)+1)
+
+	for i, o := range l.active {
+		go func() {
+			err := o.readOutput(readers[i])
+			readers[i].CloseWithError(err)
+			errs <- err
+		}()
+	}
+
+	// the demuxer is not waited, since it returns when FFmpeg is killed.
+	go func() {
+		d := &programDemuxer{
+			R:       r,
+			Writers: writers,
+		}
+		d.initialize()
+		errs <- d.run()
+	}()
+
+	err := <-errs
+
+	for i := range l.active {
+		pipeWriters[i].CloseWithError(err)
+		readers[i].CloseWithError(err)
+	}
+
+	// wait for the outputs, that may be still using the pipes
+	remaining := len(l.active)
+	for remaining > 0 {
+		<-errs
+		remaining--
+	}
+
+	return err
+}
+
+// buildFFmpegArgs builds FFmpeg command line arguments.
+// It returns the outputs that can be produced from the input, in program order.
+func (l *Ladder) buildFFmpegArgs(inputDesc *description.Session) ([]string, []*Output) {
+	hasVideo := false
+	hasAudio := false
+
+	for _, medi := range inputDesc.Medias {
+		switch medi.Type {
+		case description.MediaTypeVideo:
+			hasVideo = true
+		case description.MediaTypeAudio:
+			hasAudio = true
+		}
+	}
+
+	var active []*Output
+	var videoConfs []*conf.SRTTranscodingVideoConfig
+
+	for _, o := range l.outputs {
+		switch {
+		case o.config.Type == "video" && o.config.Video != nil && hasVideo:
+			active = append(active, o)
+			videoConfs = append(videoConfs, o.config.Video)
+
+		case o.config.Type == "audio" && o.config.Audio != nil && hasAudio:
+			active = append(active, o)
+
+		default:
+			o.Log(logger.Warn, "input doesn't contain the needed tracks, skipping")
+		}
+	}
+
+	args := []string{
+		"-f", "mpegts",
+		"-i", "pipe:0",
+	}
+
+	// decode the video once and split it into a branch per rendition
+	if len(videoConfs) != 0 {
+		split := fmt.Sprintf("[0:v]split=%d", len(videoConfs))
+		filters := []string{}
+
+		for j, video := range videoConfs {
+			split += fmt.Sprintf("[s%d]", j)
+			filters = append(filters, fmt.Sprintf("[s%d]scale=%s,fps=%d[v%d]",
+				j, strings.Replace(video.Resolution, "x", ":", 1), video.Framerate, j))
+		}
+
+		args = append(args, "-filter_complex", strings.Join(append([]string{split}, filters...), ";"))
+	}
+
+	var programs []string
+	streamIndex := 0
+	videoIndex := 0
+	audioIndex := 0
+
+	// audio is encoded once per configuration and shared between programs
+	type audioConf struct {
+		bitrate    uint
+		samplerate uint
+	}
+	audioStreams := make(map[audioConf]int)
+
+	addAudio := func(bitrate uint, samplerate uint) int {
+		if i, ok := audioStreams[audioConf{bitrate, samplerate}]; ok {
+			return i
+		}
+
+		args = append(args,
+			"-map", "0:a:0",
+			fmt.Sprintf("-c:a:%d", audioIndex), "libopus",
+			fmt.Sprintf("-b:a:%d", audioIndex), fmt.Sprintf("%dk", bitrate/1000),
+			fmt.Sprintf("-ar:a:%d", audioIndex), fmt.Sprintf("%d", samplerate),
+			fmt.Sprintf("-ac:a:%d", audioIndex), "2",
+		)
+		audioStreams[audioConf{bitrate, samplerate}] = streamIndex
+		audioIndex++
+		streamIndex++
+		return streamIndex - 1
+	}
+
+	for i, o := range active {
+		program := fmt.Sprintf("program_num=%d:title=%s", i+1, o.config.Path)
+
+		if o.config.Type == "video" {
+			video := o.config.Video
+			args = append(args,
+				"-map", fmt.Sprintf("[v%d]", videoIndex),
+				fmt.Sprintf("-c:v:%d", videoIndex), "libx264",
+				fmt.Sprintf("-preset:v:%d", videoIndex), video.Preset,
+				fmt.Sprintf("-tune:v:%d", videoIndex), "zerolatency",
+				fmt.Sprintf("-b:v:%d", videoIndex), fmt.Sprintf("%dk", video.Bitrate/1000),
+				fmt.Sprintf("-g:v:%d", videoIndex), fmt.Sprintf("%d", video.Framerate*2),
+				fmt.Sprintf("-keyint_min:v:%d", videoIndex), fmt.Sprintf("%d", video.Framerate*2),
+				fmt.Sprintf("-bf:v:%d", videoIndex), "0",
+				fmt.Sprintf("-pix_fmt:v:%d", videoIndex), "yuv420p",
+			)
+			program += fmt.Sprintf(":st=%d", streamIndex)
+			videoIndex++
+			streamIndex++
+
+			if hasAudio {
+				program += fmt.Sprintf(":st=%d", addAudio(64000, 48000))
+			}
+		} else {
+			program += fmt.Sprintf(":st=%d", addAudio(o.config.Audio.Bitrate, o.config.Audio.Samplerate))
+		}
+
+		programs = append(programs, program)
+	}
+
+	for _, program := range programs {
+		args = append(args, "-program", program)
+	}
+
+	// Output configuration
+	args = append(args,
+		"-f", "mpegts",
+		"-fflags", "+discardcorrupt+genpts+nobuffer",
+		"-max_delay", "100000",
+		"-avoid_negative_ts", "make_zero",
+		"pipe:1",
+	)
+
+	return args, active
+}
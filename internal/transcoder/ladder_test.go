@@ -0,0 +1,115 @@
+package transcoder
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestLadderFFmpegArgs(t *testing.T) {
+	confs := []conf.SRTTranscodingOutput{
+		{
+			Path: "720p",
+			Type: "video",
+			Video: &conf.SRTTranscodingVideoConfig{
+				Resolution: "1280x720",
+				Bitrate:    2000000,
+				Framerate:  30,
+				Preset:     "veryfast",
+			},
+		},
+		{
+			Path: "360p",
+			Type: "video",
+			Video: &conf.SRTTranscodingVideoConfig{
+				Resolution: "640x360",
+				Bitrate:    500000,
+				Framerate:  15,
+				Preset:     "ultrafast",
+			},
+		},
+		{
+			Path: "audio",
+			Type: "audio",
+			Audio: &conf.SRTTranscodingAudioConfig{
+				Bitrate:    32000,
+				Samplerate: 24000,
+			},
+		},
+	}
+
+	var outputs []*Output
+	for i := range confs {
+		outputs = append(outputs, NewOutput(&confs[i], 0, nil, test.NilLogger))
+	}
+
+	l := NewLadder(outputs, nil, test.NilLogger)
+
+	args, active := l.buildFFmpegArgs(&description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{}},
+		},
+		{
+			Type:    description.MediaTypeAudio,
+			Formats: []format.Format{&format.Opus{}},
+		},
+	}})
+
+	require.Equal(t, outputs, active)
+	require.Equal(t, []string{
+		"-f", "mpegts",
+		"-i", "pipe:0",
+		"-filter_complex", "[0:v]split=2[s0][s1];[s0]scale=1280:720,fps=30[v0];[s1]scale=640:360,fps=15[v1]",
+		"-map", "[v0]",
+		"-c:v:0", "libx264",
+		"-preset:v:0", "veryfast",
+		"-tune:v:0", "zerolatency",
+		"-b:v:0", "2000k",
+		"-g:v:0", "60",
+		"-keyint_min:v:0", "60",
+		"-bf:v:0", "0",
+		"-pix_fmt:v:0", "yuv420p",
+		"-map", "0:a:0",
+		"-c:a:0", "libopus",
+		"-b:a:0", "64k",
+		"-ar:a:0", "48000",
+		"-ac:a:0", "2",
+		"-map", "[v1]",
+		"-c:v:1", "libx264",
+		"-preset:v:1", "ultrafast",
+		"-tune:v:1", "zerolatency",
+		"-b:v:1", "500k",
+		"-g:v:1", "30",
+		"-keyint_min:v:1", "30",
+		"-bf:v:1", "0",
+		"-pix_fmt:v:1", "yuv420p",
+		"-map", "0:a:0",
+		"-c:a:1", "libopus",
+		"-b:a:1", "32k",
+		"-ar:a:1", "24000",
+		"-ac:a:1", "2",
+		"-program", "program_num=1:title=720p:st=0:st=1",
+		"-program", "program_num=2:title=360p:st=2:st=1",
+		"-program", "program_num=3:title=audio:st=3",
+		"-f", "mpegts",
+		"-fflags", "+discardcorrupt+genpts+nobuffer",
+		"-max_delay", "100000",
+		"-avoid_negative_ts", "make_zero",
+		"pipe:1",
+	}, args)
+
+	// without audio, the audio output is skipped
+	_, active = l.buildFFmpegArgs(&description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{}},
+		},
+	}})
+	require.Equal(t, outputs[:2], active)
+}
@@ -2,109 +2,133 @@
 package transcoder
 
 import (
-	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
 // Manager manages transcoding for a path.
 type Manager struct {
-	config      *conf.SRTTranscodingConfig
-	inputStream *stream.Stream
-	outputs     map[string]*Output
-	logger      logger.Writer
-	ctx         context.Context
-	ctxCancel   context.CancelFunc
-	wg          sync.WaitGroup
+	config  *conf.SRTTranscodingConfig
+	outputs []*Output
+	ladder  *Ladder
+	logger  logger.Writer
 
 	// State
 	active bool
+	mutex  sync.RWMutex
 }
 
 // NewManager creates a new transcoder manager.
 func NewManager(
 	config *conf.SRTTranscodingConfig,
+	pool *ProcessPool,
 	parent logger.Writer,
 ) *Manager {
-	ctx, ctxCancel := context.WithCancel(context.Background())
-
-	return &Manager{
-		config:    config,
-		outputs:   make(map[string]*Output),
-		logger:    parent,
-		ctx:       ctx,
-		ctxCancel: ctxCancel,
+	m := &Manager{
+		config: config,
+		logger: parent,
 	}
+
+	if config != nil && config.Enable {
+		for i := range config.Outputs {
+			m.outputs = append(m.outputs, NewOutput(&config.Outputs[i], time.Duration(config.StallTimeout), pool, parent))
+		}
+
+		if config.Ladder && len(m.outputs) != 0 {
+			m.ladder = NewLadder(m.outputs, pool, parent)
+		}
+	}
+
+	return m
 }
 
 // Start starts transcoding for the given input stream.
 func (m *Manager) Start(inputStream *stream.Stream) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	if m.active {
 		return fmt.Errorf("transcoder already active")
 	}
 
-	if m.config == nil || !m.config.Enable {
+	if len(m.outputs) == 0 {
 		m.logger.Log(logger.Debug, "transcoding disabled, skipping")
 		return nil
 	}
 
-	m.logger.Log(logger.Info, "starting transcoder with %d outputs", len(m.config.Outputs))
-	m.inputStream = inputStream
+	m.logger.Log(logger.Info, "starting transcoder with %d outputs", len(m.outputs))
 	m.active = true
 
-	// Create and start outputs
-	for _, outputConfig := range m.config.Outputs {
-		output, err := NewOutput(&outputConfig, m.logger)
-		if err != nil {
-			return fmt.Errorf("failed to create output %s: %w", outputConfig.Path, err)
-		}
+	if m.ladder != nil {
+		return m.ladder.Start(inputStream)
+	}
 
-		if err := output.Start(inputStream); err != nil {
-			return fmt.Errorf("failed to start output %s: %w", outputConfig.Path, err)
+	for _, output := range m.outputs {
+		err := output.Start(inputStream)
+		if err != nil {
+			return fmt.Errorf("failed to start output %s: %w", output.config.Path, err)
 		}
-
-		m.outputs[outputConfig.Path] = output
 	}
 
-	m.logger.Log(logger.Info, "transcoder started successfully")
 	return nil
 }
 
 // Stop stops transcoding.
 func (m *Manager) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	if !m.active {
 		return
 	}
 
 	m.logger.Log(logger.Info, "stopping transcoder")
 	m.active = false
-	m.ctxCancel()
 
-	// Stop all outputs
-	for name, output := range m.outputs {
-		output.Stop()
-		m.logger.Log(logger.Debug, "stopped output %s", name)
+	if m.ladder != nil {
+		m.ladder.Stop()
+		return
 	}
 
-	m.wg.Wait()
-	m.outputs = make(map[string]*Output)
-	m.logger.Log(logger.Info, "transcoder stopped")
+	for _, output := range m.outputs {
+		output.Stop()
+	}
 }
 
 // GetOutputStream returns the output stream for a given path.
 func (m *Manager) GetOutputStream(outputPath string) *stream.Stream {
-	if output, exists := m.outputs[outputPath]; exists {
-		return output.GetStream()
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, output := range m.outputs {
+		if output.config.Path == outputPath {
+			return output.GetStream()
+		}
 	}
 	return nil
 }
 
 // IsActive returns whether the transcoder is active.
 func (m *Manager) IsActive() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
 	return m.active
 }
 
+// APIOutputsList returns the outputs, used by the API and metrics.
+func (m *Manager) APIOutputsList() []*defs.APITranscoderOutput {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	items := []*defs.APITranscoderOutput{}
+	for _, output := range m.outputs {
+		items = append(items, output.APIItem())
+	}
+	return items
+}
@@ -1,257 +1,279 @@
 package transcoder
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
-	"os/exec"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v5/pkg/description"
-	"github.com/bluenviron/gortsplib/v5/pkg/format"
+
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/mpegts"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	restartMinPause     = 1 * time.Second
+	restartMaxPause     = 30 * time.Second
+	restartResetAfter   = 30 * time.Second
+	defaultStallTimeout = 10 * time.Second
+	stallCheckInterval  = 1 * time.Second
+	statsInterval       = 1 * time.Second
+	outputQueueSize     = 64 // small buffer for low latency
+	outputMaxPayload    = 1460
 )
 
 // Output represents a single transcoding output.
+//
+// FFmpeg is supervised: when it exits, it is restarted with an exponential backoff,
+// and when it stops producing output for StallTimeout, it is killed and restarted.
+// The number of FFmpeg processes that run at the same time is limited by a ProcessPool.
 type Output struct {
-	config     *conf.SRTTranscodingOutput
-	stream     *stream.Stream
-	process    *exec.Cmd
-	stdin      io.WriteCloser
-	stdout     io.ReadCloser
-	stderr     io.ReadCloser
-	reader     *stream.Reader
-	logger     logger.Writer
-	ctx        context.Context
-	ctxCancel  context.CancelFunc
-	wg         sync.WaitGroup
-
-	// State
-	active bool
+	config       *conf.SRTTranscodingOutput
+	stallTimeout time.Duration
+	pool         *ProcessPool
+	logger       logger.Writer
+	ctx          context.Context
+	ctxCancel    context.CancelFunc
+	done         chan struct{}
+	mutex        sync.RWMutex
+
+	// output stream, available when FFmpeg is producing data
+	stream *stream.Stream
+
+	// statistics
+	framesOut  uint64
+	restarts   uint64
+	fps        float64
+	lastOutput int64
 }
 
 // NewOutput creates a new transcoding output.
 func NewOutput(
 	config *conf.SRTTranscodingOutput,
+	stallTimeout time.Duration,
+	pool *ProcessPool,
 	parent logger.Writer,
-) (*Output, error) {
-	ctx, ctxCancel := context.WithCancel(context.Background())
-
-	// Create output stream
-	strm := &stream.Stream{
-		WriteQueueSize:     64, // Small buffer for low latency
-		RTPMaxPayloadSize:  1460,
-		Desc:               createStreamDescription(config),
-		GenerateRTPPackets: true,
-		FillNTP:            true,
-		Parent:             parent,
+) *Output {
+	if stallTimeout == 0 {
+		stallTimeout = defaultStallTimeout
 	}
 
-	if err := strm.Initialize(); err != nil {
-		return nil, fmt.Errorf("failed to initialize stream: %w", err)
+	return &Output{
+		config:       config,
+		stallTimeout: stallTimeout,
+		pool:         pool,
+		logger:       parent,
 	}
+}
 
-	return &Output{
-		config:    config,
-		stream:    strm,
-		logger:    parent,
-		ctx:       ctx,
-		ctxCancel: ctxCancel,
-	}, nil
+// Log implements logger.Writer.
+func (o *Output) Log(level logger.Level, format string, args ...any) {
+	o.logger.Log(level, "[transcoder output %s] "+format, append([]any{o.config.Path}, args...)...)
 }
 
 // Start starts the transcoding output.
 func (o *Output) Start(inputStream *stream.Stream) error {
-	if o.active {
+	if o.done != nil {
 		return fmt.Errorf("output already active")
 	}
 
-	o.logger.Log(logger.Info, "starting transcoding output %s", o.config.Path)
+	o.Log(logger.Info, "starting")
 
-	// Build FFmpeg command
-	args := o.buildFFmpegArgs()
-	o.process = exec.CommandContext(o.ctx, "ffmpeg", args...)
+	o.ctx, o.ctxCancel = context.WithCancel(context.Background())
+	o.done = make(chan struct{})
 
-	// Setup pipes
-	var err error
-	o.stdin, err = o.process.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
-	}
+	go o.run(inputStream)
 
-	o.stdout, err = o.process.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	o.stderr, err = o.process.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	// Start FFmpeg process
-	if err := o.process.Start(); err != nil {
-		return fmt.Errorf("failed to start FFmpeg: %w", err)
-	}
-
-	o.active = true
-
-	// Start data processing goroutines
-	o.wg.Add(3)
-	go o.inputProcessor(inputStream)
-	go o.outputProcessor()
-	go o.stderrMonitor()
-
-	o.logger.Log(logger.Info, "transcoding output %s started", o.config.Path)
 	return nil
 }
 
 // Stop stops the transcoding output.
 func (o *Output) Stop() {
-	if !o.active {
+	if o.done == nil {
 		return
 	}
 
-	o.logger.Log(logger.Info, "stopping transcoding output %s", o.config.Path)
-	o.active = false
 	o.ctxCancel()
+	<-o.done
+	o.done = nil
+
+	o.Log(logger.Info, "stopped")
+}
+
+// GetStream returns the output stream.
+// It returns nil when FFmpeg is not producing data.
+func (o *Output) GetStream() *stream.Stream {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.stream
+}
 
-	// Close stdin to signal EOF to FFmpeg
-	if o.stdin != nil {
-		o.stdin.Close()
+// APIItem returns the output description used by the API and metrics.
+func (o *Output) APIItem() *defs.APITranscoderOutput {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	return &defs.APITranscoderOutput{
+		Name:     o.config.Path,
+		Type:     o.config.Type,
+		Ready:    o.stream != nil,
+		FPS:      o.fps,
+		Restarts: atomic.LoadUint64(&o.restarts),
 	}
+}
 
-	// Wait for goroutines
-	o.wg.Wait()
+func (o *Output) run(inputStream *stream.Stream) {
+	defer close(o.done)
 
-	// Kill FFmpeg process if still running
-	if o.process != nil && o.process.Process != nil {
-		done := make(chan bool, 1)
-		go func() {
-			o.process.Wait()
-			done <- true
-		}()
+	statsDone := make(chan struct{})
+	go o.runStats(o.ctx, statsDone)
+	defer func() { <-statsDone }()
 
-		select {
-		case <-done:
-			// Process exited gracefully
-		case <-time.After(2 * time.Second):
-			// Force kill
-			o.process.Process.Kill()
-			o.process.Wait()
+	supervise(o.ctx, o.pool, o, func() *process {
+		o.resetStall()
+
+		return &process{
+			args:        o.buildFFmpegArgs(),
+			inputStream: inputStream,
+			logger:      o,
+			readOutput:  o.readOutput,
+			checkStall:  o.checkStall,
 		}
-	}
+	}, o.restarted)
+}
 
-	o.logger.Log(logger.Info, "transcoding output %s stopped", o.config.Path)
+func (o *Output) resetStall() {
+	atomic.StoreInt64(&o.lastOutput, time.Now().UnixNano())
 }
 
-// GetStream returns the output stream.
-func (o *Output) GetStream() *stream.Stream {
-	return o.stream
+func (o *Output) checkStall() error {
+	if time.Since(time.Unix(0, atomic.LoadInt64(&o.lastOutput))) >= o.stallTimeout {
+		return fmt.Errorf("output '%s' produced nothing in the last %v, encoder is stalled",
+			o.config.Path, o.stallTimeout)
+	}
+	return nil
 }
 
-// inputProcessor processes input data and sends to FFmpeg.
-func (o *Output) inputProcessor(inputStream *stream.Stream) {
-	defer o.wg.Done()
-
-	// Create reader
-	o.reader = &stream.Reader{Parent: o.logger}
-	inputStream.AddReader(o.reader)
-	defer inputStream.RemoveReader(o.reader)
-
-	// Create MPEG-TS writer
-	bw := bufio.NewWriter(o.stdin)
-	defer bw.Flush()
-
-	// Use existing MPEG-TS conversion logic
-	err := mpegts.FromStream(
-		inputStream.Desc,
-		o.reader,
-		bw,
-		nil, // No SRT connection needed
-		time.Second,
-	)
+func (o *Output) restarted() {
+	atomic.AddUint64(&o.restarts, 1)
+}
 
-	if err != nil && err != io.EOF {
-		o.logger.Log(logger.Warn, "input processor error: %v", err)
+// readOutput reads the MPEG-TS output of FFmpeg and exposes it as a stream.
+func (o *Output) readOutput(r io.Reader) error {
+	mr := &mpegts.EnhancedReader{R: r}
+	err := mr.Initialize()
+	if err != nil {
+		return err
 	}
-}
 
-// outputProcessor processes FFmpeg output and writes to stream.
-func (o *Output) outputProcessor() {
-	defer o.wg.Done()
-
-	// Create enhanced MPEG-TS reader
-	enhancedReader := &mpegts.EnhancedReader{R: o.stdout}
-	if err := enhancedReader.Initialize(); err != nil {
-		// EOF is expected when FFmpeg closes, don't log it as error during shutdown
-		if err == io.EOF {
-			select {
-			case <-o.ctx.Done():
-				o.logger.Log(logger.Debug, "MPEG-TS reader closed (FFmpeg terminated)")
-				return
-			default:
-				o.logger.Log(logger.Warn, "MPEG-TS reader got EOF before FFmpeg started: %v", err)
-			}
-		} else {
-			o.logger.Log(logger.Error, "failed to initialize MPEG-TS reader: %v", err)
-		}
-		return
+	var strm *stream.Stream
+
+	medias, err := mpegts.ToStream(mr, &strm, o)
+	if err != nil {
+		return err
 	}
 
-	// Convert MPEG-TS to stream
-	// ToStream expects a pointer to stream pointer
-	var streamPtr *stream.Stream = o.stream
-	medias, err := mpegts.ToStream(enhancedReader, &streamPtr, o.logger)
-	if err != nil && err != io.EOF {
-		o.logger.Log(logger.Warn, "failed to convert MPEG-TS to stream: %v, keeping initial description", err)
-		// Don't return here, keep the initial description and continue reading
-	} else if len(medias) > 0 {
-		// Update stream description with actual medias from FFmpeg
-		o.stream.Desc = &description.Session{Medias: medias}
-		o.logger.Log(logger.Info, "updated stream description with %d medias from FFmpeg output", len(medias))
-	} else {
-		o.logger.Log(logger.Debug, "no medias found in FFmpeg output, keeping initial description")
+	strm = &stream.Stream{
+		WriteQueueSize:     outputQueueSize,
+		RTPMaxPayloadSize:  outputMaxPayload,
+		Desc:               &description.Session{Medias: medias},
+		GenerateRTPPackets: true,
+		FillNTP:            true,
+		Parent:             o,
+	}
+	err = strm.Initialize()
+	if err != nil {
+		return err
 	}
+	defer strm.Close()
 
-	// Start reading from the enhanced reader in a goroutine
-	readErr := make(chan error, 1)
-	go func() {
-		for {
-			err := enhancedReader.Read()
-			if err != nil {
-				readErr <- err
-				return
-			}
-		}
+	statsReader := o.newStatsReader(strm.Desc)
+	strm.AddReader(statsReader)
+	defer strm.RemoveReader(statsReader)
+
+	o.mutex.Lock()
+	o.stream = strm
+	o.mutex.Unlock()
+
+	defer func() {
+		o.mutex.Lock()
+		o.stream = nil
+		o.mutex.Unlock()
 	}()
 
-	// Wait for context cancellation or read error
-	select {
-	case <-o.ctx.Done():
-		// Context cancelled, stop reading
-		return
-	case err := <-readErr:
-		if err != nil && err != io.EOF {
-			o.logger.Log(logger.Warn, "MPEG-TS reader error: %v", err)
+	o.Log(logger.Info, "ready: %s", defs.MediasInfo(medias))
+
+	for {
+		err = mr.Read()
+		if err != nil {
+			return err
 		}
-		return
 	}
 }
 
-// stderrMonitor monitors FFmpeg stderr for errors.
-func (o *Output) stderrMonitor() {
-	defer o.wg.Done()
+// newStatsReader allocates a reader that counts the video frames produced by FFmpeg
+// and keeps track of the last time FFmpeg produced something.
+func (o *Output) newStatsReader(desc *description.Session) *stream.Reader {
+	r := &stream.Reader{Parent: o}
 
-	scanner := bufio.NewScanner(o.stderr)
-	for scanner.Scan() {
-		line := scanner.Text()
-		o.logger.Log(logger.Debug, "FFmpeg: %s", line)
+	videoFound := false
+
+	for _, medi := range desc.Medias {
+		countFrames := !videoFound && medi.Type == description.MediaTypeVideo
+		if countFrames {
+			videoFound = true
+		}
+
+		r.OnData(medi, medi.Formats[0], func(u *unit.Unit) error {
+			if !u.NilPayload() {
+				atomic.StoreInt64(&o.lastOutput, time.Now().UnixNano())
+
+				if countFrames {
+					atomic.AddUint64(&o.framesOut, 1)
+				}
+			}
+			return nil
+		})
+	}
+
+	return r
+}
+
+// runStats periodically computes the output frame rate.
+func (o *Output) runStats(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	t := time.NewTicker(statsInterval)
+	defer t.Stop()
+
+	prevFrames := atomic.LoadUint64(&o.framesOut)
+	prevTime := time.Now()
+
+	for {
+		select {
+		case now := <-t.C:
+			frames := atomic.LoadUint64(&o.framesOut)
+
+			o.mutex.Lock()
+			o.fps = float64(frames-prevFrames) / now.Sub(prevTime).Seconds()
+			o.mutex.Unlock()
+
+			prevFrames = frames
+			prevTime = now
+
+		case <-ctx.Done():
+			o.mutex.Lock()
+			o.fps = 0
+			o.mutex.Unlock()
+			return
+		}
 	}
 }
 
@@ -308,37 +330,3 @@ func (o *Output) buildFFmpegArgs() []string {
 
 	return args
 }
-
-// createStreamDescription creates stream description for output.
-func createStreamDescription(config *conf.SRTTranscodingOutput) *description.Session {
-	desc := &description.Session{}
-
-	if config.Type == "video" {
-		videoTrack := &description.Media{
-			Type: description.MediaTypeVideo,
-			Formats: []format.Format{
-				&format.H264{
-					PayloadTyp:        96,
-					SPS:               []byte{0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02, 0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04, 0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9, 0x20},
-					PPS:               []byte{0x08, 0x06, 0x07, 0x08},
-					PacketizationMode: 1,
-				},
-			},
-		}
-		desc.Medias = append(desc.Medias, videoTrack)
-	}
-
-	audioTrack := &description.Media{
-		Type: description.MediaTypeAudio,
-		Formats: []format.Format{
-			&format.Opus{
-				PayloadTyp:   97,
-				ChannelCount: 2,
-			},
-		},
-	}
-	desc.Medias = append(desc.Medias, audioTrack)
-
-	return desc
-}
-
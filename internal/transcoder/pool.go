@@ -0,0 +1,83 @@
+package transcoder
+
+import (
+	"context"
+	"sync"
+)
+
+// ProcessPool limits the number of FFmpeg processes that run at the same time,
+// across all paths.
+type ProcessPool struct {
+	MaxProcesses int
+
+	mutex   sync.Mutex
+	running int
+	changed chan struct{}
+}
+
+// Initialize initializes ProcessPool.
+func (p *ProcessPool) Initialize() {
+	p.changed = make(chan struct{})
+}
+
+// SetMaxProcesses changes the maximum number of processes.
+// Zero means no limit.
+func (p *ProcessPool) SetMaxProcesses(n int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.MaxProcesses = n
+	p.notify()
+}
+
+// Running returns the number of running processes.
+func (p *ProcessPool) Running() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.running
+}
+
+// TryAcquire takes a process slot if it's available.
+func (p *ProcessPool) TryAcquire() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.MaxProcesses == 0 || p.running < p.MaxProcesses {
+		p.running++
+		return true
+	}
+	return false
+}
+
+// Acquire waits until a process slot is available, then takes it.
+func (p *ProcessPool) Acquire(ctx context.Context) error {
+	for {
+		p.mutex.Lock()
+		changed := p.changed
+		p.mutex.Unlock()
+
+		if p.TryAcquire() {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release releases a process slot.
+func (p *ProcessPool) Release() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.running--
+	p.notify()
+}
+
+func (p *ProcessPool) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
@@ -0,0 +1,57 @@
+package transcoder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessPool(t *testing.T) {
+	p := &ProcessPool{MaxProcesses: 2}
+	p.Initialize()
+
+	err := p.Acquire(context.Background())
+	require.NoError(t, err)
+
+	err = p.Acquire(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, 2, p.Running())
+
+	// the pool is full
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer ctxCancel()
+	err = p.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan struct{})
+	go func() {
+		err2 := p.Acquire(context.Background())
+		require.NoError(t, err2)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("should not happen")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Release()
+	<-acquired
+	require.Equal(t, 2, p.Running())
+
+	// raising the limit wakes up waiting processes
+	acquired = make(chan struct{})
+	go func() {
+		err2 := p.Acquire(context.Background())
+		require.NoError(t, err2)
+		close(acquired)
+	}()
+
+	p.SetMaxProcesses(0)
+	<-acquired
+	require.Equal(t, 3, p.Running())
+}
@@ -0,0 +1,194 @@
+package transcoder
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/mpegts"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+// process is a FFmpeg process that reads the input stream from stdin
+// and writes MPEG-TS to stdout.
+type process struct {
+	args        []string
+	inputStream *stream.Stream
+	logger      logger.Writer
+
+	// called with FFmpeg stdout, must return when the reader returns an error
+	readOutput func(r io.Reader) error
+
+	// returns an error when the process stopped producing output
+	checkStall func() error
+}
+
+// supervise runs a process until ctx is canceled.
+// When FFmpeg exits, it is restarted with an exponential backoff.
+func supervise(
+	ctx context.Context,
+	pool *ProcessPool,
+	l logger.Writer,
+	newProcess func() *process,
+	onRestart func(),
+) {
+	pause := restartMinPause
+
+	for {
+		err := acquireProcess(ctx, pool, l)
+		if err != nil {
+			return
+		}
+
+		start := time.Now()
+		err = newProcess().run(ctx)
+
+		if pool != nil {
+			pool.Release()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// FFmpeg ran for a while, therefore the error is not persistent
+		if time.Since(start) >= restartResetAfter {
+			pause = restartMinPause
+		}
+
+		l.Log(logger.Warn, "FFmpeg exited: %v, restarting in %v", err, pause)
+
+		select {
+		case <-time.After(pause):
+			onRestart()
+		case <-ctx.Done():
+			return
+		}
+
+		pause = min(pause*2, restartMaxPause)
+	}
+}
+
+// acquireProcess waits for a free slot in the process pool.
+func acquireProcess(ctx context.Context, pool *ProcessPool, l logger.Writer) error {
+	if pool == nil || pool.TryAcquire() {
+		return nil
+	}
+
+	l.Log(logger.Warn, "maximum number of transcoding processes reached, waiting for a free slot")
+
+	return pool.Acquire(ctx)
+}
+
+func (p *process) run(ctx context.Context) error {
+	cmd := exec.Command("ffmpeg", p.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start FFmpeg: %w", err)
+	}
+
+	go p.readStderr(stderr)
+
+	runCtx, runCtxCancel := context.WithCancel(ctx)
+
+	inputErr := make(chan error, 1)
+	go func() {
+		inputErr <- p.writeInput(runCtx, stdin)
+	}()
+
+	outputErr := make(chan error, 1)
+	go func() {
+		outputErr <- p.readOutput(stdout)
+	}()
+
+	stallTicker := time.NewTicker(stallCheckInterval)
+	defer stallTicker.Stop()
+
+outer:
+	for {
+		select {
+		case err = <-inputErr:
+			inputErr = nil
+			break outer
+
+		case err = <-outputErr:
+			outputErr = nil
+			break outer
+
+		case <-stallTicker.C:
+			err = p.checkStall()
+			if err != nil {
+				break outer
+			}
+
+		case <-ctx.Done():
+			break outer
+		}
+	}
+
+	runCtxCancel()
+	stdin.Close()
+	cmd.Process.Kill() //nolint:errcheck
+
+	if inputErr != nil {
+		<-inputErr
+	}
+	if outputErr != nil {
+		<-outputErr
+	}
+
+	cmd.Wait() //nolint:errcheck
+
+	return err
+}
+
+// writeInput writes the input stream to FFmpeg stdin, in MPEG-TS format.
+func (p *process) writeInput(ctx context.Context, w io.Writer) error {
+	reader := &stream.Reader{Parent: p.logger}
+	bw := bufio.NewWriter(w)
+
+	err := mpegts.FromStream(p.inputStream.Desc, reader, bw, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	p.inputStream.AddReader(reader)
+	defer p.inputStream.RemoveReader(reader)
+
+	select {
+	case err = <-reader.Error():
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// readStderr logs FFmpeg stderr.
+func (p *process) readStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.logger.Log(logger.Debug, "FFmpeg: %s", scanner.Text())
+	}
+}
@@ -0,0 +1,170 @@
+package transcoder
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+const (
+	tsPacketSize = 188
+	tsSyncByte   = 0x47
+	tsPATPID     = 0
+	tsTablePAT   = 0x00
+	tsTablePMT   = 0x02
+)
+
+// programDemuxer splits a multi-program MPEG-TS stream into single-program MPEG-TS streams.
+// Program i+1 is written into Writers[i].
+//
+// The PAT is written into every stream, while PMTs and elementary streams
+// are written into the streams of their programs only.
+// Elementary streams can belong to multiple programs.
+// Sections spanning multiple packets are not supported, since tables
+// produced by FFmpeg always fit into a single packet.
+type programDemuxer struct {
+	R       io.Reader
+	Writers []io.Writer
+
+	pmtPIDs map[uint16]int
+	esPIDs  map[uint16][]int
+}
+
+func (d *programDemuxer) initialize() {
+	d.pmtPIDs = make(map[uint16]int)
+	d.esPIDs = make(map[uint16][]int)
+}
+
+func (d *programDemuxer) run() error {
+	buf := make([]byte, tsPacketSize)
+
+	for {
+		_, err := io.ReadFull(d.R, buf)
+		if err != nil {
+			return err
+		}
+
+		err = d.processPacket(buf)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *programDemuxer) processPacket(pkt []byte) error {
+	if pkt[0] != tsSyncByte {
+		return fmt.Errorf("invalid sync byte")
+	}
+
+	pid := uint16(pkt[1]&0x1F)<<8 | uint16(pkt[2])
+
+	if pid == tsPATPID {
+		if sec := tsSection(pkt); sec != nil && sec[0] == tsTablePAT {
+			d.parsePAT(sec)
+		}
+
+		for _, w := range d.Writers {
+			_, err := w.Write(pkt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if i, ok := d.pmtPIDs[pid]; ok {
+		if sec := tsSection(pkt); sec != nil && sec[0] == tsTablePMT {
+			d.parsePMT(i, sec)
+		}
+
+		_, err := d.Writers[i].Write(pkt)
+		return err
+	}
+
+	if programs, ok := d.esPIDs[pid]; ok {
+		for _, i := range programs {
+			_, err := d.Writers[i].Write(pkt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// other packets (SDT, null packets, unknown programs) are discarded
+	return nil
+}
+
+func (d *programDemuxer) parsePAT(sec []byte) {
+	// skip the header and the CRC
+	for pos := 8; pos+4 <= len(sec)-4; pos += 4 {
+		programNumber := int(sec[pos])<<8 | int(sec[pos+1])
+		pmtPID := uint16(sec[pos+2]&0x1F)<<8 | uint16(sec[pos+3])
+
+		// program 0 is the network PID
+		if programNumber >= 1 && programNumber <= len(d.Writers) {
+			d.pmtPIDs[pmtPID] = programNumber - 1
+		}
+	}
+}
+
+func (d *programDemuxer) parsePMT(i int, sec []byte) {
+	if len(sec) < 12 {
+		return
+	}
+
+	programInfoLen := int(sec[10]&0x0F)<<8 | int(sec[11])
+
+	// skip the header, program descriptors and the CRC
+	for pos := 12 + programInfoLen; pos+5 <= len(sec)-4; {
+		esPID := uint16(sec[pos+1]&0x1F)<<8 | uint16(sec[pos+2])
+		esInfoLen := int(sec[pos+3]&0x0F)<<8 | int(sec[pos+4])
+
+		if !slices.Contains(d.esPIDs[esPID], i) {
+			d.esPIDs[esPID] = append(d.esPIDs[esPID], i)
+		}
+
+		pos += 5 + esInfoLen
+	}
+}
+
+// tsSection returns the PSI section that starts in a packet.
+func tsSection(pkt []byte) []byte {
+	// payload_unit_start_indicator
+	if pkt[1]&0x40 == 0 {
+		return nil
+	}
+
+	payload := tsPayload(pkt)
+	if len(payload) == 0 {
+		return nil
+	}
+
+	pointer := int(payload[0])
+	if 1+pointer+3 > len(payload) {
+		return nil
+	}
+	sec := payload[1+pointer:]
+
+	sectionLen := int(sec[1]&0x0F)<<8 | int(sec[2])
+	if 3+sectionLen > len(sec) {
+		return nil
+	}
+
+	return sec[:3+sectionLen]
+}
+
+func tsPayload(pkt []byte) []byte {
+	adaptationFieldControl := (pkt[3] >> 4) & 0x03
+	pos := 4
+
+	if adaptationFieldControl&0x02 != 0 {
+		pos += 1 + int(pkt[4])
+	}
+
+	if adaptationFieldControl&0x01 == 0 || pos >= len(pkt) {
+		return nil
+	}
+
+	return pkt[pos:]
+}
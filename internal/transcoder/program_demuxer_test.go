@@ -0,0 +1,86 @@
+package transcoder
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tsPacket(pid uint16, pusi bool, payload []byte) []byte {
+	pkt := make([]byte, tsPacketSize)
+	pkt[0] = tsSyncByte
+	pkt[1] = byte(pid >> 8)
+	if pusi {
+		pkt[1] |= 0x40
+	}
+	pkt[2] = byte(pid)
+	pkt[3] = 0x10
+	n := copy(pkt[4:], payload)
+	for i := 4 + n; i < tsPacketSize; i++ {
+		pkt[i] = 0xFF
+	}
+	return pkt
+}
+
+func psiPacket(pid uint16, tableID byte, header []byte, body []byte) []byte {
+	sectionLen := len(header) + len(body) + 4
+	sec := []byte{tableID, 0xB0 | byte(sectionLen>>8), byte(sectionLen)}
+	sec = append(sec, header...)
+	sec = append(sec, body...)
+	sec = append(sec, 0, 0, 0, 0) // CRC is not checked
+
+	return tsPacket(pid, true, append([]byte{0}, sec...))
+}
+
+func patPacket(programs map[uint16]uint16) []byte {
+	var body []byte
+	for num := uint16(1); int(num) <= len(programs); num++ {
+		pmtPID := programs[num]
+		body = append(body, byte(num>>8), byte(num), 0xE0|byte(pmtPID>>8), byte(pmtPID))
+	}
+	return psiPacket(0, tsTablePAT, []byte{0, 1, 0xC1, 0, 0}, body)
+}
+
+func pmtPacket(pid uint16, programNumber uint16, esPIDs []uint16) []byte {
+	header := []byte{
+		byte(programNumber >> 8), byte(programNumber), 0xC1, 0, 0,
+		0xE0 | byte(esPIDs[0]>>8), byte(esPIDs[0]), // PCR PID
+		0xF0, 0x02, 0x0A, 0x00, // program info with a descriptor
+	}
+	var body []byte
+	for _, esPID := range esPIDs {
+		body = append(body, 0x1B, 0xE0|byte(esPID>>8), byte(esPID), 0xF0, 0x00)
+	}
+	return psiPacket(pid, tsTablePMT, header, body)
+}
+
+func TestProgramDemuxer(t *testing.T) {
+	pat := patPacket(map[uint16]uint16{1: 0x1000, 2: 0x1001})
+	pmt1 := pmtPacket(0x1000, 1, []uint16{0x100})
+	pmt2 := pmtPacket(0x1001, 2, []uint16{0x101, 0x102, 0x100})
+	sdt := tsPacket(0x11, true, []byte{1, 2, 3})
+	es1 := tsPacket(0x100, true, []byte{4, 5, 6})
+	es2 := tsPacket(0x101, true, []byte{7, 8, 9})
+	es3 := tsPacket(0x102, false, []byte{10, 11, 12})
+
+	var in bytes.Buffer
+	for _, pkt := range [][]byte{pat, sdt, pmt1, pmt2, es1, es2, es3, es1} {
+		in.Write(pkt)
+	}
+
+	var out1, out2 bytes.Buffer
+
+	d := &programDemuxer{
+		R:       &in,
+		Writers: []io.Writer{&out1, &out2},
+	}
+	d.initialize()
+
+	err := d.run()
+	require.Equal(t, io.EOF, err)
+
+	require.Equal(t, bytes.Join([][]byte{pat, pmt1, es1, es1}, nil), out1.Bytes())
+	require.Equal(t, bytes.Join([][]byte{pat, pmt2, es1, es2, es3, es1}, nil), out2.Bytes())
+}